@@ -3,12 +3,27 @@ package main
 import (
 	"Fyne-on/pkg/crawler"
 	"Fyne-on/pkg/database"
+	"Fyne-on/pkg/graph"
+	"Fyne-on/pkg/httpcache"
+	"Fyne-on/pkg/markov"
+	"Fyne-on/pkg/models"
+	"Fyne-on/pkg/proxy"
+	"Fyne-on/pkg/scraper"
 	"Fyne-on/pkg/storage"
+	"bufio"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"io"
 	"log"
+	"math"
+	"net/url"
+	"os"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gofiber/fiber/v3"
 )
@@ -21,27 +36,137 @@ func main() {
 	defer db.Close()
 
 	storageService := storage.NewStorageService(db)
+	if _, err := storageService.Migrate(storage.MigrateOptions{}); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
 
 	githubCrawler := crawler.NewGithubCrawler(storageService)
 	githubCrawler.SetMaxIterations(100)
 	githubCrawler.SetDelayMs(5)
 
+	if mc, err := storageService.LoadMarkovChain(); err == nil {
+		githubCrawler.SetMarkovChain(mc)
+		log.Printf("Restored Markov chain: %d states, %d transitions", mc.GetStateCount(), mc.GetTransitionCount())
+	}
+
+	// SELECTOR_PROFILE points at a YAML/JSON file overriding the built-in
+	// HTML selectors; it is reloaded when the file changes
+	if path := os.Getenv("SELECTOR_PROFILE"); path != "" {
+		selectors, err := scraper.LoadSelectors(path)
+		if err != nil {
+			log.Fatalf("Failed to load selector profile: %v", err)
+		}
+		githubCrawler.HTMLScraper().SetSelectors(selectors)
+		go selectors.Watch(5*time.Second, nil)
+		log.Printf("Loaded selector profile %s (version %s)", path, selectors.Profile().Version)
+	}
+
+	// HTTP_CACHE_DIR keeps API and HTML responses on disk for HTTP_CACHE_TTL
+	// (default 24h, 0 = forever). HTTP_CACHE_OFFLINE=1 replays only cached
+	// responses and never touches the network.
+	var httpCache *httpcache.Cache
+	if dir := os.Getenv("HTTP_CACHE_DIR"); dir != "" {
+		ttl := 24 * time.Hour
+		if v := os.Getenv("HTTP_CACHE_TTL"); v != "" {
+			if ttl, err = time.ParseDuration(v); err != nil {
+				log.Fatalf("Invalid HTTP_CACHE_TTL: %v", err)
+			}
+		}
+		httpCache, err = httpcache.Open(dir, ttl)
+		if err != nil {
+			log.Fatalf("Failed to open HTTP cache: %v", err)
+		}
+		offline := os.Getenv("HTTP_CACHE_OFFLINE") == "1"
+		githubCrawler.SetHTTPCache(httpCache, offline)
+		log.Printf("HTTP cache at %s (ttl %v, offline %v)", dir, ttl, offline)
+	}
+
+	// PROXY_CONFIG names a YAML/JSON file of named proxies; jobs pick one
+	// with "proxy" and the default applies until then
+	var proxySettings *proxy.Settings
+	if path := os.Getenv("PROXY_CONFIG"); path != "" {
+		proxySettings, err = proxy.LoadSettings(path)
+		if err != nil {
+			log.Fatalf("Failed to load proxy settings: %v", err)
+		}
+		cfg, _ := proxySettings.Get("")
+		if err := githubCrawler.SetProxy(cfg); err != nil {
+			log.Fatalf("Failed to configure proxy: %v", err)
+		}
+		log.Printf("Outbound requests via %s", cfg.Redacted())
+	}
+
 	currentCrawlerConfig := struct {
-		StartUsername string
-		MaxIterations int
-		DelayMs       int
-		TokenSet      bool
-		UsePlaywright bool
+		StartUsername    string
+		MaxIterations    int
+		DelayMs          int
+		TokenSet         bool
+		UsePlaywright    bool
+		FrontierStrategy string
+		FrontierCap      int
+		MaxDepth         int
+		UserAgent        string
+		Proxy            string
 	}{
-		StartUsername: "",
-		MaxIterations: 20000,
-		DelayMs:       1000,
-		TokenSet:      false,
-		UsePlaywright: false,
+		StartUsername:    "",
+		MaxIterations:    20000,
+		DelayMs:          1000,
+		TokenSet:         false,
+		UsePlaywright:    false,
+		FrontierStrategy: "fifo",
+		FrontierCap:      100,
+		MaxDepth:         0,
+		UserAgent:        scraper.DefaultUserAgent,
+	}
+	if proxySettings != nil {
+		currentCrawlerConfig.Proxy = proxySettings.Default
 	}
 
 	app := fiber.New()
 
+	// pageParams reads limit and cursor; limit defaults to 100, max 1000
+	pageParams := func(c fiber.Ctx) storage.Page {
+		limit, _ := strconv.Atoi(c.Query("limit"))
+		if limit <= 0 {
+			limit = storage.DefaultPageLimit
+		}
+		if limit > storage.MaxPageLimit {
+			limit = storage.MaxPageLimit
+		}
+		return storage.Page{Limit: limit, Cursor: c.Query("cursor")}
+	}
+
+	// repoParams reads the :owner and :name of a repo route. Namespaced
+	// owners ("gitlab.com/group") hold a slash, so clients escape it:
+	// /repos/gitlab.com%2Fgroup/app
+	repoParams := func(c fiber.Ctx) (string, string) {
+		unescape := func(v string) string {
+			if u, err := url.PathUnescape(v); err == nil {
+				return u
+			}
+			return v
+		}
+		return unescape(c.Params("owner")), unescape(c.Params("name"))
+	}
+
+	// listError answers a failed listing, 400 for cursors it did not issue
+	listError := func(c fiber.Ctx, err error) error {
+		if errors.Is(err, storage.ErrInvalidCursor) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// pageJSON is the response of every list endpoint; next_cursor is ""
+	// on the last page
+	pageJSON := func(c fiber.Ctx, items interface{}, count int, next string) error {
+		return c.JSON(fiber.Map{
+			"items":       items,
+			"count":       count,
+			"next_cursor": next,
+		})
+	}
+
 	app.Get("/health", func(c fiber.Ctx) error {
 		return c.JSON(fiber.Map{
 			"status":  "ok",
@@ -75,13 +200,16 @@ func main() {
 		expandQ := c.Query("expand")
 		expand := expandQ == "1" || expandQ == "true" || expandQ == "full"
 
-		repos, err := storageService.GetAllRepos()
+		repos, next, err := storageService.ListRepos(storage.RepoQuery{Source: c.Query("source")}, pageParams(c))
 		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+			return listError(c, err)
 		}
 
 		result := make([]fiber.Map, 0, len(repos))
 		for _, repo := range repos {
+			if repo.Source == "" {
+				repo.Source = models.SourceGitHub
+			}
 			hash := repo.Hash
 			if hash == "" {
 				h := sha256.Sum256([]byte(repo.Owner + "/" + repo.Name))
@@ -94,6 +222,7 @@ func main() {
 				"name":     repo.Name,
 				"language": repo.Language,
 				"url":      repo.URL,
+				"source":   repo.Source,
 			}
 
 			// NEW: include additional fields if requested
@@ -101,6 +230,12 @@ func main() {
 				item["url"] = repo.URL
 				item["description"] = repo.Description
 				item["stars"] = repo.Stars
+				item["forks"] = repo.Forks
+				item["watchers"] = repo.Watchers
+				item["topics"] = repo.Topics
+				item["homepage"] = repo.Homepage
+				item["languages"] = repo.Languages
+				item["last_commit_at"] = repo.LastCommitAt
 				item["license"] = repo.License
 				item["has_open_license"] = repo.HasOpenLicense
 				item["updated_at"] = repo.UpdatedAt
@@ -108,24 +243,21 @@ func main() {
 			}
 
 			if includeCount {
-				issues, err := storageService.GetRepoIssues(repo.Owner + "/" + repo.Name)
-				if err == nil {
-					item["issues_count"] = len(issues)
-				} else {
-					item["issues_count"] = 0
-					log.Printf("failed to load issues for %s/%s: %v", repo.Owner, repo.Name, err)
+				count, err := storageService.CountRepoIssues(repo.Owner + "/" + repo.Name)
+				if err != nil {
+					log.Printf("failed to count issues for %s/%s: %v", repo.Owner, repo.Name, err)
 				}
+				item["issues_count"] = count
 			}
 
 			result = append(result, item)
 		}
-		return c.JSON(result)
+		return pageJSON(c, result, len(result), next)
 	})
 
 	// Get repository by owner and name
 	app.Get("/repos/:owner/:name", func(c fiber.Ctx) error {
-		owner := c.Params("owner")
-		name := c.Params("name")
+		owner, name := repoParams(c)
 
 		// NEW: optional expansion of fields
 		expandQ := c.Query("expand")
@@ -161,6 +293,12 @@ func main() {
 			"url":              repo.URL,
 			"description":      repo.Description,
 			"stars":            repo.Stars,
+			"forks":            repo.Forks,
+			"watchers":         repo.Watchers,
+			"topics":           repo.Topics,
+			"homepage":         repo.Homepage,
+			"languages":        repo.Languages,
+			"last_commit_at":   repo.LastCommitAt,
 			"license":          repo.License,
 			"has_open_license": repo.HasOpenLicense,
 			"updated_at":       repo.UpdatedAt,
@@ -169,49 +307,115 @@ func main() {
 	})
 
 	app.Get("/issues", func(c fiber.Ctx) error {
-		page, _ := strconv.Atoi(c.Query("page", "1"))
-		limit, _ := strconv.Atoi(c.Query("limit", "100"))
-		offset := (page - 1) * limit
-
-		issues, err := storageService.GetIssuesPage(limit, offset)
+		issues, next, err := storageService.ListIssues(storage.ActivityQuery{
+			State:  c.Query("state"),
+			Author: c.Query("author"),
+		}, pageParams(c))
 		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+			return listError(c, err)
 		}
-		return c.JSON(issues)
+		return pageJSON(c, issues, len(issues), next)
 	})
 
 	app.Get("/repos/:owner/:name/issues", func(c fiber.Ctx) error {
-		owner := c.Params("owner")
-		name := c.Params("name")
+		owner, name := repoParams(c)
 		repoID := owner + "/" + name
 
-		issues, err := storageService.GetRepoIssues(repoID)
+		issues, next, err := storageService.ListIssues(storage.ActivityQuery{
+			RepoID: repoID,
+			State:  c.Query("state"),
+			Author: c.Query("author"),
+		}, pageParams(c))
 		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+			return listError(c, err)
 		}
 
-		return c.JSON(issues)
+		return pageJSON(c, issues, len(issues), next)
 	})
 
 	app.Get("/repos/:owner/:name/prs", func(c fiber.Ctx) error {
-		owner := c.Params("owner")
-		name := c.Params("name")
+		owner, name := repoParams(c)
+		repoID := owner + "/" + name
+
+		prs, next, err := storageService.ListPullRequests(storage.ActivityQuery{
+			RepoID: repoID,
+			State:  c.Query("state"),
+			Author: c.Query("author"),
+		}, pageParams(c))
+		if err != nil {
+			return listError(c, err)
+		}
+
+		return pageJSON(c, prs, len(prs), next)
+	})
+
+	// Change log of a repository, or of one of its issues (?issue=ID) or
+	// pull requests (?pr=ID), newest first
+	app.Get("/repos/:owner/:name/history", func(c fiber.Ctx) error {
+		owner, name := repoParams(c)
+		repoID := owner + "/" + name
+
+		if _, err := storageService.GetRepo(owner, name); err != nil {
+			return c.Status(404).JSON(fiber.Map{"error": "repository not found"})
+		}
+
+		key := "repo:" + repoID
+		if id := c.Query("issue"); id != "" {
+			key = "issue:" + repoID + "/" + id
+		} else if id := c.Query("pr"); id != "" {
+			key = "pr:" + repoID + "/" + id
+		}
+
+		changes, next, err := storageService.GetHistory(key, pageParams(c))
+		if err != nil {
+			return listError(c, err)
+		}
+		return pageJSON(c, changes, len(changes), next)
+	})
+
+	app.Get("/repos/:owner/:name/dependencies", func(c fiber.Ctx) error {
+		owner, name := repoParams(c)
 		repoID := owner + "/" + name
 
-		prs, err := storageService.GetRepoPullRequests(repoID)
+		list, err := storageService.GetDependencies(repoID)
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 		}
 
-		return c.JSON(prs)
+		manifests := []string{}
+		if m, err := storageService.GetRepoManifests(repoID); err == nil {
+			manifests = m.Files
+		}
+		return c.JSON(fiber.Map{
+			"repo":         repoID,
+			"manifests":    manifests,
+			"count":        len(list),
+			"dependencies": list,
+		})
 	})
 
-	app.Get("/contacts", func(c fiber.Ctx) error {
-		contacts, err := storageService.GetAllContacts()
+	app.Get("/repos/:owner/:name/dependents", func(c fiber.Ctx) error {
+		owner, name := repoParams(c)
+		repoID := owner + "/" + name
+
+		list, err := storageService.GetDependents(repoID)
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 		}
 
+		return c.JSON(fiber.Map{
+			"repo":       repoID,
+			"count":      len(list),
+			"dependents": list,
+		})
+	})
+
+	app.Get("/contacts", func(c fiber.Ctx) error {
+		contacts, next, err := storageService.ListContacts(pageParams(c))
+		if err != nil {
+			return listError(c, err)
+		}
+
 		result := make([]fiber.Map, 0, len(contacts))
 		for _, ct := range contacts {
 			result = append(result, fiber.Map{
@@ -219,7 +423,7 @@ func main() {
 				"hash":  ct.Hash,
 			})
 		}
-		return c.JSON(result)
+		return pageJSON(c, result, len(result), next)
 	})
 
 	app.Get("/contacts/:login", func(c fiber.Ctx) error {
@@ -237,11 +441,46 @@ func main() {
 	})
 
 	type CrawlRequest struct {
-		StartUsernames []string `json:"start_usernames"`
-		MaxIterations  int      `json:"max_iterations"`
-		DelayMs        int      `json:"delay_ms"`
-		GitHubToken    string   `json:"github_token"`
-		UsePlaywright  bool     `json:"use_playwright"`
+		StartUsernames   []string `json:"start_usernames"`
+		MaxIterations    int      `json:"max_iterations"`
+		DelayMs          int      `json:"delay_ms"`
+		GitHubToken      string   `json:"github_token"`
+		UsePlaywright    bool     `json:"use_playwright"`
+		FrontierStrategy string   `json:"frontier_strategy"`
+		// FrontierCap and MaxDepth bound this job's frontier (0 = unlimited);
+		// FrontierCap defaults to 100 when left out
+		FrontierCap  *int `json:"frontier_cap"`
+		MaxDepth     int  `json:"max_depth"`
+		FetchStarred bool `json:"fetch_starred"`
+		// UserAgent is sent by the HTML scraper and matched against robots.txt
+		UserAgent string `json:"user_agent"`
+		// Source is the code host to crawl: "github" (default), "gitlab" or
+		// "gitea" (also Forgejo).
+		// SourceURL points at a self-hosted instance, SourceToken
+		// authenticates against it.
+		Source      string `json:"source"`
+		SourceURL   string `json:"source_url"`
+		SourceToken string `json:"source_token"`
+		// Proxy names an entry of PROXY_CONFIG ("direct" for none) used by
+		// this job's requests
+		Proxy string `json:"proxy"`
+		// HTMLMaxPages limits how many pages of a user's repos or stars are scraped
+		HTMLMaxPages int `json:"html_max_pages"`
+		// Strategy selects how the API crawl traverses GitHub: "bfs" (default) or "markov"
+		Strategy            string  `json:"strategy"`
+		Seed                int64   `json:"seed"`
+		TeleportProbability float64 `json:"teleport_probability"`
+		// SeedMode "trending" starts from the contributors and owners of the
+		// trending repos for TrendingLanguage and TrendingSince
+		// SeedMode "dependencies" starts from the owners of repos that
+		// SeedRepos (every repo with stored dependencies if empty) depend on
+		SeedMode         string   `json:"seed_mode"`
+		TrendingLanguage string   `json:"trending_language"`
+		TrendingSince    string   `json:"trending_since"`
+		SeedRepos        []string `json:"seed_repos"`
+		// FetchDependencies parses each crawled repo's manifests into
+		// dependency edges
+		FetchDependencies bool `json:"fetch_dependencies"`
 	}
 
 	// Start crawler (fixed: manual JSON parsing + use CrawlStart)
@@ -293,6 +532,109 @@ func main() {
 			currentCrawlerConfig.DelayMs = req.DelayMs
 		}
 		currentCrawlerConfig.UsePlaywright = req.UsePlaywright
+		// Job settings apply to this job only, never to later ones or to
+		// crawls already running
+		opts := githubCrawler.JobDefaults()
+		opts.FrontierStrategy = req.FrontierStrategy
+		if req.FrontierStrategy != "" {
+			known := false
+			for _, name := range crawler.FrontierStrategies() {
+				known = known || name == req.FrontierStrategy
+			}
+			if !known {
+				return c.Status(400).JSON(fiber.Map{
+					"error":      "unknown frontier strategy: " + req.FrontierStrategy,
+					"strategies": crawler.FrontierStrategies(),
+				})
+			}
+		} else {
+			req.FrontierStrategy = currentCrawlerConfig.FrontierStrategy
+		}
+		if req.FrontierCap != nil {
+			opts.FrontierCap = *req.FrontierCap
+		}
+		opts.MaxDepth = req.MaxDepth
+		opts.FetchStarred = req.FetchStarred
+		opts.FetchDependencies = req.FetchDependencies
+		opts.HTMLMaxPages = req.HTMLMaxPages
+		opts.UserAgent = req.UserAgent
+
+		// A job without a proxy uses the default one
+		proxyName := currentCrawlerConfig.Proxy
+		if req.Proxy != "" {
+			if proxySettings == nil {
+				return c.Status(400).JSON(fiber.Map{"error": "no proxies configured (set PROXY_CONFIG)"})
+			}
+			cfg, ok := proxySettings.Get(req.Proxy)
+			if !ok {
+				return c.Status(400).JSON(fiber.Map{"error": "unknown proxy: " + req.Proxy})
+			}
+			opts.Proxy = &cfg
+			proxyName = req.Proxy
+		}
+
+		job, err := githubCrawler.NewJob(opts)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		if req.Strategy == "" {
+			req.Strategy = "bfs"
+		}
+		if req.Strategy != "bfs" && req.Strategy != "markov" {
+			return c.Status(400).JSON(fiber.Map{"error": "unknown strategy: " + req.Strategy})
+		}
+
+		var src crawler.Source = job
+		switch req.Source {
+		case "", models.SourceGitHub:
+			req.Source = models.SourceGitHub
+		case models.SourceGitLab:
+			gl, err := crawler.NewGitLabSource(req.SourceURL, req.SourceToken, job.HTTPClient())
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+			}
+			src = gl
+		case models.SourceGitea, "forgejo":
+			if req.SourceURL == "" {
+				return c.Status(400).JSON(fiber.Map{"error": "source_url is required for source gitea"})
+			}
+			gt, err := crawler.NewGiteaSource(req.SourceURL, req.SourceToken, job.HTTPClient())
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+			}
+			req.Source = models.SourceGitea
+			src = gt
+		default:
+			return c.Status(400).JSON(fiber.Map{"error": "unknown source: " + req.Source})
+		}
+		if req.Source != models.SourceGitHub && (req.UsePlaywright || req.Strategy == "markov" || req.SeedMode != "") {
+			return c.Status(400).JSON(fiber.Map{"error": "use_playwright, strategy markov and seed_mode only work with source github"})
+		}
+
+		switch req.SeedMode {
+		case "":
+		case "trending":
+			if !scraper.ValidTrendingPeriod(req.TrendingSince) {
+				return c.Status(400).JSON(fiber.Map{"error": "unknown trending_since: " + req.TrendingSince})
+			}
+			seeds, err := job.TrendingSeeds(req.TrendingLanguage, req.TrendingSince)
+			if err != nil {
+				return c.Status(502).JSON(fiber.Map{"error": err.Error()})
+			}
+			req.StartUsernames = seeds
+		case "dependencies":
+			seeds, err := job.DependencySeeds(req.SeedRepos)
+			if err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+			}
+			if len(seeds) == 0 {
+				return c.Status(404).JSON(fiber.Map{"error": "no uncrawled owners among the stored dependencies"})
+			}
+			req.StartUsernames = seeds
+		default:
+			return c.Status(400).JSON(fiber.Map{"error": "unknown seed_mode: " + req.SeedMode})
+		}
 
 		if len(req.StartUsernames) == 0 {
 			req.StartUsernames = []string{"microsoft"}
@@ -300,14 +642,33 @@ func main() {
 
 		if req.UsePlaywright {
 			go func(orgs []string) {
-				if err := githubCrawler.CrawlStartOrgsHTML(orgs); err != nil {
+				if err := job.CrawlStartOrgsHTML(orgs); err != nil {
 					log.Printf("HTML crawler error: %v", err)
 				}
 			}(req.StartUsernames)
+		} else if req.Strategy == "markov" {
+			go func(users []string, opts crawler.WalkOptions) {
+				if err := job.CrawlMarkovWalk(users, opts); err != nil {
+					log.Printf("Markov walk error: %v", err)
+				}
+			}(req.StartUsernames, crawler.WalkOptions{
+				Seed:                req.Seed,
+				TeleportProbability: req.TeleportProbability,
+			})
+		} else if req.SeedMode != "" {
+			// Trending and dependencies yield dozens of seeds; crawl them
+			// one after another
+			go func(users []string) {
+				for _, u := range users {
+					if err := job.CrawlStart(u); err != nil {
+						log.Printf("Crawler error for %s: %v", u, err)
+					}
+				}
+			}(req.StartUsernames)
 		} else {
 			for _, user := range req.StartUsernames {
 				go func(u string) {
-					if err := githubCrawler.CrawlStart(u); err != nil {
+					if err := job.CrawlSource(src, u); err != nil {
 						log.Printf("Crawler error for %s: %v", u, err)
 					}
 				}(user)
@@ -315,27 +676,39 @@ func main() {
 		}
 
 		return c.JSON(fiber.Map{
-			"message":        "Crawler started (API mode)",
-			"start_username": req.StartUsernames,
-			"max_iterations": currentCrawlerConfig.MaxIterations,
-			"delay_ms":       currentCrawlerConfig.DelayMs,
-			"use_playwright": currentCrawlerConfig.UsePlaywright,
+			"message":           "Crawler started (API mode)",
+			"start_username":    req.StartUsernames,
+			"max_iterations":    currentCrawlerConfig.MaxIterations,
+			"delay_ms":          currentCrawlerConfig.DelayMs,
+			"use_playwright":    currentCrawlerConfig.UsePlaywright,
+			"frontier_strategy": req.FrontierStrategy,
+			"frontier_cap":      opts.FrontierCap,
+			"max_depth":         opts.MaxDepth,
+			"user_agent":        job.HTMLScraper().UserAgent(),
+			"proxy":             proxyName,
+			"strategy":          req.Strategy,
+			"seed_mode":         req.SeedMode,
+			"source":            req.Source,
 		})
 	})
 
 	app.Get("/repos/search", func(c fiber.Ctx) error {
-		language := c.Query("language")
+		minStars, _ := strconv.Atoi(c.Query("min_stars"))
+		maxStars, _ := strconv.Atoi(c.Query("max_stars"))
 
-		repos, err := storageService.GetAllRepos()
+		repos, next, err := storageService.ListRepos(storage.RepoQuery{
+			Language: c.Query("language"),
+			Owner:    c.Query("owner"),
+			Source:   c.Query("source"),
+			MinStars: minStars,
+			MaxStars: maxStars,
+		}, pageParams(c))
 		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+			return listError(c, err)
 		}
 
 		filtered := []fiber.Map{}
 		for _, repo := range repos {
-			if language != "" && repo.Language != language {
-				continue
-			}
 			hash := repo.Hash
 			if hash == "" {
 				h := sha256.Sum256([]byte(repo.Owner + "/" + repo.Name))
@@ -346,15 +719,15 @@ func main() {
 				"owner":    repo.Owner,
 				"name":     repo.Name,
 				"language": repo.Language,
+				"stars":    repo.Stars,
 			})
 		}
 
-		return c.JSON(filtered)
+		return pageJSON(c, filtered, len(filtered), next)
 	})
 
 	app.Delete("/repos/:owner/:name", func(c fiber.Ctx) error {
-		owner := c.Params("owner")
-		name := c.Params("name")
+		owner, name := repoParams(c)
 
 		if err := storageService.DeleteRepo(owner, name); err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
@@ -363,31 +736,338 @@ func main() {
 		return c.JSON(fiber.Map{"message": "repository deleted"})
 	})
 
+	app.Get("/trending", func(c fiber.Ctx) error {
+		since := c.Query("since", scraper.TrendingDaily)
+		if !scraper.ValidTrendingPeriod(since) {
+			return c.Status(400).JSON(fiber.Map{"error": "unknown since: " + since})
+		}
+		snaps, err := storageService.GetTrendingSnapshots(since, c.Query("language"), c.Query("from"), c.Query("to"))
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		// ?repo=owner/name returns that repo's rank on each day instead
+		if repoID := c.Query("repo"); repoID != "" {
+			history := []fiber.Map{}
+			for _, snap := range snaps {
+				for _, r := range snap.Repos {
+					if r.RepoID == repoID {
+						history = append(history, fiber.Map{
+							"date":         snap.Date,
+							"rank":         r.Rank,
+							"stars":        r.Stars,
+							"stars_gained": r.StarsGained,
+						})
+					}
+				}
+			}
+			return c.JSON(fiber.Map{"repo": repoID, "since": since, "history": history})
+		}
+
+		return c.JSON(fiber.Map{"since": since, "count": len(snaps), "snapshots": snaps})
+	})
+
+	app.Post("/trending/snapshot", func(c fiber.Ctx) error {
+		since := c.Query("since", scraper.TrendingDaily)
+		if !scraper.ValidTrendingPeriod(since) {
+			return c.Status(400).JSON(fiber.Map{"error": "unknown since: " + since})
+		}
+		snap, err := githubCrawler.FetchTrending(c.Query("language"), since)
+		if err != nil {
+			return c.Status(502).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(snap)
+	})
+
+	app.Get("/cache/stats", func(c fiber.Ctx) error {
+		if httpCache == nil {
+			return c.Status(404).JSON(fiber.Map{"error": "HTTP cache disabled (set HTTP_CACHE_DIR)"})
+		}
+		stats, err := httpCache.Stats()
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(stats)
+	})
+
+	app.Get("/scraper/selectors", func(c fiber.Ctx) error {
+		selectors := githubCrawler.HTMLScraper().Selectors()
+		return c.JSON(fiber.Map{
+			"profile":     selectors.Profile(),
+			"diagnostics": selectors.Diagnostics(),
+		})
+	})
+
+	app.Post("/scraper/selectors/reload", func(c fiber.Ctx) error {
+		selectors := githubCrawler.HTMLScraper().Selectors()
+		if err := selectors.Reload(); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(fiber.Map{"version": selectors.Profile().Version})
+	})
+
 	app.Get("/crawler/config", func(c fiber.Ctx) error {
 		return c.JSON(fiber.Map{
-			"start_username": currentCrawlerConfig.StartUsername,
-			"max_iterations": currentCrawlerConfig.MaxIterations,
-			"delay_ms":       currentCrawlerConfig.DelayMs,
-			"token_set":      currentCrawlerConfig.TokenSet,
-			"use_playwright": currentCrawlerConfig.UsePlaywright,
+			"start_username":    currentCrawlerConfig.StartUsername,
+			"max_iterations":    currentCrawlerConfig.MaxIterations,
+			"delay_ms":          currentCrawlerConfig.DelayMs,
+			"token_set":         currentCrawlerConfig.TokenSet,
+			"use_playwright":    currentCrawlerConfig.UsePlaywright,
+			"frontier_strategy": currentCrawlerConfig.FrontierStrategy,
+			"frontier_cap":      currentCrawlerConfig.FrontierCap,
+			"max_depth":         currentCrawlerConfig.MaxDepth,
+			"user_agent":        currentCrawlerConfig.UserAgent,
+			"proxy":             currentCrawlerConfig.Proxy,
+		})
+	})
+
+	app.Get("/markov/stats", func(c fiber.Ctx) error {
+		meta, err := storageService.GetMarkovMeta()
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"error": "no markov checkpoint yet"})
+		}
+		return c.JSON(meta)
+	})
+
+	markovStateHandler := func(c fiber.Ctx, id string) error {
+		state, err := storageService.GetMarkovState(id)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"error": "markov state not found"})
+		}
+		return c.JSON(state)
+	}
+
+	// Users are single-segment states, repos are "owner/name"
+	app.Get("/markov/state/:id", func(c fiber.Ctx) error {
+		return markovStateHandler(c, c.Params("id"))
+	})
+
+	app.Get("/markov/state/:owner/:name", func(c fiber.Ctx) error {
+		owner, name := repoParams(c)
+		return markovStateHandler(c, owner+"/"+name)
+	})
+
+	// Higher-order chains trained from stored crawl paths, cached per order
+	var pathChainsMu sync.Mutex
+	pathChains := map[int]*markov.HigherOrderChain{}
+
+	// Stored paths hold at most crawler.MaxPathLen states, so longer
+	// contexts never occur; the bound also caps what a chain allocates
+	errBadOrder := errors.New("order must be at most " + strconv.Itoa(crawler.MaxPathLen))
+
+	// maxGenerateLength caps how many states one generate call samples
+	const maxGenerateLength = 100
+
+	pathChain := func(c fiber.Ctx, retrain bool) (*markov.HigherOrderChain, int, error) {
+		order, _ := strconv.Atoi(c.Query("order", "2"))
+		if order < 1 {
+			order = 2
+		}
+		if order > crawler.MaxPathLen {
+			return nil, 0, errBadOrder
+		}
+		if hc, ok := pathChains[order]; ok && !retrain {
+			return hc, 0, nil
+		}
+		seed, _ := strconv.ParseInt(c.Query("seed", "0"), 10, 64)
+		hc, paths, err := storageService.TrainPathChain(order, seed)
+		if err != nil {
+			return nil, 0, err
+		}
+		pathChains[order] = hc
+		return hc, paths, nil
+	}
+
+	// pathChainError answers a failed pathChain, 400 for a bad order
+	pathChainError := func(c fiber.Ctx, err error) error {
+		if errors.Is(err, errBadOrder) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	splitStates := func(q string) []string {
+		states := []string{}
+		for _, s := range strings.Split(q, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				states = append(states, s)
+			}
+		}
+		return states
+	}
+
+	app.Post("/markov/paths/train", func(c fiber.Ctx) error {
+		pathChainsMu.Lock()
+		defer pathChainsMu.Unlock()
+
+		hc, paths, err := pathChain(c, true)
+		if err != nil {
+			return pathChainError(c, err)
+		}
+		return c.JSON(fiber.Map{
+			"order":    hc.Order(),
+			"paths":    paths,
+			"contexts": hc.GetContextCount(),
+		})
+	})
+
+	app.Get("/markov/paths/predict", func(c fiber.Ctx) error {
+		pathChainsMu.Lock()
+		defer pathChainsMu.Unlock()
+
+		hc, _, err := pathChain(c, false)
+		if err != nil {
+			return pathChainError(c, err)
+		}
+		top, _ := strconv.Atoi(c.Query("top", "10"))
+		history := splitStates(c.Query("history"))
+
+		next, err := hc.Predict(history, top)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(fiber.Map{"history": history, "next": next})
+	})
+
+	app.Get("/markov/paths/generate", func(c fiber.Ctx) error {
+		pathChainsMu.Lock()
+		defer pathChainsMu.Unlock()
+
+		hc, _, err := pathChain(c, false)
+		if err != nil {
+			return pathChainError(c, err)
+		}
+		length, err := strconv.Atoi(c.Query("length", "5"))
+		if err != nil || length < 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "length must be a non-negative number"})
+		}
+		if length > maxGenerateLength {
+			length = maxGenerateLength
+		}
+
+		path, err := hc.Generate(splitStates(c.Query("start")), length)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		// A start path with unseen steps scores -Inf, which JSON cannot hold
+		score := hc.Score(path)
+		if math.IsInf(score, -1) {
+			return c.JSON(fiber.Map{"path": path, "log_likelihood": nil})
+		}
+		return c.JSON(fiber.Map{"path": path, "log_likelihood": score})
+	})
+
+	app.Get("/markov/paths/score", func(c fiber.Ctx) error {
+		pathChainsMu.Lock()
+		defer pathChainsMu.Unlock()
+
+		hc, _, err := pathChain(c, false)
+		if err != nil {
+			return pathChainError(c, err)
+		}
+		path := splitStates(c.Query("path"))
+
+		score := hc.Score(path)
+		if math.IsInf(score, -1) {
+			return c.JSON(fiber.Map{"path": path, "log_likelihood": nil, "probability": 0})
+		}
+		return c.JSON(fiber.Map{"path": path, "log_likelihood": score, "probability": math.Exp(score)})
+	})
+
+	app.Get("/graph/export", func(c fiber.Ctx) error {
+		format := c.Query("format", "json")
+		minDegree, _ := strconv.Atoi(c.Query("min_degree", "0"))
+		filter := graph.Filter{
+			Language:  c.Query("language"),
+			Owner:     c.Query("owner"),
+			MinDegree: minDegree,
+		}
+		if kinds := c.Query("kinds"); kinds != "" {
+			filter.Kinds = splitStates(kinds)
+		}
+
+		// Validate the format before the response starts streaming
+		if _, err := graph.NewWriter(format, io.Discard); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error(), "formats": graph.Formats})
+		}
+
+		c.Set("Content-Type", graph.ContentType(format))
+		c.Set("Content-Disposition", "attachment; filename=crawl."+format)
+		return c.SendStreamWriter(func(w *bufio.Writer) {
+			gw, _ := graph.NewWriter(format, w)
+			if err := graph.Export(storageService, gw, filter); err != nil {
+				log.Printf("graph export failed: %v", err)
+			}
+			w.Flush()
+		})
+	})
+
+	rankingsHandler := func(c fiber.Ctx, list func(page storage.Page) ([]storage.Ranking, string, error)) error {
+		refresh := c.Query("refresh")
+		if refresh == "1" || refresh == "true" {
+			if err := githubCrawler.UpdateRankings(); err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+			}
+		}
+
+		meta, err := storageService.GetRankingMeta()
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"error": "rankings not computed yet (use ?refresh=1)"})
+		}
+
+		rankings, next, err := list(pageParams(c))
+		if err != nil {
+			return listError(c, err)
+		}
+		// The page envelope, plus when the rankings were computed
+		return c.JSON(fiber.Map{
+			"items":       rankings,
+			"count":       len(rankings),
+			"next_cursor": next,
+			"computed_at": meta.ComputedAt,
 		})
+	}
+
+	app.Get("/rankings/repos", func(c fiber.Ctx) error {
+		return rankingsHandler(c, storageService.GetRepoRankings)
+	})
+
+	app.Get("/rankings/contacts", func(c fiber.Ctx) error {
+		return rankingsHandler(c, storageService.GetContactRankings)
 	})
 
 	app.Get("/api/routes", func(c fiber.Ctx) error {
 		routes := []fiber.Map{
 			{"method": "GET", "path": "/health", "description": "Health check"},
 			{"method": "GET", "path": "/stats", "description": "Get database statistics"},
-			{"method": "GET", "path": "/repos", "description": "Get all repositories (query: include_issues=count)"},
-			{"method": "GET", "path": "/repos/:owner/:name", "description": "Get specific repository"},
-			{"method": "GET", "path": "/repos/:owner/:name/issues", "description": "Get repository issues"},
-			{"method": "GET", "path": "/repos/:owner/:name/prs", "description": "Get repository pull requests"},
-			{"method": "GET", "path": "/repos/search", "description": "Search repositories (query: language)"},
+			{"method": "GET", "path": "/repos", "description": "Get repositories, one page at a time (query: limit, cursor, include_issues=count, source=github|gitlab|gitea)"},
+			{"method": "GET", "path": "/repos/:owner/:name", "description": "Get specific repository (escape namespaced owners: gitlab.com%2Fgroup)"},
+			{"method": "GET", "path": "/repos/:owner/:name/issues", "description": "Get repository issues (query: limit, cursor, state, author)"},
+			{"method": "GET", "path": "/repos/:owner/:name/prs", "description": "Get repository pull requests (query: limit, cursor, state, author)"},
+			{"method": "GET", "path": "/repos/:owner/:name/history", "description": "Get the change log of a repository, newest first, with field diffs (query: issue, pr, limit, cursor)"},
+			{"method": "GET", "path": "/repos/:owner/:name/dependencies", "description": "Get the packages a repository depends on, resolved to crawled repos where possible"},
+			{"method": "GET", "path": "/repos/:owner/:name/dependents", "description": "Get the crawled repositories depending on a repository"},
+			{"method": "GET", "path": "/repos/search", "description": "Search repositories through secondary indexes, most stars first (query: language, owner, source, min_stars, max_stars, limit, cursor)"},
 			{"method": "DELETE", "path": "/repos/:owner/:name", "description": "Delete repository"},
-			{"method": "GET", "path": "/contacts", "description": "Get all contacts"},
+			{"method": "GET", "path": "/contacts", "description": "Get contacts, one page at a time (query: limit, cursor)"},
 			{"method": "GET", "path": "/contacts/:login", "description": "Get specific contact"},
-			{"method": "POST", "path": "/crawler/start", "description": "Start crawler (HTML mode; body: start_username, max_iterations, delay_ms, github_token, use_playwright)"},
+			{"method": "POST", "path": "/crawler/start", "description": "Start crawler (HTML mode; body: start_username, max_iterations, delay_ms, github_token, use_playwright, frontier_strategy, frontier_cap, max_depth, fetch_starred, user_agent, html_max_pages, strategy, seed, teleport_probability, seed_mode, trending_language, trending_since, seed_repos, fetch_dependencies, proxy, source, source_url, source_token)"},
 			{"method": "GET", "path": "/crawler/config", "description": "Get current crawler configuration"},
-			{"method": "GET", "path": "/issues", "description": "Get all issues"},
+			{"method": "GET", "path": "/issues", "description": "Get all issues (query: limit, cursor, state, author)"},
+			{"method": "GET", "path": "/markov/stats", "description": "Get last Markov chain checkpoint summary"},
+			{"method": "GET", "path": "/markov/state/:id", "description": "Get transitions of a Markov state (user login or owner/name)"},
+			{"method": "POST", "path": "/markov/paths/train", "description": "Train an order-k chain from stored crawl paths (query: order, seed)"},
+			{"method": "GET", "path": "/markov/paths/predict", "description": "Predict next hops (query: order, history=a,b, top)"},
+			{"method": "GET", "path": "/markov/paths/generate", "description": "Generate a path (query: order, start=a,b, length)"},
+			{"method": "GET", "path": "/markov/paths/score", "description": "Log-likelihood of a path (query: order, path=a,b,c)"},
+			{"method": "GET", "path": "/graph/export", "description": "Export the crawl graph (query: format=dot|graphml|gexf|json, language, owner, min_degree, kinds)"},
+			{"method": "GET", "path": "/rankings/repos", "description": "Repositories by stationary probability (query: limit, cursor, refresh)"},
+			{"method": "GET", "path": "/rankings/contacts", "description": "Contacts by stationary probability (query: limit, cursor, refresh)"},
+			{"method": "GET", "path": "/trending", "description": "Stored trending snapshots (query: language, since=daily|weekly|monthly, from, to, repo)"},
+			{"method": "POST", "path": "/trending/snapshot", "description": "Scrape and store today's trending repos (query: language, since)"},
+			{"method": "GET", "path": "/cache/stats", "description": "HTTP response cache size and hit/miss counters"},
+			{"method": "GET", "path": "/scraper/selectors", "description": "Active HTML selector profile and which selectors matched recently"},
+			{"method": "POST", "path": "/scraper/selectors/reload", "description": "Reload the selector profile file (SELECTOR_PROFILE)"},
 			{"method": "GET", "path": "/api/routes", "description": "List all available endpoints"},
 		}
 		return c.JSON(routes)
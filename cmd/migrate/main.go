@@ -0,0 +1,77 @@
+// Command migrate shows and applies the schema migrations of a database.
+// The app and seed commands apply pending migrations at startup; this
+// command checks what they would change first, or stops at a version:
+//
+//	go run ./cmd/migrate status
+//	go run ./cmd/migrate dry-run
+//	go run ./cmd/migrate -to 2 run
+//
+// Migrations only roll forward; copy the database directory before running
+// them on data you care about.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"Fyne-on/pkg/database"
+	"Fyne-on/pkg/storage"
+)
+
+func main() {
+	dir := flag.String("db", "./badger_data", "database directory")
+	to := flag.Int("to", 0, "schema version to roll forward to (default: latest)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: migrate [-db dir] [-to version] status|run|dry-run\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	cmd := flag.Arg(0)
+	if flag.NArg() != 1 || (cmd != "status" && cmd != "run" && cmd != "dry-run") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	db, err := database.OpenDB(*dir)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	s := storage.NewStorageService(db)
+
+	current, err := s.SchemaVersion()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if cmd == "status" {
+		fmt.Printf("schema version %d (latest %d)\n", current, storage.LatestSchemaVersion())
+		for _, m := range storage.Migrations() {
+			state := "pending"
+			if m.Version <= current {
+				state = "applied"
+			}
+			fmt.Printf("  %3d  %-8s %s\n", m.Version, state, m.Description)
+		}
+		return
+	}
+
+	results, err := s.Migrate(storage.MigrateOptions{To: *to, DryRun: cmd == "dry-run"})
+	for _, r := range results {
+		fmt.Printf("  %3d  %d scanned, %d changed  %s\n", r.Version, r.Scanned, r.Changed, r.Description)
+	}
+	if err != nil {
+		db.Close()
+		log.Fatal(err)
+	}
+	if len(results) == 0 {
+		fmt.Printf("schema version %d is up to date\n", current)
+	} else if cmd == "dry-run" {
+		fmt.Printf("dry run: %d migrations pending, nothing written\n", len(results))
+	} else {
+		fmt.Printf("schema version %d -> %d\n", current, results[len(results)-1].Version)
+	}
+}
@@ -18,6 +18,9 @@ func main() {
 
 	// Initialize storage service
 	storageService := storage.NewStorageService(db)
+	if _, err := storageService.Migrate(storage.MigrateOptions{}); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
 
 	// Add test contacts
 	contacts := []models.Contact{
@@ -0,0 +1,60 @@
+package crawler
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"Fyne-on/pkg/database"
+	"Fyne-on/pkg/httpcache"
+	"Fyne-on/pkg/scraper/scrapertest"
+	"Fyne-on/pkg/storage"
+)
+
+func TestOfflineReplay(t *testing.T) {
+	srv, err := scrapertest.NewServer(filepath.Join("..", "scraper", "testdata", "fixtures"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := database.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	cache, err := httpcache.Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gc := NewGithubCrawler(storage.NewStorageService(db))
+	gc.HTMLScraper().SetBaseURL(srv.URL)
+	gc.HTMLScraper().SetHostLimits(4, 0, 1)
+	gc.SetHTTPCache(cache, false)
+
+	online, err := gc.HTMLScraper().FetchUserRepos("octocat")
+	if err != nil {
+		t.Fatalf("FetchUserRepos failed: %v", err)
+	}
+
+	// Replay with the server gone
+	srv.Close()
+	gc.SetHTTPCache(cache, true)
+
+	replayed, err := gc.HTMLScraper().FetchUserRepos("octocat")
+	if err != nil {
+		t.Fatalf("Offline FetchUserRepos failed: %v", err)
+	}
+	if !reflect.DeepEqual(online, replayed) {
+		t.Errorf("Expected offline replay to match the online crawl:\n%+v\n%+v", online, replayed)
+	}
+
+	if _, err := gc.HTMLScraper().FetchUserRepos("someone-else"); !errors.Is(err, httpcache.ErrOfflineMiss) {
+		t.Errorf("Expected ErrOfflineMiss for an uncached page, got %v", err)
+	}
+	if _, err := gc.makeRequest("https://api.github.com/users/octocat"); !errors.Is(err, httpcache.ErrOfflineMiss) {
+		t.Errorf("Expected ErrOfflineMiss from the API client, got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"Fyne-on/pkg/deps"
+	"Fyne-on/pkg/models"
+)
+
+// SetFetchDependencies makes crawls read every repo's manifests (go.mod,
+// package.json, requirements.txt, Cargo.toml) and store its dependencies
+func (gc *GithubCrawler) SetFetchDependencies(v bool) {
+	gc.fetchDeps = v
+}
+
+// FetchFile implements FileSource through raw.githubusercontent.com
+func (gc *GithubCrawler) FetchFile(owner, repo, path string) ([]byte, error) {
+	header := http.Header{}
+	if gc.token != "" {
+		header.Set("Authorization", "token "+gc.token)
+	}
+	return fetch(gc.client, fmt.Sprintf("%s/%s/%s/HEAD/%s", gc.rawBaseURL, owner, repo, path), header)
+}
+
+// SaveRepoDependencies fetches and parses the manifests at the root of a
+// repo and replaces its stored dependencies. It returns how many
+// dependencies were found; a repo without manifests stores none.
+func (gc *GithubCrawler) SaveRepoDependencies(fs FileSource, repo models.Repo) (int, error) {
+	repoID := repo.Owner + "/" + repo.Name
+	manifests := models.RepoManifests{RepoID: repoID, Files: []string{}, Packages: []models.Package{}, FetchedAt: time.Now()}
+	list := []models.Dependency{}
+
+	for _, file := range deps.ManifestNames() {
+		data, err := fs.FetchFile(repo.Owner, repo.Name, file)
+		if err != nil {
+			if !errors.Is(err, ErrNotFound) {
+				return 0, fmt.Errorf("failed to fetch %s: %w", file, err)
+			}
+			continue
+		}
+		m, err := deps.Parse(file, data)
+		if err != nil {
+			log.Printf("  Skipping %s of %s: %v", file, repoID, err)
+			continue
+		}
+
+		manifests.Files = append(manifests.Files, file)
+		if m.Package != "" {
+			name := m.Package
+			if m.Ecosystem == deps.PyPI {
+				name = deps.NormalizePyPI(name)
+			}
+			manifests.Packages = append(manifests.Packages, models.Package{Ecosystem: m.Ecosystem, Name: name, Manifest: file})
+		}
+		for _, r := range m.Requirements {
+			list = append(list, models.Dependency{
+				Ecosystem: m.Ecosystem,
+				Name:      r.Name,
+				Version:   r.Version,
+				Scope:     r.Scope,
+				Manifest:  file,
+			})
+		}
+	}
+
+	if err := gc.storage.SaveDependencies(manifests, list); err != nil {
+		return 0, err
+	}
+	return len(list), nil
+}
+
+// DependencySeeds returns the GitHub owners of repos that the given repos
+// (all repos with stored dependencies if none are given) depend on and
+// that have not been crawled yet, most depended upon first. Dependencies
+// resolve to repos through Go module paths and the packages of crawled
+// repos; other registry names cannot be traced to an owner.
+func (gc *GithubCrawler) DependencySeeds(repoIDs []string) ([]string, error) {
+	counts := map[string]int{}
+	add := func(d models.Dependency) {
+		target := d.ResolvedRepo
+		if target == "" && d.Ecosystem == deps.Go {
+			target = deps.GoModuleRepo(d.Name)
+		}
+		// Only plain owner/name ids are GitHub repos
+		if target == "" || target == d.RepoID || strings.Count(target, "/") != 1 {
+			return
+		}
+		counts[strings.SplitN(target, "/", 2)[0]]++
+	}
+
+	if len(repoIDs) == 0 {
+		err := gc.storage.IterateDependencies(func(d models.Dependency) error {
+			add(d)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, repoID := range repoIDs {
+		list, err := gc.storage.GetDependencies(repoID)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range list {
+			add(d)
+		}
+	}
+
+	seeds := []string{}
+	for owner := range counts {
+		if _, err := gc.storage.GetContact(owner); err == nil {
+			continue
+		}
+		seeds = append(seeds, owner)
+	}
+	sort.Slice(seeds, func(i, j int) bool {
+		if counts[seeds[i]] != counts[seeds[j]] {
+			return counts[seeds[i]] > counts[seeds[j]]
+		}
+		return seeds[i] < seeds[j]
+	})
+	return seeds, nil
+}
@@ -0,0 +1,132 @@
+package crawler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"Fyne-on/pkg/database"
+	"Fyne-on/pkg/models"
+	"Fyne-on/pkg/storage"
+)
+
+// fakeFiles is a FileSource serving files keyed by "owner/repo/path"
+type fakeFiles map[string]string
+
+func (f fakeFiles) FetchFile(owner, repo, path string) ([]byte, error) {
+	data, ok := f[owner+"/"+repo+"/"+path]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return []byte(data), nil
+}
+
+func TestSaveRepoDependencies(t *testing.T) {
+	db, err := database.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	st := storage.NewStorageService(db)
+	gc := NewGithubCrawler(st)
+
+	files := fakeFiles{
+		"acme/tool/go.mod":       "module github.com/acme/tool\n\nrequire (\n\tgithub.com/spf13/cobra v1.8.0\n\tgithub.com/acme/lib/v2 v2.1.0\n)\n",
+		"acme/tool/package.json": `{"name": "acme-tool", "devDependencies": {"left-pad": "1.3.0"}}`,
+		"acme/web/package.json":  `{"name": "acme-web", "dependencies": {"acme-tool": "^1.0.0"}}`,
+	}
+	for _, r := range []models.Repo{{Owner: "acme", Name: "tool"}, {Owner: "acme", Name: "lib"}, {Owner: "acme", Name: "web"}} {
+		r.ID = r.Owner + "/" + r.Name
+		if _, err := st.SaveRepo(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := gc.SaveRepoDependencies(files, models.Repo{Owner: "acme", Name: "tool"})
+	if err != nil || n != 3 {
+		t.Fatalf("Expected 3 dependencies, got %d (%v)", n, err)
+	}
+	if _, err := gc.SaveRepoDependencies(files, models.Repo{Owner: "acme", Name: "web"}); err != nil {
+		t.Fatal(err)
+	}
+
+	list, _ := st.GetDependencies("acme/tool")
+	resolved := map[string]string{}
+	for _, d := range list {
+		resolved[d.Name] = d.ResolvedRepo
+	}
+	want := map[string]string{"github.com/spf13/cobra": "", "github.com/acme/lib/v2": "acme/lib", "left-pad": ""}
+	if !reflect.DeepEqual(resolved, want) {
+		t.Errorf("Expected resolutions %v, got %v", want, resolved)
+	}
+
+	// acme-web depends on the npm package acme/tool publishes
+	dependents, _ := st.GetDependents("acme/tool")
+	if len(dependents) != 1 || dependents[0].RepoID != "acme/web" || dependents[0].Name != "acme-tool" {
+		t.Errorf("Expected acme/web to depend on acme/tool, got %+v", dependents)
+	}
+	dependents, _ = st.GetDependents("acme/lib")
+	if len(dependents) != 1 || dependents[0].RepoID != "acme/tool" {
+		t.Errorf("Expected acme/tool to depend on acme/lib, got %+v", dependents)
+	}
+
+	var edges []string
+	st.IterateEdges(models.EdgeDependsOn, func(e models.Edge) error {
+		edges = append(edges, e.From+" -> "+e.To)
+		return nil
+	})
+	if !reflect.DeepEqual(edges, []string{"acme/tool -> acme/lib", "acme/web -> acme/tool"}) {
+		t.Errorf("Unexpected depends_on edges: %v", edges)
+	}
+
+	// Owners already crawled are not seeds
+	st.SaveContact(models.Contact{Login: "acme"})
+	seeds, err := gc.DependencySeeds(nil)
+	if err != nil || !reflect.DeepEqual(seeds, []string{"spf13"}) {
+		t.Errorf("Expected seed spf13, got %v (%v)", seeds, err)
+	}
+
+	// Saving again replaces the previous dependencies and edges
+	files["acme/tool/go.mod"] = "module github.com/acme/tool\n"
+	delete(files, "acme/tool/package.json")
+	if _, err := gc.SaveRepoDependencies(files, models.Repo{Owner: "acme", Name: "tool"}); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := st.GetDependencies("acme/tool"); len(list) != 0 {
+		t.Errorf("Expected stale dependencies to be removed, got %+v", list)
+	}
+	if dependents, _ := st.GetDependents("acme/lib"); len(dependents) != 0 {
+		t.Errorf("Expected stale dependents to be removed, got %+v", dependents)
+	}
+	edges = nil
+	st.IterateEdges(models.EdgeDependsOn, func(e models.Edge) error {
+		edges = append(edges, e.From+" -> "+e.To)
+		return nil
+	})
+	if !reflect.DeepEqual(edges, []string{"acme/web -> acme/tool"}) {
+		t.Errorf("Expected only acme/web's edge to remain, got %v", edges)
+	}
+}
+
+func TestGithubFetchFile(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/acme/tool/HEAD/go.mod" {
+			w.Write([]byte("module github.com/acme/tool\n"))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	gc := NewGithubCrawler(nil)
+	gc.rawBaseURL = srv.URL
+	data, err := gc.FetchFile("acme", "tool", "go.mod")
+	if err != nil || string(data) != "module github.com/acme/tool\n" {
+		t.Errorf("Expected go.mod contents, got %q (%v)", data, err)
+	}
+	if _, err := gc.FetchFile("acme", "tool", "Cargo.toml"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
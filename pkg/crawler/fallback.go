@@ -0,0 +1,102 @@
+package crawler
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"Fyne-on/pkg/models"
+)
+
+// ErrQuotaExhausted is returned by API requests that have an HTML fallback
+// (issues and contributors) while the REST rate limit is used up and the
+// fallback is enabled. Other requests sleep until the quota resets.
+var ErrQuotaExhausted = errors.New("GitHub API quota exhausted")
+
+// maxHTMLIssuePages limits how many issue list pages are scraped per state
+const maxHTMLIssuePages = 10
+
+// SetHTMLFallback controls whether issues and contributors are scraped from
+// HTML while the API quota is exhausted. When disabled, requests sleep until
+// the quota resets instead.
+func (gc *GithubCrawler) SetHTMLFallback(v bool) {
+	gc.htmlFallback = v
+}
+
+// apiQuota is the last known API rate limit, shared by a crawler and its
+// jobs since they spend the same token
+type apiQuota struct {
+	mu    sync.Mutex
+	reset time.Time
+}
+
+// quotaExhausted reports whether the last known API quota is still used up
+func (gc *GithubCrawler) quotaExhausted() bool {
+	gc.quota.mu.Lock()
+	defer gc.quota.mu.Unlock()
+	return time.Now().Before(gc.quota.reset)
+}
+
+func (gc *GithubCrawler) setQuotaReset(t time.Time) {
+	gc.quota.mu.Lock()
+	defer gc.quota.mu.Unlock()
+	gc.quota.reset = t
+}
+
+// fetchIssuesHTML scrapes the issue lists of the given states
+func (gc *GithubCrawler) fetchIssuesHTML(owner, repo string, states []string, saveFunc func(models.Issue) error) error {
+	for _, state := range states {
+		for page := 1; page <= maxHTMLIssuePages; page++ {
+			issues, err := gc.htmlScraper.FetchRepoIssues(owner, repo, state, page)
+			if err != nil {
+				return err
+			}
+			if len(issues) == 0 {
+				break
+			}
+
+			for _, is := range issues {
+				created, _ := time.Parse(time.RFC3339, is.CreatedAt)
+				issue := models.Issue{
+					ID:        strconv.Itoa(is.Number),
+					RepoID:    owner + "/" + repo,
+					Title:     is.Title,
+					URL:       is.URL,
+					State:     is.State,
+					Author:    is.Author,
+					CreatedAt: created,
+					UpdatedAt: time.Now(),
+				}
+				if err := saveFunc(issue); err != nil {
+					log.Printf("Failed to save issue %s: %v", issue.ID, err)
+				}
+			}
+			log.Printf("  Saved %d issues from HTML page %d (state: %s)", len(issues), page, state)
+		}
+	}
+	return nil
+}
+
+// fetchContributorsHTML scrapes the contributors of a repository
+func (gc *GithubCrawler) fetchContributorsHTML(owner, repo string) ([]models.Contact, error) {
+	contributors, err := gc.htmlScraper.FetchContributors(owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pages don't show the numeric user ID the API reports, so ID is left
+	// empty; merging into a stored profile keeps the one it has
+	contacts := make([]models.Contact, 0, len(contributors))
+	for _, c := range contributors {
+		contacts = append(contacts, models.Contact{
+			Login:         c.Login,
+			URL:           c.URL,
+			Avatar:        c.Avatar,
+			Contributions: c.Contributions,
+			UpdatedAt:     time.Now(),
+		})
+	}
+	return contacts, nil
+}
@@ -0,0 +1,93 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Fyne-on/pkg/models"
+)
+
+func TestMakeRequestQuotaExhausted(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprint(time.Now().Add(time.Hour).Unix()))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	gc := NewGithubCrawler(nil)
+
+	_, err := gc.makeFallbackRequest(srv.URL + "/repos/octo/app/issues")
+	if !errors.Is(err, ErrQuotaExhausted) {
+		t.Fatalf("Expected ErrQuotaExhausted, got %v", err)
+	}
+
+	// Until the reset time, requests fail without reaching the API
+	_, err = gc.makeFallbackRequest(srv.URL + "/repos/octo/app/issues")
+	if !errors.Is(err, ErrQuotaExhausted) {
+		t.Errorf("Expected ErrQuotaExhausted, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected 1 API call, got %d", calls)
+	}
+}
+
+func TestMakeRequestWaitsForQuotaWithoutFallback(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", fmt.Sprint(time.Now().Unix()))
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, `{"login": "octo"}`)
+	}))
+	defer srv.Close()
+
+	// Profiles have no HTML fallback, so they wait for the reset even
+	// though the fallback is enabled
+	gc := NewGithubCrawler(nil)
+	body, err := gc.makeRequest(srv.URL + "/users/octo")
+	if err != nil {
+		t.Fatalf("Expected the request to succeed after the reset, got %v", err)
+	}
+	if calls != 2 || string(body) != `{"login": "octo"}` {
+		t.Errorf("Expected a retry after the reset, got %d calls and %q", calls, body)
+	}
+}
+
+func TestEnrichRepoHTMLKeepsMissedValues(t *testing.T) {
+	// A landing page whose stats the selectors do not find
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "<html><body><main>redesigned</main></body></html>")
+	}))
+	defer srv.Close()
+
+	gc := NewGithubCrawler(nil)
+	gc.SetFetchRepoPages(true)
+	gc.HTMLScraper().SetBaseURL(srv.URL)
+
+	pushed := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	repo := models.Repo{
+		ID: "octo/app", Owner: "octo", Name: "app",
+		Stars: 120, Forks: 7, Watchers: 9, Topics: []string{"cli"},
+		Homepage: "https://octo.dev", LastCommitAt: pushed,
+	}
+	gc.enrichRepoHTML(&repo)
+	if repo.Stars != 120 || repo.Forks != 7 || repo.Watchers != 9 || len(repo.Topics) != 1 ||
+		repo.Homepage != "https://octo.dev" || !repo.LastCommitAt.Equal(pushed) {
+		t.Errorf("Expected API values to survive missed selectors, got %+v", repo)
+	}
+}
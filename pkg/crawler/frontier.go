@@ -0,0 +1,215 @@
+package crawler
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// FrontierItem is a user waiting to be crawled along with the signals
+// used to rank it against the rest of the frontier.
+type FrontierItem struct {
+	Login         string
+	Depth         int
+	Followers     int
+	Contributions int
+	SourceRepo    string
+	SourceStars   int
+	SourcePushed  time.Time
+	Score         float64
+	// Path holds the states that led to this user, oldest first
+	Path []string
+
+	seq   uint64
+	index int
+}
+
+// ScoreFunc ranks a frontier item. Higher scores are crawled first,
+// equal scores are crawled in insertion order.
+type ScoreFunc func(item FrontierItem) float64
+
+var frontierStrategies = map[string]ScoreFunc{
+	"fifo": func(item FrontierItem) float64 {
+		return 0
+	},
+	"followers": func(item FrontierItem) float64 {
+		return float64(item.Followers)
+	},
+	"contributions": func(item FrontierItem) float64 {
+		return float64(item.Contributions)
+	},
+	"stars": func(item FrontierItem) float64 {
+		return float64(item.SourceStars)
+	},
+	"freshness": func(item FrontierItem) float64 {
+		if item.SourcePushed.IsZero() {
+			return 0
+		}
+		return float64(item.SourcePushed.Unix())
+	},
+}
+
+// FrontierStrategies returns the names of the built-in scoring strategies
+func FrontierStrategies() []string {
+	names := make([]string, 0, len(frontierStrategies))
+	for name := range frontierStrategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Frontier is a bounded priority queue of users to crawl.
+// When the frontier is full a new item only gets in by evicting
+// the lowest ranked one.
+type Frontier struct {
+	items    frontierHeap
+	byLogin  map[string]*FrontierItem
+	score    ScoreFunc
+	cap      int
+	maxDepth int
+	seq      uint64
+}
+
+// NewFrontier creates a frontier. A cap or maxDepth of zero means unlimited.
+func NewFrontier(score ScoreFunc, cap, maxDepth int) *Frontier {
+	if score == nil {
+		score = frontierStrategies["fifo"]
+	}
+	return &Frontier{
+		byLogin:  make(map[string]*FrontierItem),
+		score:    score,
+		cap:      cap,
+		maxDepth: maxDepth,
+	}
+}
+
+// Push adds an item to the frontier and reports whether it was enqueued.
+// Pushing a login that is already queued keeps the better of the two scores.
+func (f *Frontier) Push(item FrontierItem) bool {
+	if item.Login == "" {
+		return false
+	}
+	if f.maxDepth > 0 && item.Depth > f.maxDepth {
+		return false
+	}
+
+	item.Score = f.score(item)
+
+	if existing, ok := f.byLogin[item.Login]; ok {
+		if item.Score > existing.Score {
+			item.seq = existing.seq
+			item.index = existing.index
+			*existing = item
+			heap.Fix(&f.items, existing.index)
+		}
+		return false
+	}
+
+	if f.cap > 0 && len(f.items) >= f.cap {
+		worst := f.worst()
+		if worst == nil || item.Score <= worst.Score {
+			return false
+		}
+		heap.Remove(&f.items, worst.index)
+		delete(f.byLogin, worst.Login)
+	}
+
+	f.seq++
+	item.seq = f.seq
+	entry := &item
+	heap.Push(&f.items, entry)
+	f.byLogin[entry.Login] = entry
+	return true
+}
+
+// Pop removes and returns the highest ranked item
+func (f *Frontier) Pop() (FrontierItem, bool) {
+	if len(f.items) == 0 {
+		return FrontierItem{}, false
+	}
+	entry := heap.Pop(&f.items).(*FrontierItem)
+	delete(f.byLogin, entry.Login)
+	return *entry, true
+}
+
+// Len returns the number of queued items
+func (f *Frontier) Len() int {
+	return len(f.items)
+}
+
+// Contains reports whether a login is currently queued
+func (f *Frontier) Contains(login string) bool {
+	_, ok := f.byLogin[login]
+	return ok
+}
+
+func (f *Frontier) worst() *FrontierItem {
+	var worst *FrontierItem
+	for _, it := range f.items {
+		if worst == nil || it.Score < worst.Score || (it.Score == worst.Score && it.seq > worst.seq) {
+			worst = it
+		}
+	}
+	return worst
+}
+
+type frontierHeap []*FrontierItem
+
+func (h frontierHeap) Len() int { return len(h) }
+
+func (h frontierHeap) Less(i, j int) bool {
+	if h[i].Score != h[j].Score {
+		return h[i].Score > h[j].Score
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h frontierHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *frontierHeap) Push(x any) {
+	item := x.(*FrontierItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *frontierHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	*h = old[:n-1]
+	return item
+}
+
+// MaxPathLen bounds how much of the traversal history is kept per item, and
+// so the order of chains trained from stored crawl paths
+const MaxPathLen = 8
+
+// extendPath returns path followed by states, keeping at most MaxPathLen entries
+func extendPath(path []string, states ...string) []string {
+	out := make([]string, 0, len(path)+len(states))
+	out = append(out, path...)
+	out = append(out, states...)
+	if len(out) > MaxPathLen {
+		out = out[len(out)-MaxPathLen:]
+	}
+	return out
+}
+
+func lookupFrontierStrategy(name string) (ScoreFunc, error) {
+	if name == "" {
+		name = "fifo"
+	}
+	score, ok := frontierStrategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown frontier strategy: %s", name)
+	}
+	return score, nil
+}
@@ -0,0 +1,215 @@
+package crawler
+
+import (
+	"reflect"
+	"testing"
+
+	"Fyne-on/pkg/database"
+	"Fyne-on/pkg/models"
+	"Fyne-on/pkg/storage"
+)
+
+func TestFrontierFIFO(t *testing.T) {
+	f := NewFrontier(nil, 0, 0)
+	f.Push(FrontierItem{Login: "a"})
+	f.Push(FrontierItem{Login: "b"})
+	f.Push(FrontierItem{Login: "c"})
+
+	for _, want := range []string{"a", "b", "c"} {
+		item, ok := f.Pop()
+		if !ok {
+			t.Fatal("Expected item, frontier is empty")
+		}
+		if item.Login != want {
+			t.Errorf("Expected '%s', got '%s'", want, item.Login)
+		}
+	}
+}
+
+func TestFrontierPriority(t *testing.T) {
+	f := NewFrontier(frontierStrategies["followers"], 0, 0)
+	f.Push(FrontierItem{Login: "low", Followers: 1})
+	f.Push(FrontierItem{Login: "high", Followers: 100})
+	f.Push(FrontierItem{Login: "mid", Followers: 10})
+
+	item, _ := f.Pop()
+	if item.Login != "high" {
+		t.Errorf("Expected 'high', got '%s'", item.Login)
+	}
+}
+
+func TestFrontierCapEvictsLowest(t *testing.T) {
+	f := NewFrontier(frontierStrategies["stars"], 2, 0)
+	f.Push(FrontierItem{Login: "a", SourceStars: 5})
+	f.Push(FrontierItem{Login: "b", SourceStars: 1})
+
+	if f.Push(FrontierItem{Login: "c", SourceStars: 0}) {
+		t.Error("Lower ranked item should not enter a full frontier")
+	}
+	if !f.Push(FrontierItem{Login: "d", SourceStars: 10}) {
+		t.Error("Higher ranked item should evict the lowest one")
+	}
+	if f.Len() != 2 {
+		t.Errorf("Expected 2 items, got %d", f.Len())
+	}
+	if f.Contains("b") {
+		t.Error("Lowest ranked item should have been evicted")
+	}
+}
+
+func TestFrontierMaxDepth(t *testing.T) {
+	f := NewFrontier(nil, 0, 1)
+
+	if !f.Push(FrontierItem{Login: "a", Depth: 1}) {
+		t.Error("Item within max depth should be enqueued")
+	}
+	if f.Push(FrontierItem{Login: "b", Depth: 2}) {
+		t.Error("Item beyond max depth should be rejected")
+	}
+}
+
+func TestFrontierDuplicateKeepsBestScore(t *testing.T) {
+	f := NewFrontier(frontierStrategies["contributions"], 0, 0)
+	f.Push(FrontierItem{Login: "a", Contributions: 1})
+	f.Push(FrontierItem{Login: "b", Contributions: 5})
+	f.Push(FrontierItem{Login: "a", Contributions: 50})
+
+	if f.Len() != 2 {
+		t.Errorf("Expected 2 items, got %d", f.Len())
+	}
+	item, _ := f.Pop()
+	if item.Login != "a" || item.Contributions != 50 {
+		t.Errorf("Expected 'a' with 50 contributions, got '%s' with %d", item.Login, item.Contributions)
+	}
+}
+
+func TestSetFrontierStrategyUnknown(t *testing.T) {
+	gc := &GithubCrawler{}
+	if err := gc.SetFrontierStrategy("nope"); err == nil {
+		t.Fatal("Expected error for unknown strategy")
+	}
+}
+
+func TestNewJobSettingsStayWithTheJob(t *testing.T) {
+	gc := NewGithubCrawler(nil)
+	first, err := gc.NewJob(JobOptions{FrontierStrategy: "followers", FrontierCap: 0, MaxDepth: 3, FetchStarred: true, HTMLMaxPages: 2})
+	if err != nil {
+		t.Fatalf("NewJob failed: %v", err)
+	}
+	if first.frontierCap != 0 || first.maxDepth != 3 || !first.fetchStarred || first.HTMLScraper() == gc.HTMLScraper() {
+		t.Errorf("Expected the job's own settings, got cap %d, depth %d, starred %v", first.frontierCap, first.maxDepth, first.fetchStarred)
+	}
+
+	// A later job without settings gets the crawler's, not the first job's
+	second, _ := gc.NewJob(gc.JobDefaults())
+	if second.frontierCap != 100 || second.maxDepth != 0 || second.fetchStarred || second.HTMLScraper() != gc.HTMLScraper() {
+		t.Errorf("Expected the crawler's settings, got cap %d, depth %d, starred %v", second.frontierCap, second.maxDepth, second.fetchStarred)
+	}
+	if gc.frontierCap != 100 || gc.maxDepth != 0 || gc.fetchStarred {
+		t.Errorf("Expected the crawler to keep its settings")
+	}
+
+	if _, err := gc.NewJob(JobOptions{FrontierStrategy: "nope"}); err == nil {
+		t.Errorf("Expected error for unknown strategy")
+	}
+}
+
+// contributorSource is a Source where alice owns alice/app, which bob and
+// carol contribute to, and bob owns bob/lib, which dave contributes to. It
+// reports bare contributor records, as the GitHub contributors endpoint
+// does, and records the users crawled.
+type contributorSource struct {
+	crawled []string
+}
+
+func (s *contributorSource) Name() string { return models.SourceGitHub }
+
+func (s *contributorSource) FetchUserProfile(login string) (*models.Contact, error) {
+	return nil, ErrNotFound
+}
+
+func (s *contributorSource) FetchUserRepos(login string) ([]models.Repo, error) {
+	s.crawled = append(s.crawled, login)
+	switch login {
+	case "alice":
+		return []models.Repo{{ID: "alice/app", Owner: "alice", Name: "app"}}, nil
+	case "bob":
+		return []models.Repo{{ID: "bob/lib", Owner: "bob", Name: "lib"}}, nil
+	}
+	return nil, nil
+}
+
+func (s *contributorSource) FetchRepositoryIssues(owner, repo string, saveFunc func(models.Issue) error) error {
+	return nil
+}
+
+func (s *contributorSource) FetchRepositoryPRs(owner, repo string) ([]models.PullRequest, error) {
+	return nil, nil
+}
+
+func (s *contributorSource) FetchRepositoryContributors(owner, repo string) ([]models.Contact, error) {
+	if owner == "bob" {
+		return []models.Contact{{Login: "dave"}}, nil
+	}
+	return []models.Contact{{Login: "bob", Contributions: 9}, {Login: "carol", Contributions: 1}}, nil
+}
+
+func TestFollowersStrategyUsesStoredProfiles(t *testing.T) {
+	db, err := database.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	st := storage.NewStorageService(db)
+	st.SaveContact(models.Contact{Login: "bob", Followers: 5})
+	st.SaveContact(models.Contact{Login: "carol", Followers: 500, Company: "Acme"})
+
+	gc := NewGithubCrawler(st)
+	gc.SetDelayMs(0)
+	gc.SetMaxIterations(2)
+	if err := gc.SetFrontierStrategy("followers"); err != nil {
+		t.Fatal(err)
+	}
+
+	src := &contributorSource{}
+	if err := gc.CrawlSource(src, "alice"); err != nil {
+		t.Fatalf("CrawlSource failed: %v", err)
+	}
+	if len(src.crawled) != 2 || src.crawled[1] != "carol" {
+		t.Errorf("Expected carol, the most followed contributor, second, got %v", src.crawled)
+	}
+	carol, _ := st.GetContact("carol")
+	if carol.Followers != 500 || carol.Company != "Acme" || carol.Contributions != 1 {
+		t.Errorf("Expected the repo visit to keep carol's profile, got %+v", carol)
+	}
+}
+
+func TestCrawlSourceStoresBranchPaths(t *testing.T) {
+	db, err := database.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	st := storage.NewStorageService(db)
+
+	gc := NewGithubCrawler(st)
+	gc.SetDelayMs(0)
+	gc.SetMaxIterations(10)
+	if err := gc.CrawlSource(&contributorSource{}, "alice"); err != nil {
+		t.Fatalf("CrawlSource failed: %v", err)
+	}
+
+	// bob's path is a prefix of dave's, so only the branch ends are stored
+	var paths [][]string
+	st.IterateCrawlPaths(func(p models.CrawlPath) error {
+		paths = append(paths, p.States)
+		return nil
+	})
+	want := [][]string{
+		{"alice", "alice/app", "carol"},
+		{"alice", "alice/app", "bob", "bob/lib", "dave"},
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("Expected paths %v, got %v", want, paths)
+	}
+}
@@ -0,0 +1,328 @@
+package crawler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"Fyne-on/pkg/models"
+)
+
+// giteaPageSize is the largest page Gitea and Forgejo serve by default
+const giteaPageSize = 50
+
+// GiteaSource reads users, repositories, issues and pull requests from the
+// REST API (v1) of a Gitea or Forgejo instance
+type GiteaSource struct {
+	baseURL string
+	host    string
+	token   string
+	client  *http.Client
+	// maxCommitPages limits how many pages of commits are read to find
+	// a repo's contributors
+	maxCommitPages int
+}
+
+var _ Source = (*GiteaSource)(nil)
+
+// NewGiteaSource creates a source for the instance at baseURL. token is an
+// access token (optional for public data); client may be shared with
+// other sources, nil uses a new one.
+func NewGiteaSource(baseURL, token string, client *http.Client) (*GiteaSource, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid Gitea URL %q", baseURL)
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+	return &GiteaSource{
+		baseURL:        strings.TrimSuffix(baseURL, "/"),
+		host:           u.Host,
+		token:          token,
+		client:         client,
+		maxCommitPages: 2,
+	}, nil
+}
+
+// Name implements Source
+func (g *GiteaSource) Name() string {
+	return models.SourceGitea
+}
+
+// Host returns the host records from this instance are namespaced by
+func (g *GiteaSource) Host() string {
+	return g.host
+}
+
+func (g *GiteaSource) header() http.Header {
+	header := http.Header{}
+	if g.token != "" {
+		header.Set("Authorization", "token "+g.token)
+	}
+	return header
+}
+
+// get decodes the JSON response of an API path into v
+func (g *GiteaSource) get(path string, v interface{}) error {
+	return fetchJSON(g.client, g.baseURL+"/api/v1"+path, g.header(), v)
+}
+
+// FetchFile implements FileSource, reading from the default branch
+func (g *GiteaSource) FetchFile(owner, repo, path string) ([]byte, error) {
+	return fetch(g.client, g.baseURL+"/api/v1"+g.repoPath(owner, repo)+"/raw/"+path, g.header())
+}
+
+// repoPath is the API path of a repo, owner may be namespaced
+func (g *GiteaSource) repoPath(owner, repo string) string {
+	return "/repos/" + url.PathEscape(localOwner(g.host, owner)) + "/" + url.PathEscape(repo)
+}
+
+type giteaUser struct {
+	ID             int    `json:"id"`
+	Login          string `json:"login"`
+	HTMLURL        string `json:"html_url"`
+	AvatarURL      string `json:"avatar_url"`
+	Email          string `json:"email"`
+	Location       string `json:"location"`
+	Description    string `json:"description"`
+	FollowersCount int    `json:"followers_count"`
+}
+
+func (g *GiteaSource) contact(u giteaUser) models.Contact {
+	profileURL := u.HTMLURL
+	if profileURL == "" {
+		profileURL = g.baseURL + "/" + u.Login
+	}
+	return models.Contact{
+		ID:        strconv.Itoa(u.ID),
+		Login:     namespacedLogin(g.host, u.Login),
+		URL:       profileURL,
+		Avatar:    u.AvatarURL,
+		Email:     u.Email,
+		Location:  u.Location,
+		Bio:       u.Description,
+		Followers: u.FollowersCount,
+		Source:    models.SourceGitea,
+		UpdatedAt: time.Now(),
+	}
+}
+
+// FetchUserProfile implements Source
+func (g *GiteaSource) FetchUserProfile(login string) (*models.Contact, error) {
+	var user giteaUser
+	if err := g.get("/users/"+url.PathEscape(localLogin(g.host, login)), &user); err != nil {
+		return nil, err
+	}
+	contact := g.contact(user)
+	return &contact, nil
+}
+
+// FetchUserRepos implements Source
+func (g *GiteaSource) FetchUserRepos(login string) ([]models.Repo, error) {
+	repos := []models.Repo{}
+	for page := 1; ; page++ {
+		var data []struct {
+			Name  string `json:"name"`
+			Owner struct {
+				Login string `json:"login"`
+			} `json:"owner"`
+			HTMLURL       string    `json:"html_url"`
+			Description   string    `json:"description"`
+			Website       string    `json:"website"`
+			StarsCount    int       `json:"stars_count"`
+			ForksCount    int       `json:"forks_count"`
+			WatchersCount int       `json:"watchers_count"`
+			Language      string    `json:"language"`
+			Licenses      []string  `json:"licenses"` // SPDX IDs, Gitea 1.22+
+			Topics        []string  `json:"topics"`
+			CreatedAt     time.Time `json:"created_at"`
+			UpdatedAt     time.Time `json:"updated_at"`
+		}
+		path := fmt.Sprintf("/users/%s/repos?limit=%d&page=%d", url.PathEscape(localLogin(g.host, login)), giteaPageSize, page)
+		if err := g.get(path, &data); err != nil {
+			if page == 1 {
+				return nil, err
+			}
+			break
+		}
+		if len(data) == 0 {
+			break
+		}
+
+		for _, rd := range data {
+			owner := namespacedOwner(g.host, rd.Owner.Login)
+			repo := models.Repo{
+				ID:          owner + "/" + rd.Name,
+				Name:        rd.Name,
+				Owner:       owner,
+				URL:         rd.HTMLURL,
+				Description: rd.Description,
+				Homepage:    rd.Website,
+				Stars:       rd.StarsCount,
+				Forks:       rd.ForksCount,
+				Watchers:    rd.WatchersCount,
+				Language:    rd.Language,
+				Topics:      rd.Topics,
+				Source:      models.SourceGitea,
+				PushedAt:    rd.UpdatedAt,
+				CreatedAt:   rd.CreatedAt,
+				UpdatedAt:   time.Now(),
+			}
+			if len(rd.Licenses) > 0 {
+				repo.License = strings.ToLower(rd.Licenses[0])
+			}
+
+			// Bytes of code per language, stored as percentages
+			var languages map[string]float64
+			if err := g.get(g.repoPath(owner, rd.Name)+"/languages", &languages); err == nil && len(languages) > 0 {
+				total := 0.0
+				for _, n := range languages {
+					total += n
+				}
+				if total > 0 {
+					repo.Languages = make(map[string]float64, len(languages))
+					for lang, n := range languages {
+						repo.Languages[lang] = n * 100 / total
+					}
+				}
+			}
+			repos = append(repos, repo)
+		}
+	}
+	return repos, nil
+}
+
+type giteaIssue struct {
+	ID      int    `json:"id"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	State   string `json:"state"`
+	HTMLURL string `json:"html_url"`
+	Merged  bool   `json:"merged"`
+	User    struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// FetchRepositoryIssues implements Source
+func (g *GiteaSource) FetchRepositoryIssues(owner, repo string, saveFunc func(models.Issue) error) error {
+	repoID := namespacedOwner(g.host, localOwner(g.host, owner)) + "/" + repo
+	for page := 1; ; page++ {
+		var issues []giteaIssue
+		path := fmt.Sprintf("%s/issues?state=all&type=issues&limit=%d&page=%d", g.repoPath(owner, repo), giteaPageSize, page)
+		if err := g.get(path, &issues); err != nil {
+			return err
+		}
+		if len(issues) == 0 {
+			break
+		}
+		for _, is := range issues {
+			issue := models.Issue{
+				ID:        strconv.Itoa(is.ID),
+				RepoID:    repoID,
+				Title:     is.Title,
+				URL:       is.HTMLURL,
+				State:     is.State,
+				Body:      is.Body,
+				Author:    namespacedLogin(g.host, is.User.Login),
+				CreatedAt: is.CreatedAt,
+				UpdatedAt: is.UpdatedAt,
+				Source:    models.SourceGitea,
+			}
+			if err := saveFunc(issue); err != nil {
+				log.Printf("Failed to save issue %s: %v", issue.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// FetchRepositoryPRs implements Source
+func (g *GiteaSource) FetchRepositoryPRs(owner, repo string) ([]models.PullRequest, error) {
+	repoID := namespacedOwner(g.host, localOwner(g.host, owner)) + "/" + repo
+	prs := []models.PullRequest{}
+	for page := 1; ; page++ {
+		var pulls []giteaIssue
+		path := fmt.Sprintf("%s/pulls?state=all&limit=%d&page=%d", g.repoPath(owner, repo), giteaPageSize, page)
+		if err := g.get(path, &pulls); err != nil {
+			if page == 1 {
+				return nil, err
+			}
+			break
+		}
+		if len(pulls) == 0 {
+			break
+		}
+		for _, pr := range pulls {
+			state := pr.State
+			if pr.Merged {
+				state = "merged"
+			}
+			prs = append(prs, models.PullRequest{
+				ID:        strconv.Itoa(pr.ID),
+				RepoID:    repoID,
+				Title:     pr.Title,
+				URL:       pr.HTMLURL,
+				State:     state,
+				Body:      pr.Body,
+				Author:    namespacedLogin(g.host, pr.User.Login),
+				CreatedAt: pr.CreatedAt,
+				UpdatedAt: pr.UpdatedAt,
+				Source:    models.SourceGitea,
+			})
+		}
+	}
+	return prs, nil
+}
+
+// FetchRepositoryContributors implements Source. Gitea has no contributor
+// statistics endpoint, so the authors of the latest commits are returned,
+// most commits first.
+func (g *GiteaSource) FetchRepositoryContributors(owner, repo string) ([]models.Contact, error) {
+	counts := map[string]int{}
+	users := map[string]giteaUser{}
+	for page := 1; page <= g.maxCommitPages; page++ {
+		var commits []struct {
+			Author *giteaUser `json:"author"` // nil for authors without an account
+		}
+		path := fmt.Sprintf("%s/commits?stat=false&verification=false&files=false&limit=%d&page=%d", g.repoPath(owner, repo), giteaPageSize, page)
+		if err := g.get(path, &commits); err != nil {
+			if page == 1 {
+				return nil, err
+			}
+			break
+		}
+		if len(commits) == 0 {
+			break
+		}
+		for _, c := range commits {
+			if c.Author == nil || c.Author.Login == "" {
+				continue
+			}
+			counts[c.Author.Login]++
+			users[c.Author.Login] = *c.Author
+		}
+	}
+
+	contacts := make([]models.Contact, 0, len(users))
+	for login, u := range users {
+		contact := g.contact(u)
+		contact.Contributions = counts[login]
+		contacts = append(contacts, contact)
+	}
+	sort.Slice(contacts, func(i, j int) bool {
+		if contacts[i].Contributions != contacts[j].Contributions {
+			return contacts[i].Contributions > contacts[j].Contributions
+		}
+		return contacts[i].Login < contacts[j].Login
+	})
+	return contacts, nil
+}
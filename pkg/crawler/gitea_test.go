@@ -0,0 +1,161 @@
+package crawler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Fyne-on/pkg/database"
+	"Fyne-on/pkg/models"
+	"Fyne-on/pkg/storage"
+)
+
+// fakeGitea serves a tiny Gitea API: alice owns alice/demo, which bob
+// committed to
+func fakeGitea(t *testing.T) *httptest.Server {
+	t.Helper()
+	bob := obj{"id": 2, "login": "bob", "followers_count": 3}
+	routes := map[string]interface{}{
+		"/api/v1/users/alice": obj{"id": 1, "login": "alice", "html_url": "https://gt/alice", "location": "Berlin", "followers_count": 7},
+		"/api/v1/users/bob":   bob,
+		"/api/v1/users/alice/repos?limit=50&page=1": []obj{{
+			"name": "demo", "owner": obj{"login": "alice"}, "html_url": "https://gt/alice/demo",
+			"description": "Demo repo", "stars_count": 42, "forks_count": 5, "language": "Go",
+			"licenses": []string{"MIT"}, "topics": []string{"cli"},
+			"updated_at": "2024-05-01T10:00:00Z",
+		}},
+		"/api/v1/repos/alice/demo/languages": obj{"Go": 750, "Shell": 250},
+		"/api/v1/repos/alice/demo/issues?state=all&type=issues&limit=50&page=1": []obj{
+			{"id": 100, "title": "Crash on start", "state": "open", "user": obj{"login": "bob"}},
+			{"id": 101, "title": "Typo", "state": "closed", "user": obj{"login": "alice"}},
+		},
+		"/api/v1/repos/alice/demo/pulls?state=all&limit=50&page=1": []obj{
+			{"id": 200, "title": "Fix crash", "state": "closed", "merged": true, "user": obj{"login": "bob"}},
+			{"id": 201, "title": "Wip", "state": "open", "user": obj{"login": "bob"}},
+		},
+		"/api/v1/repos/alice/demo/commits?stat=false&verification=false&files=false&limit=50&page=1": []obj{
+			{"author": bob}, {"author": bob}, {"author": nil},
+			{"author": obj{"id": 1, "login": "alice"}},
+		},
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.EscapedPath()
+		if r.URL.RawQuery != "" {
+			key += "?" + r.URL.RawQuery
+		}
+		if v, ok := routes[key]; ok {
+			json.NewEncoder(w).Encode(v)
+			return
+		}
+		// Later pages are empty
+		if strings.Contains(key, "page=") {
+			w.Write([]byte("[]"))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+}
+
+func TestCrawlGiteaSource(t *testing.T) {
+	srv := fakeGitea(t)
+	defer srv.Close()
+
+	db, err := database.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	st := storage.NewStorageService(db)
+
+	gc := NewGithubCrawler(st)
+	gc.SetDelayMs(0)
+	gc.SetMaxIterations(5)
+
+	src, err := NewGiteaSource(srv.URL, "", gc.HTTPClient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := src.Host()
+
+	if err := gc.CrawlSource(src, "alice"); err != nil {
+		t.Fatalf("CrawlSource failed: %v", err)
+	}
+
+	repo, err := st.GetRepo(host+"/alice", "demo")
+	if err != nil {
+		t.Fatalf("Expected %s/alice/demo to be stored: %v", host, err)
+	}
+	if repo.Source != models.SourceGitea || repo.Stars != 42 || repo.License != "mit" || repo.Languages["Go"] != 75 {
+		t.Errorf("Unexpected repo: %+v", repo)
+	}
+
+	issues, _ := st.GetRepoIssues(host + "/alice/demo")
+	if len(issues) != 2 {
+		t.Fatalf("Expected 2 issues, got %d", len(issues))
+	}
+	for _, is := range issues {
+		if is.ID == "100" && (is.State != "open" || is.Author != "bob@"+host || is.Source != models.SourceGitea) {
+			t.Errorf("Unexpected issue: %+v", is)
+		}
+	}
+
+	prs, _ := st.GetRepoPullRequests(host + "/alice/demo")
+	states := map[string]string{}
+	for _, pr := range prs {
+		states[pr.ID] = pr.State
+	}
+	if len(prs) != 2 || states["200"] != "merged" || states["201"] != "open" {
+		t.Errorf("Expected one merged and one open pull request, got %+v", prs)
+	}
+
+	contributors, err := src.FetchRepositoryContributors(host+"/alice", "demo")
+	if err != nil {
+		t.Fatalf("FetchRepositoryContributors failed: %v", err)
+	}
+	if len(contributors) != 2 || contributors[0].Login != "bob@"+host || contributors[0].Contributions != 2 {
+		t.Errorf("Expected bob first with 2 commits, got %+v", contributors)
+	}
+
+	bob, err := st.GetContact("bob@" + host)
+	if err != nil {
+		t.Fatalf("Expected committer bob to be crawled: %v", err)
+	}
+	if bob.Source != models.SourceGitea || bob.Followers != 3 {
+		t.Errorf("Unexpected contact: %+v", bob)
+	}
+
+	alice, err := src.FetchUserProfile("alice@" + host)
+	if err != nil {
+		t.Fatalf("FetchUserProfile failed: %v", err)
+	}
+	if alice.Login != "alice@"+host || alice.Location != "Berlin" || alice.Followers != 7 {
+		t.Errorf("Expected alice's profile details, got %+v", alice)
+	}
+}
+
+func TestGiteaSourceSendsToken(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"id": 1, "login": "alice"}`))
+	}))
+	defer srv.Close()
+
+	src, err := NewGiteaSource(srv.URL, "s3cret", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.FetchUserProfile("alice"); err != nil {
+		t.Fatalf("FetchUserProfile failed: %v", err)
+	}
+	if auth != "token s3cret" {
+		t.Errorf("Expected Authorization %q, got %q", "token s3cret", auth)
+	}
+
+	if _, err := NewGiteaSource("", "", nil); err == nil {
+		t.Errorf("Expected an error without an instance URL")
+	}
+}
@@ -2,6 +2,7 @@ package crawler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/PuerkitoBio/goquery"
 	"io"
@@ -12,8 +13,10 @@ import (
 	"sync"
 	"time"
 
+	"Fyne-on/pkg/httpcache"
 	"Fyne-on/pkg/markov"
 	"Fyne-on/pkg/models"
+	"Fyne-on/pkg/proxy"
 	"Fyne-on/pkg/scraper"
 	"Fyne-on/pkg/storage"
 )
@@ -28,6 +31,16 @@ type GithubCrawler struct {
 	markovChain   *markov.MarkovChain
 	usePlaywright bool
 	htmlScraper   *scraper.HTTPScraper
+	frontierScore ScoreFunc
+	frontierCap   int
+	maxDepth      int
+	checkpointN   int
+	fetchStarred  bool
+	fetchPages    bool
+	htmlFallback  bool
+	fetchDeps     bool
+	rawBaseURL    string
+	quota         *apiQuota
 }
 
 func NewGithubCrawler(storage *storage.StorageService) *GithubCrawler {
@@ -39,6 +52,13 @@ func NewGithubCrawler(storage *storage.StorageService) *GithubCrawler {
 		delayMs:       1000,
 		markovChain:   markov.NewMarkovChain(0),
 		htmlScraper:   scraper.NewHTTPScraper(15),
+		frontierScore: frontierStrategies["fifo"],
+		frontierCap:   100,
+		checkpointN:   50,
+		fetchPages:    true,
+		htmlFallback:  true,
+		rawBaseURL:    "https://raw.githubusercontent.com",
+		quota:         &apiQuota{},
 	}
 }
 
@@ -62,10 +82,150 @@ func (gc *GithubCrawler) UsePlaywright(v bool) {
 	gc.usePlaywright = v
 }
 
+// SetUserAgent sets the User-Agent the HTML scraper sends and matches
+// against robots.txt
+func (gc *GithubCrawler) SetUserAgent(ua string) {
+	gc.htmlScraper.SetUserAgent(ua)
+}
+
+// SetFetchRepoPages controls whether HTML crawls open every repo's landing
+// page for stars, forks, topics and license (one extra request per repo)
+func (gc *GithubCrawler) SetFetchRepoPages(v bool) {
+	gc.fetchPages = v
+}
+
+// HTMLScraper returns the scraper used by the HTML crawl modes, e.g. to
+// tune its per-host limits, retry policy or headers
+func (gc *GithubCrawler) HTMLScraper() *scraper.HTTPScraper {
+	return gc.htmlScraper
+}
+
+// SetFrontierStrategy selects one of the built-in frontier scoring strategies
+func (gc *GithubCrawler) SetFrontierStrategy(name string) error {
+	score, err := lookupFrontierStrategy(name)
+	if err != nil {
+		return err
+	}
+	gc.frontierScore = score
+	return nil
+}
+
+// SetFrontierScorer installs a custom frontier scoring function
+func (gc *GithubCrawler) SetFrontierScorer(score ScoreFunc) {
+	if score != nil {
+		gc.frontierScore = score
+	}
+}
+
+// SetFrontierCap limits how many users can wait in the frontier (0 = unlimited)
+func (gc *GithubCrawler) SetFrontierCap(n int) {
+	if n >= 0 {
+		gc.frontierCap = n
+	}
+}
+
+// SetMarkovChain replaces the crawler's chain, e.g. with one restored from storage
+func (gc *GithubCrawler) SetMarkovChain(mc *markov.MarkovChain) {
+	if mc != nil {
+		gc.markovChain = mc
+	}
+}
+
+// MarkovChain returns the chain learned by the crawler
+func (gc *GithubCrawler) MarkovChain() *markov.MarkovChain {
+	return gc.markovChain
+}
+
+// SetCheckpointInterval sets how many crawl iterations pass between
+// Markov chain checkpoints (0 = only at the end of a crawl)
+func (gc *GithubCrawler) SetCheckpointInterval(n int) {
+	if n >= 0 {
+		gc.checkpointN = n
+	}
+}
+
+// checkpointMarkov persists the chain every checkpointN iterations,
+// or unconditionally when iteration is negative
+func (gc *GithubCrawler) checkpointMarkov(iteration int) {
+	if iteration >= 0 && (gc.checkpointN == 0 || iteration%gc.checkpointN != 0) {
+		return
+	}
+	if err := gc.storage.SaveMarkovChain(gc.markovChain); err != nil {
+		log.Printf("Markov checkpoint failed: %v", err)
+	}
+	if iteration < 0 {
+		if err := gc.UpdateRankings(); err != nil {
+			log.Printf("Rankings update failed: %v", err)
+		}
+	}
+}
+
+// UpdateRankings recomputes the stationary distribution of the chain and
+// stores it as repo and contact rankings
+func (gc *GithubCrawler) UpdateRankings() error {
+	res := gc.markovChain.StationaryDistribution(markov.DefaultDamping, markov.DefaultMaxIterations, markov.DefaultTolerance)
+	return gc.storage.SaveRankings(res.Scores, storage.RankingMeta{
+		Damping:    markov.DefaultDamping,
+		Iterations: res.Iterations,
+		Converged:  res.Converged,
+	})
+}
+
+// SetHTTPCache serves API and HTML responses from an on-disk cache (nil
+// disables it). Offline, only cached responses are used, so a past crawl
+// can be replayed without network access.
+func (gc *GithubCrawler) SetHTTPCache(c *httpcache.Cache, offline bool) {
+	gc.client.Transport = httpcache.Wrap(gc.client.Transport, c, offline)
+	gc.htmlScraper.SetCache(c, offline)
+}
+
+// SetProxy routes every API, HTML and robots.txt request through a proxy
+// (a zero Config goes direct). The HTTP cache, if any, is kept. It sets up
+// the crawler before crawls start; a job picks its own proxy with
+// JobOptions.Proxy.
+func (gc *GithubCrawler) SetProxy(cfg proxy.Config) error {
+	tr, err := cfg.Transport()
+	if err != nil {
+		return err
+	}
+	gc.client.Transport = httpcache.Rebase(gc.client.Transport, tr)
+	gc.htmlScraper.SetTransport(tr)
+	return nil
+}
+
+// SetFetchStarred makes crawls also record the repos each user starred
+func (gc *GithubCrawler) SetFetchStarred(v bool) {
+	gc.fetchStarred = v
+}
+
+// SetMaxDepth limits how many hops away from the start user the crawl goes (0 = unlimited)
+func (gc *GithubCrawler) SetMaxDepth(n int) {
+	if n >= 0 {
+		gc.maxDepth = n
+	}
+}
+
+// makeRequest GETs an API url, sleeping until the quota resets when it is
+// used up
 func (gc *GithubCrawler) makeRequest(url string) ([]byte, error) {
+	return gc.request(url, false)
+}
+
+// makeFallbackRequest is makeRequest for calls with an HTML fallback: while
+// the fallback is enabled, an exhausted quota fails with ErrQuotaExhausted
+// instead of sleeping
+func (gc *GithubCrawler) makeFallbackRequest(url string) ([]byte, error) {
+	return gc.request(url, gc.htmlFallback)
+}
+
+func (gc *GithubCrawler) request(url string, failFast bool) ([]byte, error) {
 	maxRetries := 5
 	retryDelay := time.Second * 5
 
+	if failFast && gc.quotaExhausted() {
+		return nil, ErrQuotaExhausted
+	}
+
 	for i := 0; i < maxRetries; i++ {
 		req, _ := http.NewRequest("GET", url, nil)
 		req.Header.Set("User-Agent", "Fyne-on-Crawler/1.0")
@@ -86,6 +246,11 @@ func (gc *GithubCrawler) makeRequest(url string) ([]byte, error) {
 
 			if remaining == "0" && resetTime != "" {
 				resetUnix, _ := strconv.ParseInt(resetTime, 10, 64)
+				gc.setQuotaReset(time.Unix(resetUnix, 0))
+				if failFast {
+					resp.Body.Close()
+					return nil, fmt.Errorf("%w until %s", ErrQuotaExhausted, time.Unix(resetUnix, 0).Format(time.RFC3339))
+				}
 				sleepDuration := time.Until(time.Unix(resetUnix, 0)) + time.Second
 
 				log.Printf("Rate limit hit. Sleeping for %v...", sleepDuration)
@@ -135,6 +300,7 @@ func (gc *GithubCrawler) FetchUserProfile(username string) (*models.Contact, err
 		Email     string `json:"email"`
 		Location  string `json:"location"`
 		Bio       string `json:"bio"`
+		Followers int    `json:"followers"`
 	}
 
 	if err := json.Unmarshal(body, &userData); err != nil {
@@ -150,6 +316,7 @@ func (gc *GithubCrawler) FetchUserProfile(username string) (*models.Contact, err
 		Email:     userData.Email,
 		Location:  userData.Location,
 		Bio:       userData.Bio,
+		Followers: userData.Followers,
 		UpdatedAt: time.Now(),
 	}
 
@@ -220,7 +387,11 @@ func (gc *GithubCrawler) FetchRepositoryContributors(owner, repo string) ([]mode
 	for page <= 2 {
 		url := fmt.Sprintf("https://api.github.com/repos/%s/%s/contributors?per_page=100&page=%d", owner, repo, page)
 
-		body, err := gc.makeRequest(url)
+		body, err := gc.makeFallbackRequest(url)
+		if errors.Is(err, ErrQuotaExhausted) && len(contacts) == 0 {
+			log.Printf("  %v, scraping contributors of %s/%s from HTML", err, owner, repo)
+			return gc.fetchContributorsHTML(owner, repo)
+		}
 		if err != nil {
 			break
 		}
@@ -243,11 +414,12 @@ func (gc *GithubCrawler) FetchRepositoryContributors(owner, repo string) ([]mode
 
 		for _, cd := range contribData {
 			contact := models.Contact{
-				ID:        fmt.Sprintf("%d", cd.ID),
-				Login:     cd.Login,
-				URL:       cd.HTMLURL,
-				Avatar:    cd.AvatarURL,
-				UpdatedAt: time.Now(),
+				ID:            fmt.Sprintf("%d", cd.ID),
+				Login:         cd.Login,
+				URL:           cd.HTMLURL,
+				Avatar:        cd.AvatarURL,
+				Contributions: cd.Contributions,
+				UpdatedAt:     time.Now(),
 			}
 			contacts = append(contacts, contact)
 		}
@@ -262,12 +434,16 @@ func (gc *GithubCrawler) FetchRepositoryContributors(owner, repo string) ([]mode
 func (gc *GithubCrawler) FetchRepositoryIssues(owner, repo string, saveFunc func(models.Issue) error) error {
 	states := []string{"open", "closed"}
 
-	for _, state := range states {
+	for i, state := range states {
 		for page := 1; ; page++ {
 			url := fmt.Sprintf("https://api.github.com/repos/%s/%s/issues?state=%s&per_page=100&page=%d", owner, repo, state, page)
 			log.Printf("  Fetching %s issues page %d for %s/%s", state, page, owner, repo)
 
-			body, err := gc.makeRequest(url)
+			body, err := gc.makeFallbackRequest(url)
+			if errors.Is(err, ErrQuotaExhausted) {
+				log.Printf("  %v, scraping %s/%s issues from HTML", err, owner, repo)
+				return gc.fetchIssuesHTML(owner, repo, states[i:], saveFunc)
+			}
 
 			if err != nil {
 				log.Printf("Error fetching issues page %d for %s/%s (state: %s): %v", page, owner, repo, state, err)
@@ -412,13 +588,17 @@ func (gc *GithubCrawler) FetchUserRepos(username string) ([]models.Repo, error)
 			Owner struct {
 				Login string `json:"login"`
 			} `json:"owner"`
-			HTMLURL     string `json:"html_url"`
-			Description string `json:"description"`
-			Stars       int    `json:"stargazers_count"`
-			Language    string `json:"language"`
+			HTMLURL     string   `json:"html_url"`
+			Description string   `json:"description"`
+			Stars       int      `json:"stargazers_count"`
+			Forks       int      `json:"forks_count"`
+			Language    string   `json:"language"`
+			Homepage    string   `json:"homepage"`
+			Topics      []string `json:"topics"`
 			License     struct {
 				Key string `json:"key"`
 			} `json:"license"`
+			PushedAt time.Time `json:"pushed_at"`
 		}
 		if err := json.Unmarshal(body, &reposData); err != nil {
 			break
@@ -434,8 +614,12 @@ func (gc *GithubCrawler) FetchUserRepos(username string) ([]models.Repo, error)
 				URL:         rd.HTMLURL,
 				Description: rd.Description,
 				Stars:       rd.Stars,
+				Forks:       rd.Forks,
 				Language:    rd.Language,
+				Homepage:    rd.Homepage,
+				Topics:      rd.Topics,
 				License:     rd.License.Key,
+				PushedAt:    rd.PushedAt,
 				UpdatedAt:   time.Now(),
 			}
 			repos = append(repos, repo)
@@ -456,78 +640,25 @@ func (gc *GithubCrawler) FetchOrgReposHTML(org string) ([]models.Repo, error) {
 		wg.Add(1)
 		go func(p int) {
 			defer wg.Done()
-			url := fmt.Sprintf("https://github.com/orgs/%s/repositories?page=%d", org, p)
+			url := fmt.Sprintf("%s/orgs/%s/repositories?page=%d", gc.htmlScraper.BaseURL(), org, p)
 			doc, err := gc.htmlScraper.FetchDocument(url)
+			if errors.Is(err, scraper.ErrDisallowed) {
+				log.Printf("Skipping %s page %d: %v", org, p, err)
+				return
+			}
 			if err != nil {
 				log.Printf("HTML fetch failed for %s page %d: %v", org, p, err)
 				return
 			}
 
-			found := 0
-
-			doc.Find("a[data-hovercard-type='repository']").Each(func(i int, s *goquery.Selection) {
-				href, _ := s.Attr("href")
-				parts := strings.Split(href, "/")
-				if len(parts) < 3 {
-					return
-				}
-				id := parts[1] + "/" + parts[2]
-
-				mu.Lock()
-				if seen[id] {
-					mu.Unlock()
-					return
-				}
-				seen[id] = true
-				repo := models.Repo{
-					Owner:     parts[1],
-					Name:      parts[2],
-					URL:       "https://github.com" + href,
-					ID:        id,
-					CreatedAt: time.Now(),
-					UpdatedAt: time.Now(),
-				}
-				repos = append(repos, repo)
-				gc.storage.SaveRepo(repo)
-				mu.Unlock()
-				found++
-			})
-
-			doc.Find("h3 a").Each(func(i int, s *goquery.Selection) {
-				href, _ := s.Attr("href")
-				parts := strings.Split(href, "/")
-				if len(parts) < 3 {
-					return
-				}
-				id := parts[1] + "/" + parts[2]
+			run := gc.htmlScraper.Selectors().Run(url)
+			defer run.Done()
 
-				mu.Lock()
-				if seen[id] {
-					mu.Unlock()
-					return
-				}
-				seen[id] = true
-				repo := models.Repo{
-					Owner:     parts[1],
-					Name:      parts[2],
-					URL:       "https://github.com" + href,
-					ID:        id,
-					CreatedAt: time.Now(),
-					UpdatedAt: time.Now(),
-				}
-				repos = append(repos, repo)
-				gc.storage.SaveRepo(repo)
-				mu.Unlock()
-				found++
-			})
-
-			doc.Find("li.Box-row a").Each(func(i int, s *goquery.Selection) {
+			found := 0
+			run.Find(doc.Selection, "org_repos.link").Each(func(i int, s *goquery.Selection) {
 				href, _ := s.Attr("href")
-				if !strings.Contains(href, "/"+org+"/") {
-					return
-				}
 				parts := strings.Split(href, "/")
-				if len(parts) < 3 {
+				if len(parts) < 3 || !strings.EqualFold(parts[1], org) {
 					return
 				}
 				id := parts[1] + "/" + parts[2]
@@ -541,9 +672,8 @@ func (gc *GithubCrawler) FetchOrgReposHTML(org string) ([]models.Repo, error) {
 				repo := models.Repo{
 					Owner:     parts[1],
 					Name:      parts[2],
-					URL:       "https://github.com" + href,
+					URL:       "https://github.com/" + id,
 					ID:        id,
-					CreatedAt: time.Now(),
 					UpdatedAt: time.Now(),
 				}
 				repos = append(repos, repo)
@@ -599,70 +729,145 @@ func (gc *GithubCrawler) GetTrendingDevelopers(language string) ([]string, error
 	return developers, nil
 }
 
+// CrawlStart crawls GitHub breadth first from startUsername
 func (gc *GithubCrawler) CrawlStart(startUsername string) error {
-	visited := make(map[string]bool)
-	queue := []string{startUsername}
-	iteration := 0
-
-	for len(queue) > 0 && iteration < gc.maxIterations {
-		username := queue[0]
-		queue = queue[1:]
+	return gc.CrawlSource(gc, startUsername)
+}
 
-		if visited[username] {
+// saveStarred stores the repos a user starred along with starred edges
+func (gc *GithubCrawler) saveStarred(username string) {
+	starred, err := gc.FetchUserStarredRepos(username)
+	if err != nil {
+		log.Printf("  Failed to fetch starred repos for %s: %v", username, err)
+		return
+	}
+	for _, repo := range starred {
+		if _, err := gc.storage.SaveRepo(repo); err != nil {
+			log.Printf("  SaveRepo failed for %s: %v", repo.ID, err)
 			continue
 		}
-		visited[username] = true
-		iteration++
-
-		log.Printf("Crawling: %s (iteration %d)\n", username, iteration)
+		gc.storage.SaveEdge(models.EdgeStarred, username, repo.ID)
+	}
+}
 
-		contact, err := gc.FetchUserProfile(username)
-		if err == nil {
-			gc.storage.SaveContact(*contact)
+// saveStarredHTML stores the repos a user starred, read from the stars tab,
+// and a starred edge to each
+func (gc *GithubCrawler) saveStarredHTML(username string) {
+	starred, err := gc.htmlScraper.FetchUserStarred(username)
+	if err != nil {
+		log.Printf("  Failed to scrape starred repos for %s: %v", username, err)
+		return
+	}
+	for _, r := range starred {
+		repo := models.Repo{
+			Name:        r.Name,
+			Owner:       r.Owner,
+			URL:         r.URL,
+			Description: r.Description,
+			Language:    r.Language,
+			Stars:       r.Stars,
+			ID:          r.Owner + "/" + r.Name,
+			UpdatedAt:   time.Now(),
 		}
+		if _, err := gc.storage.SaveRepo(repo); err != nil {
+			log.Printf("  SaveRepo failed for %s: %v", repo.ID, err)
+			continue
+		}
+		gc.storage.SaveEdge(models.EdgeStarred, username, repo.ID)
+	}
+}
 
-		repos, err := gc.FetchUserRepos(username)
-		if err == nil {
-			for _, repo := range repos {
-				_, saveErr := gc.storage.SaveRepo(repo)
-				if saveErr != nil {
-					log.Printf("  SaveRepo failed for %s: %v\n", repo.ID, saveErr)
-					continue
-				}
-
-				repoID := repo.Owner + "/" + repo.Name
-				log.Printf("  Processing repo: %s\n", repoID)
-
-				issueErr := gc.FetchRepositoryIssues(repo.Owner, repo.Name, func(issue models.Issue) error {
-					_, err := gc.storage.SaveIssue(issue)
-					return err
-				})
-
-				if issueErr != nil {
-					log.Printf("  Error processing issues for %s: %v", repoID, issueErr)
-				}
+// processRepo stores the issues, pull requests and contributors of a repo
+// and returns the contributors. Contributors are merged into previously
+// fetched profiles, since the contributors endpoint does not report
+// followers, company or bio.
+func (gc *GithubCrawler) processRepo(src Source, repo models.Repo) []models.Contact {
+	repoID := repo.Owner + "/" + repo.Name
+	log.Printf("  Processing repo: %s\n", repoID)
+
+	issueErr := src.FetchRepositoryIssues(repo.Owner, repo.Name, func(issue models.Issue) error {
+		_, err := gc.storage.SaveIssue(issue)
+		return err
+	})
+
+	if issueErr != nil {
+		log.Printf("  Error processing issues for %s: %v", repoID, issueErr)
+	}
 
-				prs, _ := gc.FetchRepositoryPRs(repo.Owner, repo.Name)
-				for _, pr := range prs {
-					gc.storage.SavePullRequest(pr)
-				}
+	prs, _ := src.FetchRepositoryPRs(repo.Owner, repo.Name)
+	for _, pr := range prs {
+		gc.storage.SavePullRequest(pr)
+	}
 
-				contributors, _ := gc.FetchRepositoryContributors(repo.Owner, repo.Name)
-				for _, contrib := range contributors {
-					gc.storage.SaveContact(contrib)
+	contributors, _ := src.FetchRepositoryContributors(repo.Owner, repo.Name)
+	for i, contrib := range contributors {
+		merged, err := gc.storage.MergeContact(contrib)
+		if err != nil {
+			log.Printf("  SaveContact failed for %s: %v", contrib.Login, err)
+		} else {
+			contributors[i] = merged
+		}
+		gc.storage.SaveEdge(models.EdgeContributor, contrib.Login, repoID)
+		gc.markovChain.AddTransition(repoID, contrib.Login)
+	}
 
-					if len(queue) < 100 && !visited[contrib.Login] {
-						queue = append(queue, contrib.Login)
-					}
-				}
+	if gc.fetchDeps {
+		if fs, ok := src.(FileSource); ok {
+			if _, err := gc.SaveRepoDependencies(fs, repo); err != nil {
+				log.Printf("  Error extracting dependencies for %s: %v", repoID, err)
 			}
 		}
+	}
 
-		time.Sleep(time.Duration(gc.delayMs) * time.Millisecond)
+	return contributors
+}
+
+// enrichRepoHTML fills repo with the data from its landing page. Values
+// the page did not yield (a selector missed) keep what repo already had.
+func (gc *GithubCrawler) enrichRepoHTML(repo *models.Repo) {
+	if !gc.fetchPages {
+		return
+	}
+	page, err := gc.htmlScraper.FetchRepoPage(repo.Owner, repo.Name)
+	if errors.Is(err, scraper.ErrDisallowed) {
+		log.Printf("Skipping repo page %s: %v", repo.ID, err)
+		return
+	}
+	if err != nil {
+		log.Printf("Repo page fetch failed for %s: %v", repo.ID, err)
+		return
 	}
 
-	log.Printf("Crawling completed. Processed %d users\n", iteration)
-	return nil
+	if page.Stars > 0 {
+		repo.Stars = page.Stars
+	}
+	if page.Forks > 0 {
+		repo.Forks = page.Forks
+	}
+	if page.Watchers > 0 {
+		repo.Watchers = page.Watchers
+	}
+	if len(page.Topics) > 0 {
+		repo.Topics = page.Topics
+	}
+	if page.Homepage != "" {
+		repo.Homepage = page.Homepage
+	}
+	if !page.LastCommit.IsZero() {
+		repo.LastCommitAt = page.LastCommit
+	}
+	if page.Description != "" {
+		repo.Description = page.Description
+	}
+	if page.License != "" {
+		repo.License = page.License
+	}
+	if page.Language != "" {
+		repo.Language = page.Language
+	}
+	if len(page.Languages) > 0 {
+		repo.Languages = page.Languages
+	}
 }
 
 func (gc *GithubCrawler) CrawlStartOrgsHTML(orgs []string) error {
@@ -678,6 +883,7 @@ func (gc *GithubCrawler) CrawlStartOrgsHTML(orgs []string) error {
 		}
 
 		for _, repo := range repos {
+			gc.enrichRepoHTML(&repo)
 			isNew, saveErr := gc.storage.SaveRepo(repo)
 			if saveErr != nil {
 				log.Printf("SaveRepo failed for %s: %v", repo.ID, saveErr)
@@ -713,6 +919,9 @@ func (gc *GithubCrawler) CrawlStartHTML(startUsername string) error {
 		frontier = append(frontier, startUsername)
 	} else {
 		trending, err := gc.htmlScraper.FetchTrendingDevelopers()
+		if errors.Is(err, scraper.ErrDisallowed) {
+			log.Printf("Skipping trending developers: %v", err)
+		}
 		if err == nil && len(trending) > 0 {
 			frontier = append(frontier, trending...)
 		} else {
@@ -730,6 +939,10 @@ func (gc *GithubCrawler) CrawlStartHTML(startUsername string) error {
 		gc.visited[current] = true
 
 		userRepos, err := gc.htmlScraper.FetchUserRepos(current)
+		if errors.Is(err, scraper.ErrDisallowed) {
+			log.Printf("Skipping %s: %v", current, err)
+			continue
+		}
 		if err != nil {
 			if gc.delayMs > 0 {
 				time.Sleep(time.Duration(gc.delayMs) * time.Millisecond)
@@ -737,6 +950,10 @@ func (gc *GithubCrawler) CrawlStartHTML(startUsername string) error {
 			continue
 		}
 
+		if gc.fetchStarred {
+			gc.saveStarredHTML(current)
+		}
+
 		for _, r := range userRepos {
 			repo := models.Repo{
 				Name:        r.Name,
@@ -746,9 +963,9 @@ func (gc *GithubCrawler) CrawlStartHTML(startUsername string) error {
 				Language:    r.Language,
 				Stars:       r.Stars,
 				ID:          r.Owner + "/" + r.Name,
-				CreatedAt:   time.Now(),
 				UpdatedAt:   time.Now(),
 			}
+			gc.enrichRepoHTML(&repo)
 
 			isNew, saveErr := gc.storage.SaveRepo(repo)
 			if saveErr != nil {
@@ -769,6 +986,7 @@ func (gc *GithubCrawler) CrawlStartHTML(startUsername string) error {
 			gc.markovChain.AddTransition(current, r.Owner+"/"+r.Name)
 
 			iter++
+			gc.checkpointMarkov(iter)
 			if gc.delayMs > 0 {
 				time.Sleep(time.Duration(gc.delayMs) * time.Millisecond)
 			}
@@ -778,5 +996,6 @@ func (gc *GithubCrawler) CrawlStartHTML(startUsername string) error {
 		}
 	}
 
+	gc.checkpointMarkov(-1)
 	return nil
 }
@@ -0,0 +1,332 @@
+package crawler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"Fyne-on/pkg/models"
+)
+
+// DefaultGitLabURL is the GitLab instance used when none is configured
+const DefaultGitLabURL = "https://gitlab.com"
+
+// GitLabSource reads users, projects, issues and merge requests from the
+// GitLab REST API (v4) of gitlab.com or a self-hosted instance
+type GitLabSource struct {
+	baseURL string
+	host    string
+	token   string
+	client  *http.Client
+}
+
+var _ Source = (*GitLabSource)(nil)
+
+// NewGitLabSource creates a source for the instance at baseURL ("" for
+// gitlab.com). token is a personal access token (optional for public
+// data); client may be shared with other sources, nil uses a new one.
+func NewGitLabSource(baseURL, token string, client *http.Client) (*GitLabSource, error) {
+	if baseURL == "" {
+		baseURL = DefaultGitLabURL
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid GitLab URL %q", baseURL)
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+	return &GitLabSource{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		host:    u.Host,
+		token:   token,
+		client:  client,
+	}, nil
+}
+
+// Name implements Source
+func (g *GitLabSource) Name() string {
+	return models.SourceGitLab
+}
+
+// Host returns the host records from this instance are namespaced by
+func (g *GitLabSource) Host() string {
+	return g.host
+}
+
+func (g *GitLabSource) header() http.Header {
+	header := http.Header{}
+	if g.token != "" {
+		header.Set("PRIVATE-TOKEN", g.token)
+	}
+	return header
+}
+
+// get decodes the JSON response of an API path into v
+func (g *GitLabSource) get(path string, v interface{}) error {
+	return fetchJSON(g.client, g.baseURL+"/api/v4"+path, g.header(), v)
+}
+
+// FetchFile implements FileSource
+func (g *GitLabSource) FetchFile(owner, repo, path string) ([]byte, error) {
+	rawURL := fmt.Sprintf("%s/api/v4/projects/%s/repository/files/%s/raw?ref=HEAD", g.baseURL, g.projectPath(owner, repo), url.PathEscape(path))
+	return fetch(g.client, rawURL, g.header())
+}
+
+// projectPath is the URL-encoded "namespace/project" the API accepts in
+// place of a project ID
+func (g *GitLabSource) projectPath(owner, repo string) string {
+	return url.PathEscape(localOwner(g.host, owner) + "/" + repo)
+}
+
+type gitlabUser struct {
+	ID           int    `json:"id"`
+	Username     string `json:"username"`
+	Name         string `json:"name"`
+	WebURL       string `json:"web_url"`
+	AvatarURL    string `json:"avatar_url"`
+	Organization string `json:"organization"`
+	PublicEmail  string `json:"public_email"`
+	Location     string `json:"location"`
+	Bio          string `json:"bio"`
+	Followers    int    `json:"followers"`
+}
+
+func (g *GitLabSource) contact(u gitlabUser) models.Contact {
+	return models.Contact{
+		ID:        strconv.Itoa(u.ID),
+		Login:     namespacedLogin(g.host, u.Username),
+		URL:       u.WebURL,
+		Avatar:    u.AvatarURL,
+		Company:   u.Organization,
+		Email:     u.PublicEmail,
+		Location:  u.Location,
+		Bio:       u.Bio,
+		Followers: u.Followers,
+		Source:    models.SourceGitLab,
+		UpdatedAt: time.Now(),
+	}
+}
+
+// FetchUserProfile implements Source
+func (g *GitLabSource) FetchUserProfile(login string) (*models.Contact, error) {
+	var found []gitlabUser
+	if err := g.get("/users?username="+url.QueryEscape(localLogin(g.host, login)), &found); err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("gitlab user %s not found", login)
+	}
+
+	// The search result lacks profile details
+	var user gitlabUser
+	if err := g.get(fmt.Sprintf("/users/%d", found[0].ID), &user); err != nil {
+		user = found[0]
+	}
+	contact := g.contact(user)
+	return &contact, nil
+}
+
+// FetchUserRepos implements Source. Only projects in the user's own
+// namespace are listed, as on the user's GitLab profile.
+func (g *GitLabSource) FetchUserRepos(login string) ([]models.Repo, error) {
+	repos := []models.Repo{}
+	for page := 1; ; page++ {
+		var projects []struct {
+			ID          int      `json:"id"`
+			Path        string   `json:"path"`
+			WebURL      string   `json:"web_url"`
+			Description string   `json:"description"`
+			StarCount   int      `json:"star_count"`
+			ForksCount  int      `json:"forks_count"`
+			Topics      []string `json:"topics"`
+			TagList     []string `json:"tag_list"` // topics before GitLab 14.0
+			Namespace   struct {
+				FullPath string `json:"full_path"`
+			} `json:"namespace"`
+			CreatedAt      time.Time `json:"created_at"`
+			LastActivityAt time.Time `json:"last_activity_at"`
+		}
+		path := fmt.Sprintf("/users/%s/projects?per_page=100&page=%d", url.PathEscape(localLogin(g.host, login)), page)
+		if err := g.get(path, &projects); err != nil {
+			if page == 1 {
+				return nil, err
+			}
+			break
+		}
+		if len(projects) == 0 {
+			break
+		}
+
+		for _, p := range projects {
+			owner := namespacedOwner(g.host, p.Namespace.FullPath)
+			topics := p.Topics
+			if len(topics) == 0 {
+				topics = p.TagList
+			}
+			repo := models.Repo{
+				ID:          owner + "/" + p.Path,
+				Name:        p.Path,
+				Owner:       owner,
+				URL:         p.WebURL,
+				Description: p.Description,
+				Stars:       p.StarCount,
+				Forks:       p.ForksCount,
+				Topics:      topics,
+				Source:      models.SourceGitLab,
+				PushedAt:    p.LastActivityAt,
+				CreatedAt:   p.CreatedAt,
+				UpdatedAt:   time.Now(),
+			}
+
+			// Percent of code per language, like GitHub's language bar
+			var languages map[string]float64
+			if err := g.get(fmt.Sprintf("/projects/%d/languages", p.ID), &languages); err == nil && len(languages) > 0 {
+				repo.Languages = languages
+				best := -1.0
+				for lang, pct := range languages {
+					if pct > best || (pct == best && lang < repo.Language) {
+						repo.Language, best = lang, pct
+					}
+				}
+			}
+			repos = append(repos, repo)
+		}
+	}
+	return repos, nil
+}
+
+// gitlabState maps GitLab's "opened" and "locked" onto the states stored
+// for GitHub
+func gitlabState(state string) string {
+	switch state {
+	case "opened":
+		return "open"
+	case "locked":
+		return "closed"
+	}
+	return state
+}
+
+type gitlabIssue struct {
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	State       string `json:"state"`
+	WebURL      string `json:"web_url"`
+	Author      struct {
+		Username string `json:"username"`
+	} `json:"author"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// FetchRepositoryIssues implements Source
+func (g *GitLabSource) FetchRepositoryIssues(owner, repo string, saveFunc func(models.Issue) error) error {
+	repoID := namespacedOwner(g.host, localOwner(g.host, owner)) + "/" + repo
+	for _, state := range []string{"opened", "closed"} {
+		for page := 1; ; page++ {
+			var issues []gitlabIssue
+			path := fmt.Sprintf("/projects/%s/issues?state=%s&per_page=100&page=%d", g.projectPath(owner, repo), state, page)
+			if err := g.get(path, &issues); err != nil {
+				return err
+			}
+			if len(issues) == 0 {
+				break
+			}
+			for _, is := range issues {
+				issue := models.Issue{
+					ID:        strconv.Itoa(is.ID),
+					RepoID:    repoID,
+					Title:     is.Title,
+					URL:       is.WebURL,
+					State:     gitlabState(is.State),
+					Body:      is.Description,
+					Author:    namespacedLogin(g.host, is.Author.Username),
+					CreatedAt: is.CreatedAt,
+					UpdatedAt: is.UpdatedAt,
+					Source:    models.SourceGitLab,
+				}
+				if err := saveFunc(issue); err != nil {
+					log.Printf("Failed to save issue %s: %v", issue.ID, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// FetchRepositoryPRs implements Source with the project's merge requests
+func (g *GitLabSource) FetchRepositoryPRs(owner, repo string) ([]models.PullRequest, error) {
+	repoID := namespacedOwner(g.host, localOwner(g.host, owner)) + "/" + repo
+	prs := []models.PullRequest{}
+	for page := 1; ; page++ {
+		var mrs []gitlabIssue
+		path := fmt.Sprintf("/projects/%s/merge_requests?state=all&per_page=100&page=%d", g.projectPath(owner, repo), page)
+		if err := g.get(path, &mrs); err != nil {
+			if page == 1 {
+				return nil, err
+			}
+			break
+		}
+		if len(mrs) == 0 {
+			break
+		}
+		for _, mr := range mrs {
+			prs = append(prs, models.PullRequest{
+				ID:        strconv.Itoa(mr.ID),
+				RepoID:    repoID,
+				Title:     mr.Title,
+				URL:       mr.WebURL,
+				State:     gitlabState(mr.State),
+				Body:      mr.Description,
+				Author:    namespacedLogin(g.host, mr.Author.Username),
+				CreatedAt: mr.CreatedAt,
+				UpdatedAt: mr.UpdatedAt,
+				Source:    models.SourceGitLab,
+			})
+		}
+	}
+	return prs, nil
+}
+
+// FetchRepositoryContributors implements Source. GitLab's contributor
+// statistics only carry commit author names, so the project members are
+// returned, with commit counts matched to them by name where possible.
+func (g *GitLabSource) FetchRepositoryContributors(owner, repo string) ([]models.Contact, error) {
+	commits := map[string]int{}
+	var stats []struct {
+		Name    string `json:"name"`
+		Commits int    `json:"commits"`
+	}
+	if err := g.get(fmt.Sprintf("/projects/%s/repository/contributors?per_page=100", g.projectPath(owner, repo)), &stats); err == nil {
+		for _, st := range stats {
+			commits[st.Name] += st.Commits
+		}
+	}
+
+	contacts := []models.Contact{}
+	for page := 1; page <= 2; page++ {
+		var members []gitlabUser
+		path := fmt.Sprintf("/projects/%s/members/all?per_page=100&page=%d", g.projectPath(owner, repo), page)
+		if err := g.get(path, &members); err != nil {
+			if page == 1 {
+				return nil, err
+			}
+			break
+		}
+		if len(members) == 0 {
+			break
+		}
+		for _, m := range members {
+			contact := g.contact(m)
+			contact.Contributions = commits[m.Name]
+			contacts = append(contacts, contact)
+		}
+	}
+	return contacts, nil
+}
@@ -0,0 +1,133 @@
+package crawler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Fyne-on/pkg/database"
+	"Fyne-on/pkg/models"
+	"Fyne-on/pkg/storage"
+)
+
+// fakeGitLab serves a tiny GitLab API: alice owns alice/demo, which bob
+// is a member of
+func fakeGitLab(t *testing.T) *httptest.Server {
+	t.Helper()
+	routes := map[string]interface{}{
+		"/api/v4/users?username=alice": []obj{{"id": 1, "username": "alice"}},
+		"/api/v4/users/1":              obj{"id": 1, "username": "alice", "web_url": "https://gl/alice", "organization": "ACME", "followers": 7},
+		"/api/v4/users?username=bob":   []obj{{"id": 2, "username": "bob"}},
+		"/api/v4/users/2":              obj{"id": 2, "username": "bob", "followers": 3},
+		"/api/v4/users/alice/projects?per_page=100&page=1": []obj{{
+			"id": 10, "path": "demo", "web_url": "https://gl/alice/demo", "description": "Demo project",
+			"star_count": 42, "forks_count": 5, "topics": []string{"cli"},
+			"namespace":        obj{"full_path": "alice"},
+			"last_activity_at": "2024-05-01T10:00:00Z",
+		}},
+		"/api/v4/projects/10/languages": obj{"Go": 80.5, "Shell": 19.5},
+		"/api/v4/projects/alice%2Fdemo/issues?state=opened&per_page=100&page=1": []obj{{
+			"id": 100, "title": "Crash on start", "state": "opened", "web_url": "https://gl/alice/demo/-/issues/1",
+			"author": obj{"username": "bob"},
+		}},
+		"/api/v4/projects/alice%2Fdemo/issues?state=closed&per_page=100&page=1": []obj{{
+			"id": 101, "title": "Typo", "state": "closed", "author": obj{"username": "alice"},
+		}},
+		"/api/v4/projects/alice%2Fdemo/merge_requests?state=all&per_page=100&page=1": []obj{{
+			"id": 200, "title": "Fix crash", "state": "merged", "author": obj{"username": "bob"},
+		}},
+		"/api/v4/projects/alice%2Fdemo/repository/contributors?per_page=100": []obj{
+			{"name": "Bob B", "commits": 12},
+		},
+		"/api/v4/projects/alice%2Fdemo/members/all?per_page=100&page=1": []obj{
+			{"id": 1, "username": "alice", "name": "Alice A"},
+			{"id": 2, "username": "bob", "name": "Bob B"},
+		},
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.EscapedPath()
+		if r.URL.RawQuery != "" {
+			key += "?" + r.URL.RawQuery
+		}
+		if v, ok := routes[key]; ok {
+			json.NewEncoder(w).Encode(v)
+			return
+		}
+		// Later pages and unknown users are empty
+		if strings.Contains(key, "page=") || strings.Contains(key, "/projects") {
+			w.Write([]byte("[]"))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+}
+
+type obj = map[string]interface{}
+
+func TestCrawlGitLabSource(t *testing.T) {
+	srv := fakeGitLab(t)
+	defer srv.Close()
+
+	db, err := database.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	st := storage.NewStorageService(db)
+
+	gc := NewGithubCrawler(st)
+	gc.SetDelayMs(0)
+	gc.SetMaxIterations(5)
+
+	src, err := NewGitLabSource(srv.URL, "", gc.HTTPClient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := src.Host()
+
+	if err := gc.CrawlSource(src, "alice"); err != nil {
+		t.Fatalf("CrawlSource failed: %v", err)
+	}
+
+	repo, err := st.GetRepo(host+"/alice", "demo")
+	if err != nil {
+		t.Fatalf("Expected %s/alice/demo to be stored: %v", host, err)
+	}
+	if repo.Source != models.SourceGitLab || repo.Stars != 42 || repo.Language != "Go" {
+		t.Errorf("Unexpected repo: %+v", repo)
+	}
+
+	issues, _ := st.GetRepoIssues(host + "/alice/demo")
+	if len(issues) != 2 {
+		t.Fatalf("Expected 2 issues, got %d", len(issues))
+	}
+	for _, is := range issues {
+		if is.ID == "100" && (is.State != "open" || is.Author != "bob@"+host || is.Source != models.SourceGitLab) {
+			t.Errorf("Unexpected issue: %+v", is)
+		}
+	}
+
+	prs, _ := st.GetRepoPullRequests(host + "/alice/demo")
+	if len(prs) != 1 || prs[0].State != "merged" {
+		t.Errorf("Expected 1 merged merge request, got %+v", prs)
+	}
+
+	bob, err := st.GetContact("bob@" + host)
+	if err != nil {
+		t.Fatalf("Expected member bob to be crawled: %v", err)
+	}
+	if bob.Source != models.SourceGitLab || bob.Followers != 3 {
+		t.Errorf("Unexpected contact: %+v", bob)
+	}
+
+	alice, err := src.FetchUserProfile("alice@" + host)
+	if err != nil {
+		t.Fatalf("FetchUserProfile failed: %v", err)
+	}
+	if alice.Login != "alice@"+host || alice.Company != "ACME" || alice.Followers != 7 {
+		t.Errorf("Expected alice's profile details, got %+v", alice)
+	}
+}
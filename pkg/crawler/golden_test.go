@@ -0,0 +1,90 @@
+package crawler
+
+import (
+	"flag"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"Fyne-on/pkg/database"
+	"Fyne-on/pkg/scraper/scrapertest"
+	"Fyne-on/pkg/storage"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files from the current parser output")
+
+func TestGoldenOrgReposHTML(t *testing.T) {
+	srv, err := scrapertest.NewServer(filepath.Join("..", "scraper", "testdata", "fixtures"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	db, err := database.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	gc := NewGithubCrawler(storage.NewStorageService(db))
+	gc.HTMLScraper().SetBaseURL(srv.URL)
+	gc.HTMLScraper().SetHostLimits(8, 0, 1)
+
+	repos, err := gc.FetchOrgReposHTML("octo-org")
+	if err != nil {
+		t.Fatalf("FetchOrgReposHTML failed: %v", err)
+	}
+
+	// Pages are fetched concurrently, so order and timestamps vary
+	sort.Slice(repos, func(i, j int) bool { return repos[i].ID < repos[j].ID })
+	for i := range repos {
+		repos[i].UpdatedAt = time.Time{}
+	}
+	scrapertest.Golden(t, filepath.Join("testdata", "golden", "org_repos_octo-org.json"), repos, *update)
+}
+
+func TestOrgReposHTMLRecrawlKeepsHistory(t *testing.T) {
+	srv, err := scrapertest.NewServer(filepath.Join("..", "scraper", "testdata", "fixtures"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	db, err := database.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	store := storage.NewStorageService(db)
+	gc := NewGithubCrawler(store)
+	gc.HTMLScraper().SetBaseURL(srv.URL)
+	gc.HTMLScraper().SetHostLimits(8, 0, 1)
+
+	// The HTML pages do not show when a repo was created, so crawling them
+	// again must not record a change
+	if _, err := gc.FetchOrgReposHTML("octo-org"); err != nil {
+		t.Fatalf("FetchOrgReposHTML failed: %v", err)
+	}
+	repos, err := gc.FetchOrgReposHTML("octo-org")
+	if err != nil {
+		t.Fatalf("FetchOrgReposHTML failed: %v", err)
+	}
+	if len(repos) == 0 {
+		t.Fatal("Expected repos from the fixtures")
+	}
+	for _, repo := range repos {
+		written, err := store.SaveRepo(repo)
+		if err != nil {
+			t.Fatalf("SaveRepo failed: %v", err)
+		}
+		if written {
+			t.Errorf("Expected an unchanged %s not to be written", repo.ID)
+		}
+		changes, _, _ := store.GetRepoHistory(repo.Owner, repo.Name, storage.Page{})
+		if len(changes) != 0 {
+			t.Errorf("Expected no history for %s, got %+v", repo.ID, changes)
+		}
+	}
+}
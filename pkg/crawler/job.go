@@ -0,0 +1,82 @@
+package crawler
+
+import (
+	"net/http"
+
+	"Fyne-on/pkg/httpcache"
+	"Fyne-on/pkg/proxy"
+)
+
+// JobOptions are the settings of one crawl job. A job starts from the
+// crawler's settings, as returned by JobDefaults, so nothing a job sets
+// carries over to the next one.
+type JobOptions struct {
+	// FrontierStrategy names a built-in frontier strategy; "" keeps the
+	// crawler's scorer
+	FrontierStrategy string
+	// FrontierCap and MaxDepth bound the frontier; 0 means unlimited
+	FrontierCap int
+	MaxDepth    int
+	// FetchStarred records the repos each user starred
+	FetchStarred bool
+	// FetchDependencies parses each crawled repo's manifests
+	FetchDependencies bool
+	// HTMLMaxPages limits the pages of a paginated list the HTML scraper
+	// reads; 0 keeps the crawler's limit
+	HTMLMaxPages int
+	// UserAgent is sent by HTML requests and matched against robots.txt;
+	// "" keeps the crawler's
+	UserAgent string
+	// Proxy routes the job's API, HTML and robots.txt requests; nil keeps
+	// the crawler's
+	Proxy *proxy.Config
+}
+
+// JobDefaults returns the crawler's settings as job options
+func (gc *GithubCrawler) JobDefaults() JobOptions {
+	return JobOptions{
+		FrontierCap:       gc.frontierCap,
+		MaxDepth:          gc.maxDepth,
+		FetchStarred:      gc.fetchStarred,
+		FetchDependencies: gc.fetchDeps,
+	}
+}
+
+// NewJob returns a crawler for one job with opts applied. It shares the
+// storage, Markov chain, API quota, HTTP cache and per-host limits of gc,
+// but runs with its own settings, and its own clients when it changes how
+// HTML or API requests are made, so jobs never change each other's.
+func (gc *GithubCrawler) NewJob(opts JobOptions) (*GithubCrawler, error) {
+	job := *gc
+	if opts.FrontierStrategy != "" {
+		score, err := lookupFrontierStrategy(opts.FrontierStrategy)
+		if err != nil {
+			return nil, err
+		}
+		job.frontierScore = score
+	}
+	job.frontierCap = max(opts.FrontierCap, 0)
+	job.maxDepth = max(opts.MaxDepth, 0)
+	job.fetchStarred = opts.FetchStarred
+	job.fetchDeps = opts.FetchDependencies
+
+	if opts.HTMLMaxPages > 0 || opts.UserAgent != "" || opts.Proxy != nil {
+		job.htmlScraper = gc.htmlScraper.Clone()
+		job.htmlScraper.SetMaxPages(opts.HTMLMaxPages)
+		if opts.UserAgent != "" {
+			job.htmlScraper.SetUserAgent(opts.UserAgent)
+		}
+	}
+	if opts.Proxy != nil {
+		tr, err := opts.Proxy.Transport()
+		if err != nil {
+			return nil, err
+		}
+		job.client = &http.Client{
+			Timeout:   gc.client.Timeout,
+			Transport: httpcache.Rebase(gc.client.Transport, tr),
+		}
+		job.htmlScraper.SetTransport(tr)
+	}
+	return &job, nil
+}
@@ -0,0 +1,136 @@
+package crawler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"Fyne-on/pkg/database"
+	"Fyne-on/pkg/httpcache"
+	"Fyne-on/pkg/proxy"
+	"Fyne-on/pkg/storage"
+)
+
+func TestSetProxyRoutesEveryClient(t *testing.T) {
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	proxySrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen[r.URL.String()] = true
+		mu.Unlock()
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, "{}")
+	}))
+	defer proxySrv.Close()
+
+	db, err := database.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	cache, err := httpcache.Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gc := NewGithubCrawler(storage.NewStorageService(db))
+	gc.HTMLScraper().SetHostLimits(4, 0, 1)
+	gc.SetHTTPCache(cache, false)
+	if err := gc.SetProxy(proxy.Config{URL: proxySrv.URL}); err != nil {
+		t.Fatalf("SetProxy failed: %v", err)
+	}
+
+	if _, err := gc.makeRequest("http://api.github.test/users/octocat"); err != nil {
+		t.Fatalf("API request through proxy failed: %v", err)
+	}
+	if _, err := gc.HTMLScraper().FetchRaw("http://github.test/octocat"); err != nil {
+		t.Fatalf("HTML request through proxy failed: %v", err)
+	}
+
+	for _, u := range []string{"http://api.github.test/users/octocat", "http://github.test/robots.txt", "http://github.test/octocat"} {
+		if !seen[u] {
+			t.Errorf("Expected %s to go through the proxy, saw %v", u, seen)
+		}
+	}
+
+	// The cache stays in front of the proxy
+	proxySrv.Close()
+	if _, err := gc.HTMLScraper().FetchRaw("http://github.test/octocat"); err != nil {
+		t.Errorf("Expected cached page after the proxy went away: %v", err)
+	}
+}
+
+func TestJobProxyIsPerJob(t *testing.T) {
+	var mu sync.Mutex
+	seen := map[string][]string{}
+	record := func(via string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			seen[via] = append(seen[via], r.URL.Path)
+			mu.Unlock()
+			if r.URL.Path == "/robots.txt" {
+				http.NotFound(w, r)
+				return
+			}
+			io.WriteString(w, "{}")
+		}
+	}
+	proxySrv := httptest.NewServer(record("proxy"))
+	defer proxySrv.Close()
+	origin := httptest.NewServer(record("direct"))
+	defer origin.Close()
+
+	db, err := database.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	gc := NewGithubCrawler(storage.NewStorageService(db))
+	gc.HTMLScraper().SetHostLimits(4, 0, 1)
+
+	proxied, err := gc.NewJob(JobOptions{Proxy: &proxy.Config{URL: proxySrv.URL}})
+	if err != nil {
+		t.Fatalf("NewJob failed: %v", err)
+	}
+	direct, err := gc.NewJob(gc.JobDefaults())
+	if err != nil {
+		t.Fatalf("NewJob failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			proxied.makeRequest(origin.URL + "/proxy/api")
+			proxied.HTMLScraper().FetchRaw(origin.URL + "/proxy/page")
+		}()
+		go func() {
+			defer wg.Done()
+			direct.makeRequest(origin.URL + "/direct/api")
+			direct.HTMLScraper().FetchRaw(origin.URL + "/direct/page")
+		}()
+	}
+	wg.Wait()
+
+	for via, paths := range seen {
+		for _, p := range paths {
+			if p != "/robots.txt" && !strings.HasPrefix(p, "/"+via+"/") {
+				t.Errorf("Unexpected %s request %s", via, p)
+			}
+		}
+	}
+	if len(seen["proxy"]) == 0 || len(seen["direct"]) == 0 {
+		t.Errorf("Expected requests both through the proxy and direct, got %v", seen)
+	}
+	if gc.HTTPClient() == proxied.HTTPClient() || gc.HTMLScraper() == proxied.HTMLScraper() {
+		t.Errorf("Expected the proxied job to have its own clients")
+	}
+}
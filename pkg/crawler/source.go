@@ -0,0 +1,229 @@
+package crawler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"Fyne-on/pkg/models"
+)
+
+// Source is a code host the crawler reads users, repositories and their
+// activity from. Records carry the source's Name in their Source field.
+//
+// Sources other than GitHub namespace what they return by host, so equal
+// names on two hosts never collide: repo owners become "{host}/{owner}"
+// and logins "{login}@{host}". Methods accept both namespaced and plain
+// logins and owners.
+type Source interface {
+	Name() string
+	FetchUserProfile(login string) (*models.Contact, error)
+	FetchUserRepos(login string) ([]models.Repo, error)
+	FetchRepositoryIssues(owner, repo string, saveFunc func(models.Issue) error) error
+	FetchRepositoryPRs(owner, repo string) ([]models.PullRequest, error)
+	FetchRepositoryContributors(owner, repo string) ([]models.Contact, error)
+}
+
+var _ Source = (*GithubCrawler)(nil)
+
+// Name implements Source
+func (gc *GithubCrawler) Name() string {
+	return models.SourceGitHub
+}
+
+// HTTPClient returns the client API requests go through. Sources sharing
+// it also share the crawler's proxy and HTTP cache.
+func (gc *GithubCrawler) HTTPClient() *http.Client {
+	return gc.client
+}
+
+// namespacedOwner and namespacedLogin qualify names from a non-GitHub host
+func namespacedOwner(host, owner string) string {
+	return host + "/" + owner
+}
+
+func namespacedLogin(host, login string) string {
+	return login + "@" + host
+}
+
+// localOwner and localLogin undo namespacedOwner and namespacedLogin
+func localOwner(host, owner string) string {
+	return strings.TrimPrefix(owner, host+"/")
+}
+
+func localLogin(host, login string) string {
+	return strings.TrimSuffix(login, "@"+host)
+}
+
+// ErrNotFound is returned for API requests answered with 404
+var ErrNotFound = errors.New("not found")
+
+// FileSource is implemented by sources that can read files from a repo's
+// default branch, which dependency extraction needs
+type FileSource interface {
+	FetchFile(owner, repo, path string) ([]byte, error)
+}
+
+var (
+	_ FileSource = (*GithubCrawler)(nil)
+	_ FileSource = (*GitLabSource)(nil)
+	_ FileSource = (*GiteaSource)(nil)
+)
+
+// fetch GETs rawURL with extra headers and returns the response body.
+// 429 responses are retried after their Retry-After delay.
+func fetch(client *http.Client, rawURL string, header http.Header) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest("GET", rawURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", "Fyne-on-Crawler/1.0")
+		for k, vals := range header {
+			req.Header[k] = vals
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < 3 {
+			resp.Body.Close()
+			wait := 5 * time.Second
+			if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				wait = time.Duration(s) * time.Second
+			}
+			log.Printf("Rate limit hit on %s. Sleeping for %v...", req.URL.Host, wait)
+			time.Sleep(wait)
+			continue
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		switch resp.StatusCode {
+		case http.StatusOK:
+			return body, nil
+		case http.StatusNotFound:
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, rawURL)
+		}
+		return nil, fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+}
+
+// fetchJSON is fetch decoding the JSON response into v
+func fetchJSON(client *http.Client, rawURL string, header http.Header, v interface{}) error {
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Accept", "application/json")
+	body, err := fetch(client, rawURL, header)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// CrawlSource crawls src breadth first from startLogin: each user's
+// profile and repos, then the issues, pull requests and contributors of
+// every repo, queueing contributors by the frontier strategy
+func (gc *GithubCrawler) CrawlSource(src Source, startLogin string) error {
+	visited := make(map[string]bool)
+	// branches holds the path to each visited user no other visited user
+	// was reached from; only these are stored, since the paths of inner
+	// users are prefixes of them and would train their hops again
+	branches := make(map[string][]string)
+	frontier := NewFrontier(gc.frontierScore, gc.frontierCap, gc.maxDepth)
+	frontier.Push(FrontierItem{Login: startLogin})
+	iteration := 0
+
+	for frontier.Len() > 0 && iteration < gc.maxIterations {
+		item, _ := frontier.Pop()
+		username := item.Login
+
+		if visited[username] {
+			continue
+		}
+		visited[username] = true
+		iteration++
+
+		log.Printf("Crawling %s: %s (iteration %d, depth %d, score %.0f)\n", src.Name(), username, iteration, item.Depth, item.Score)
+
+		contact, err := src.FetchUserProfile(username)
+		if err == nil {
+			gc.storage.SaveContact(*contact)
+			// Sources may namespace the login ("alice" -> "alice@gitlab.com")
+			if contact.Login != "" && contact.Login != username {
+				username = contact.Login
+				visited[username] = true
+			}
+		}
+
+		// A user's path extends its parent's (the state before the repo
+		// that led here), which is then no longer the end of a branch
+		if len(item.Path) > 0 {
+			if len(item.Path) >= 2 {
+				delete(branches, item.Path[len(item.Path)-2])
+			}
+			branches[username] = extendPath(item.Path, username)
+		}
+
+		if gc.fetchStarred && src == Source(gc) {
+			gc.saveStarred(username)
+		}
+
+		repos, err := src.FetchUserRepos(username)
+		if err == nil {
+			for _, repo := range repos {
+				_, saveErr := gc.storage.SaveRepo(repo)
+				if saveErr != nil {
+					log.Printf("  SaveRepo failed for %s: %v\n", repo.ID, saveErr)
+					continue
+				}
+				gc.markovChain.AddTransition(username, repo.Owner+"/"+repo.Name)
+
+				for _, contrib := range gc.processRepo(src, repo) {
+					if !visited[contrib.Login] {
+						frontier.Push(FrontierItem{
+							Login:         contrib.Login,
+							Depth:         item.Depth + 1,
+							Followers:     contrib.Followers,
+							Contributions: contrib.Contributions,
+							SourceRepo:    repo.ID,
+							SourceStars:   repo.Stars,
+							SourcePushed:  repo.PushedAt,
+							Path:          extendPath(item.Path, username, repo.ID),
+						})
+					}
+				}
+			}
+		}
+
+		gc.checkpointMarkov(iteration)
+		time.Sleep(time.Duration(gc.delayMs) * time.Millisecond)
+	}
+
+	logins := make([]string, 0, len(branches))
+	for login := range branches {
+		logins = append(logins, login)
+	}
+	sort.Strings(logins)
+	for _, login := range logins {
+		if err := gc.storage.SaveCrawlPath(branches[login], "bfs"); err != nil {
+			log.Printf("  SaveCrawlPath failed for %s: %v\n", login, err)
+		}
+	}
+
+	gc.checkpointMarkov(-1)
+	log.Printf("Crawling %s completed. Processed %d users\n", src.Name(), iteration)
+	return nil
+}
@@ -0,0 +1,97 @@
+package crawler
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"Fyne-on/pkg/models"
+	"Fyne-on/pkg/scraper"
+)
+
+// FetchTrending scrapes a trending page, stores its repos and a dated
+// snapshot of the ranking, and returns the snapshot
+func (gc *GithubCrawler) FetchTrending(language, since string) (*models.TrendingSnapshot, error) {
+	if since == "" {
+		since = scraper.TrendingDaily
+	}
+	rows, err := gc.htmlScraper.FetchTrendingRepos(language, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch trending repos: %w", err)
+	}
+
+	now := time.Now()
+	snap := models.TrendingSnapshot{
+		Date:      now.UTC().Format("2006-01-02"),
+		Language:  language,
+		Since:     since,
+		Repos:     make([]models.TrendingRepo, 0, len(rows)),
+		FetchedAt: now,
+	}
+	for _, r := range rows {
+		repoID := r.Owner + "/" + r.Name
+		snap.Repos = append(snap.Repos, models.TrendingRepo{
+			Rank:        r.Rank,
+			RepoID:      repoID,
+			Description: r.Description,
+			Language:    r.Language,
+			Stars:       r.Stars,
+			Forks:       r.Forks,
+			StarsGained: r.StarsGained,
+			BuiltBy:     r.BuiltBy,
+		})
+
+		// A trending row does not say when the repo was created, so
+		// CreatedAt stays zero rather than claiming the fetch time
+		repo := models.Repo{
+			ID:          repoID,
+			Name:        r.Name,
+			Owner:       r.Owner,
+			URL:         r.URL,
+			Description: r.Description,
+			Language:    r.Language,
+			Stars:       r.Stars,
+			Forks:       r.Forks,
+		}
+		if _, err := gc.storage.SaveRepo(repo); err != nil {
+			log.Printf("  SaveRepo failed for %s: %v", repoID, err)
+			continue
+		}
+		for _, login := range r.BuiltBy {
+			gc.storage.SaveEdge(models.EdgeContributor, login, repoID)
+		}
+	}
+
+	if err := gc.storage.SaveTrendingSnapshot(snap); err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+// TrendingSeeds fetches a trending page and returns the users to start a
+// crawl from: the contributors shown on each row, then the repo owners
+func (gc *GithubCrawler) TrendingSeeds(language, since string) ([]string, error) {
+	snap, err := gc.FetchTrending(language, since)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	seeds := []string{}
+	add := func(login string) {
+		if login != "" && !seen[login] {
+			seen[login] = true
+			seeds = append(seeds, login)
+		}
+	}
+	for _, r := range snap.Repos {
+		for _, login := range r.BuiltBy {
+			add(login)
+		}
+	}
+	for _, r := range snap.Repos {
+		add(strings.SplitN(r.RepoID, "/", 2)[0])
+	}
+	return seeds, nil
+}
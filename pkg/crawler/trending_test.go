@@ -0,0 +1,61 @@
+package crawler
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"Fyne-on/pkg/database"
+	"Fyne-on/pkg/scraper/scrapertest"
+	"Fyne-on/pkg/storage"
+)
+
+func TestTrendingSeedsStoresSnapshot(t *testing.T) {
+	srv, err := scrapertest.NewServer(filepath.Join("..", "scraper", "testdata", "fixtures"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	db, err := database.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	st := storage.NewStorageService(db)
+	gc := NewGithubCrawler(st)
+	gc.HTMLScraper().SetBaseURL(srv.URL)
+	gc.HTMLScraper().SetHostLimits(4, 0, 1)
+
+	seeds, err := gc.TrendingSeeds("go", "weekly")
+	if err != nil {
+		t.Fatalf("TrendingSeeds failed: %v", err)
+	}
+	want := []string{"meowgorithm", "aymanbagabas", "rsc", "charm", "golang", "octo-org"}
+	if !reflect.DeepEqual(seeds, want) {
+		t.Errorf("Expected seeds %v, got %v", want, seeds)
+	}
+
+	snaps, err := st.GetTrendingSnapshots("weekly", "Go", "", "")
+	if err != nil {
+		t.Fatalf("GetTrendingSnapshots failed: %v", err)
+	}
+	if len(snaps) != 1 || len(snaps[0].Repos) != 3 {
+		t.Fatalf("Expected 1 snapshot with 3 repos, got %+v", snaps)
+	}
+	if r := snaps[0].Repos[0]; r.RepoID != "charm/bubbletea" || r.Rank != 1 || r.StarsGained != 1204 {
+		t.Errorf("Unexpected first row: %+v", r)
+	}
+
+	repo, err := st.GetRepo("golang", "go")
+	if err != nil {
+		t.Fatalf("Expected trending repo to be stored: %v", err)
+	}
+	if repo.Stars != 124567 {
+		t.Errorf("Expected 124567 stars, got %d", repo.Stars)
+	}
+	if !repo.CreatedAt.IsZero() {
+		t.Errorf("Expected no creation date from a trending row, got %v", repo.CreatedAt)
+	}
+}
@@ -0,0 +1,122 @@
+package crawler
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// WalkOptions configures a Markov-guided crawl
+type WalkOptions struct {
+	// Seed makes the walk reproducible; zero picks a time-based seed
+	Seed int64
+	// TeleportProbability is the chance of restarting from a start user
+	// instead of following a transition on each step
+	TeleportProbability float64
+}
+
+// DefaultTeleportProbability is used when WalkOptions leaves it unset
+const DefaultTeleportProbability = 0.15
+
+// CrawlMarkovWalk explores GitHub by weighted random walks over the Markov
+// chain. States are user logins and "owner/name" repo ids; users lead to
+// their repos and repos lead to their contributors. A state is fetched the
+// first time the walk reaches it, which is also when its transitions are
+// learned, so later steps are guided by what has been seen so far.
+func (gc *GithubCrawler) CrawlMarkovWalk(startUsernames []string, opts WalkOptions) error {
+	if len(startUsernames) == 0 {
+		return fmt.Errorf("markov walk needs at least one start user")
+	}
+
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	teleport := opts.TeleportProbability
+	if teleport <= 0 || teleport > 1 {
+		teleport = DefaultTeleportProbability
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	log.Printf("Markov walk started (seed %d, teleport %.2f)", seed, teleport)
+
+	expanded := make(map[string]bool)
+	current := startUsernames[rng.Intn(len(startUsernames))]
+	segment := []string{current}
+	steps := 0
+
+	// restart saves the walk segment that just ended and teleports
+	restart := func() {
+		if err := gc.storage.SaveCrawlPath(segment, "markov"); err != nil {
+			log.Printf("  SaveCrawlPath failed: %v", err)
+		}
+		current = startUsernames[rng.Intn(len(startUsernames))]
+		segment = []string{current}
+	}
+
+	for steps < gc.maxIterations {
+		steps++
+
+		if !expanded[current] {
+			expanded[current] = true
+			log.Printf("Walk step %d: expanding %s", steps, current)
+			gc.expandState(current)
+			time.Sleep(time.Duration(gc.delayMs) * time.Millisecond)
+		}
+		gc.checkpointMarkov(steps)
+
+		if rng.Float64() < teleport || !gc.markovChain.HasTransitions(current) {
+			restart()
+			continue
+		}
+
+		next, err := gc.markovChain.GetNextStateWithRand(current, rng)
+		if err != nil {
+			restart()
+			continue
+		}
+		current = next
+		segment = append(segment, current)
+	}
+
+	if err := gc.storage.SaveCrawlPath(segment, "markov"); err != nil {
+		log.Printf("  SaveCrawlPath failed: %v", err)
+	}
+	gc.checkpointMarkov(-1)
+	log.Printf("Markov walk completed. %d steps, %d states expanded", steps, len(expanded))
+	return nil
+}
+
+// expandState fetches a single walk state and records its outgoing transitions
+func (gc *GithubCrawler) expandState(state string) {
+	// Repo IDs split on their last "/": namespaced owners hold one too
+	if i := strings.LastIndex(state, "/"); i >= 0 {
+		repo, err := gc.storage.GetRepo(state[:i], state[i+1:])
+		if err != nil {
+			log.Printf("  Unknown repo state %s: %v", state, err)
+			return
+		}
+		gc.processRepo(gc, *repo)
+		return
+	}
+
+	contact, err := gc.FetchUserProfile(state)
+	if err == nil {
+		gc.storage.SaveContact(*contact)
+	}
+
+	repos, err := gc.FetchUserRepos(state)
+	if err != nil {
+		log.Printf("  Failed to fetch repos for %s: %v", state, err)
+		return
+	}
+	for _, repo := range repos {
+		if _, err := gc.storage.SaveRepo(repo); err != nil {
+			log.Printf("  SaveRepo failed for %s: %v", repo.ID, err)
+			continue
+		}
+		gc.markovChain.AddTransition(state, repo.Owner+"/"+repo.Name)
+	}
+}
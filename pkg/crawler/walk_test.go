@@ -0,0 +1,151 @@
+package crawler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"Fyne-on/pkg/database"
+	"Fyne-on/pkg/models"
+	"Fyne-on/pkg/storage"
+)
+
+// apiTransport sends every API request to a test server
+type apiTransport struct{ host string }
+
+func (t apiTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme = "http"
+	r.URL.Host = t.host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// fakeAPI serves users, their repos and repo contributors, and records the
+// walk states expanded against it in order
+type fakeAPI struct {
+	repos        map[string][]string
+	contributors map[string][]string
+
+	mu       sync.Mutex
+	expanded []string
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	firstPage := r.URL.Query().Get("page") == "1"
+	var body interface{} = []interface{}{}
+
+	switch {
+	case strings.HasPrefix(path, "/users/") && strings.HasSuffix(path, "/repos"):
+		login := strings.TrimSuffix(strings.TrimPrefix(path, "/users/"), "/repos")
+		if firstPage {
+			var repos []interface{}
+			for _, name := range f.repos[login] {
+				repos = append(repos, map[string]interface{}{
+					"name":  name,
+					"owner": map[string]string{"login": login},
+				})
+			}
+			body = repos
+		}
+	case strings.HasPrefix(path, "/users/"):
+		login := strings.TrimPrefix(path, "/users/")
+		f.record(login)
+		body = map[string]string{"login": login}
+	case strings.HasPrefix(path, "/repos/") && strings.HasSuffix(path, "/contributors"):
+		id := strings.TrimSuffix(strings.TrimPrefix(path, "/repos/"), "/contributors")
+		if firstPage {
+			f.record(id)
+			var contribs []interface{}
+			for _, login := range f.contributors[id] {
+				contribs = append(contribs, map[string]string{"login": login})
+			}
+			body = contribs
+		}
+	}
+	json.NewEncoder(w).Encode(body)
+}
+
+func (f *fakeAPI) record(state string) {
+	f.mu.Lock()
+	f.expanded = append(f.expanded, state)
+	f.mu.Unlock()
+}
+
+func newFakeAPI() *fakeAPI {
+	return &fakeAPI{
+		repos: map[string][]string{
+			"alice": {"app", "lib"},
+			"bob":   {"tool"},
+			"dave":  {"site"},
+		},
+		contributors: map[string][]string{
+			"alice/app":     {"bob", "carol"},
+			"alice/lib":     {"alice", "dave"},
+			"bob/tool":      {"alice", "carol"},
+			"dave/site":     {"bob"},
+			"group/sub/app": {"dave"},
+		},
+	}
+}
+
+// newWalkCrawler returns a crawler with a fresh store whose API requests go
+// to api
+func newWalkCrawler(t *testing.T, api http.Handler) (*GithubCrawler, *storage.StorageService) {
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	db, err := database.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	st := storage.NewStorageService(db)
+	gc := NewGithubCrawler(st)
+	gc.SetDelayMs(0)
+	gc.client.Transport = apiTransport{host: srv.Listener.Addr().String()}
+	return gc, st
+}
+
+func TestCrawlMarkovWalkIsReproducible(t *testing.T) {
+	walk := func() []string {
+		api := newFakeAPI()
+		gc, _ := newWalkCrawler(t, api)
+		gc.SetMaxIterations(40)
+		opts := WalkOptions{Seed: 42, TeleportProbability: 0.3}
+		if err := gc.CrawlMarkovWalk([]string{"alice", "bob"}, opts); err != nil {
+			t.Fatalf("CrawlMarkovWalk failed: %v", err)
+		}
+		return api.expanded
+	}
+
+	first := walk()
+	if len(first) < 3 {
+		t.Fatalf("Expected the walk to expand several states, got %v", first)
+	}
+	if second := walk(); !reflect.DeepEqual(first, second) {
+		t.Errorf("Expected the same seed to expand %v, got %v", first, second)
+	}
+}
+
+func TestExpandStateNamespacedOwner(t *testing.T) {
+	api := newFakeAPI()
+	gc, st := newWalkCrawler(t, api)
+	if _, err := st.SaveRepo(models.Repo{ID: "group/sub/app", Owner: "group/sub", Name: "app"}); err != nil {
+		t.Fatal(err)
+	}
+
+	gc.expandState("group/sub/app")
+
+	if !reflect.DeepEqual(api.expanded, []string{"group/sub/app"}) {
+		t.Errorf("Expected the contributors of group/sub/app to be fetched, got %v", api.expanded)
+	}
+	if n := gc.markovChain.Count("group/sub/app", "dave"); n != 1 {
+		t.Errorf("Expected a group/sub/app -> dave transition, got count %d", n)
+	}
+}
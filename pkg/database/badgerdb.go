@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -12,13 +13,28 @@ import (
 	"github.com/dgraph-io/badger/v3"
 )
 
+// ErrStop ends a ScanKeys early without an error
+var ErrStop = errors.New("stop iteration")
+
+// IndexFunc returns the index keys of a stored JSON value
+type IndexFunc func(value []byte) []string
+
+// Entry is a key and a value to be stored as JSON
+type Entry struct {
+	Key   string
+	Value interface{}
+}
+
 type BadgerDB struct {
 	db *badger.DB
 }
 
 func InitDB() (*BadgerDB, error) {
-	dbPath := "./badger_data"
+	return OpenDB("./badger_data")
+}
 
+// OpenDB opens (creating if needed) a database in dbPath
+func OpenDB(dbPath string) (*BadgerDB, error) {
 	if err := os.MkdirAll(dbPath, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create db directory: %w", err)
 	}
@@ -62,6 +78,73 @@ func (b *BadgerDB) Set(key string, value interface{}) error {
 	})
 }
 
+// SetBatch writes many values at once using a Badger write batch
+func (b *BadgerDB) SetBatch(items map[string]interface{}) error {
+	wb := b.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	for key, value := range items {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to marshal data for %s: %w", key, err)
+		}
+		if err := wb.Set([]byte(key), data); err != nil {
+			return err
+		}
+	}
+	return wb.Flush()
+}
+
+// WriteBatch deletes keys and then writes raw values in one write batch, so
+// a key both deleted and set ends up set
+func (b *BadgerDB) WriteBatch(sets map[string][]byte, deletes []string) error {
+	wb := b.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	for _, key := range deletes {
+		if err := wb.Delete([]byte(key)); err != nil {
+			return err
+		}
+	}
+	for key, value := range sets {
+		if err := wb.Set([]byte(key), value); err != nil {
+			return err
+		}
+	}
+	return wb.Flush()
+}
+
+// DeleteBatch removes many keys at once using a Badger write batch
+func (b *BadgerDB) DeleteBatch(keys []string) error {
+	wb := b.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	for _, key := range keys {
+		if err := wb.Delete([]byte(key)); err != nil {
+			return err
+		}
+	}
+	return wb.Flush()
+}
+
+// KeysWithPrefix returns all keys with the given prefix without reading values
+func (b *BadgerDB) KeysWithPrefix(prefix string) ([]string, error) {
+	keys := []string{}
+	err := b.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = []byte(prefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			keys = append(keys, string(it.Item().Key()))
+		}
+		return nil
+	})
+	return keys, err
+}
+
 func (b *BadgerDB) Get(key string) ([]byte, error) {
 	var result []byte
 	err := b.db.View(func(txn *badger.Txn) error {
@@ -229,3 +312,242 @@ func (b *BadgerDB) IteratePrefix(prefix string, fn func(k []byte, v []byte) erro
 		return nil
 	})
 }
+
+// SetWithIndex writes value under key together with its index keys in one
+// transaction. Index keys of the value being replaced that the new value
+// does not have are deleted, so indexes never point at stale values.
+// Index keys carry no value; the record key is their suffix. Extra
+// entries, such as change log entries, are written in the same transaction.
+func (b *BadgerDB) SetWithIndex(key string, value interface{}, indexes IndexFunc, extra ...Entry) error {
+	data, extraData, err := marshalEntries(value, extra)
+	if err != nil {
+		return err
+	}
+	return b.db.Update(func(txn *badger.Txn) error {
+		old, err := txnGet(txn, key)
+		if err != nil {
+			return err
+		}
+		return setWithIndex(txn, key, data, old, indexes, extra, extraData)
+	})
+}
+
+// UpdateFunc builds the value to store from the current one (nil if the
+// key does not exist). A nil value leaves the key as it is.
+type UpdateFunc func(old []byte) (value interface{}, extra []Entry, err error)
+
+// UpdateWithIndex is SetWithIndex for a value derived from the stored one:
+// fn reads and the write happens in the same transaction, so concurrent
+// updates of a key never act on a version another one has replaced. fn is
+// called again if the transaction conflicts and must not have side effects.
+func (b *BadgerDB) UpdateWithIndex(key string, fn UpdateFunc, indexes IndexFunc) (bool, error) {
+	for {
+		written := false
+		err := b.db.Update(func(txn *badger.Txn) error {
+			old, err := txnGet(txn, key)
+			if err != nil {
+				return err
+			}
+			value, extra, err := fn(old)
+			if err != nil || value == nil {
+				return err
+			}
+			data, extraData, err := marshalEntries(value, extra)
+			if err != nil {
+				return err
+			}
+			written = true
+			return setWithIndex(txn, key, data, old, indexes, extra, extraData)
+		})
+		if err != badger.ErrConflict {
+			return written && err == nil, err
+		}
+	}
+}
+
+// marshalEntries encodes a record and the extra entries written with it
+func marshalEntries(value interface{}, extra []Entry) ([]byte, [][]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal data: %w", err)
+	}
+	extraData := make([][]byte, len(extra))
+	for i, e := range extra {
+		if extraData[i], err = json.Marshal(e.Value); err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal %s: %w", e.Key, err)
+		}
+	}
+	return data, extraData, nil
+}
+
+// setWithIndex writes data over old inside txn, see SetWithIndex
+func setWithIndex(txn *badger.Txn, key string, data, old []byte, indexes IndexFunc, extra []Entry, extraData [][]byte) error {
+	for i, e := range extra {
+		if err := txn.Set([]byte(e.Key), extraData[i]); err != nil {
+			return err
+		}
+	}
+
+	newKeys := map[string]bool{}
+	for _, k := range indexes(data) {
+		newKeys[k] = true
+	}
+	if old != nil {
+		for _, k := range indexes(old) {
+			if !newKeys[k] {
+				if err := txn.Delete([]byte(k)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for k := range newKeys {
+		if err := txn.Set([]byte(k), nil); err != nil {
+			return err
+		}
+	}
+	return txn.Set([]byte(key), data)
+}
+
+// DeleteWithIndex deletes key and its index keys in one transaction
+func (b *BadgerDB) DeleteWithIndex(key string, indexes IndexFunc) error {
+	return b.db.Update(func(txn *badger.Txn) error {
+		old, err := txnGet(txn, key)
+		if err != nil || old == nil {
+			return err
+		}
+		for _, k := range indexes(old) {
+			if err := txn.Delete([]byte(k)); err != nil {
+				return err
+			}
+		}
+		return txn.Delete([]byte(key))
+	})
+}
+
+// txnGet returns a copy of the value of key, nil if it does not exist
+func txnGet(txn *badger.Txn, key string) ([]byte, error) {
+	item, err := txn.Get([]byte(key))
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item.ValueCopy(nil)
+}
+
+// PutKeys writes keys without values, as used by indexes, in a write batch
+func (b *BadgerDB) PutKeys(keys []string) error {
+	wb := b.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	for _, key := range keys {
+		if err := wb.Set([]byte(key), nil); err != nil {
+			return err
+		}
+	}
+	return wb.Flush()
+}
+
+// ScanOptions selects a range of keys sharing a prefix
+type ScanOptions struct {
+	Prefix string
+	// Start is the first key visited, "" for the start of the range. In
+	// reverse order it is the highest, and keys it is a prefix of are
+	// included too.
+	Start string
+	// After skips Start itself, as when resuming after a cursor key
+	After   bool
+	Reverse bool
+	// Values reads values as well; otherwise fn gets nil values
+	Values bool
+	// Limit stops the scan after this many keys, 0 for no limit
+	Limit int
+}
+
+// Scan calls fn for the keys selected by opts in one read transaction. It
+// reports whether keys remain after Limit was reached. fn may return
+// ErrStop to end the scan early.
+func (b *BadgerDB) Scan(opts ScanOptions, fn func(key string, value []byte) error) (bool, error) {
+	more := false
+	err := b.db.View(func(txn *badger.Txn) error {
+		itOpts := badger.DefaultIteratorOptions
+		itOpts.PrefetchValues = opts.Values
+		itOpts.Prefix = []byte(opts.Prefix)
+		itOpts.Reverse = opts.Reverse
+		it := txn.NewIterator(itOpts)
+		defer it.Close()
+
+		seek := opts.Start
+		if seek == "" {
+			seek = opts.Prefix
+		}
+		if opts.Reverse && (!opts.After || opts.Start == "") {
+			seek += "\xff"
+		}
+
+		n := 0
+		for it.Seek([]byte(seek)); it.ValidForPrefix(itOpts.Prefix); it.Next() {
+			item := it.Item()
+			key := string(item.Key())
+			if opts.After && key == opts.Start {
+				continue
+			}
+			if opts.Limit > 0 && n == opts.Limit {
+				more = true
+				return nil
+			}
+			n++
+
+			var val []byte
+			if opts.Values {
+				v, err := item.ValueCopy(nil)
+				if err != nil {
+					return err
+				}
+				val = v
+			}
+			if err := fn(key, val); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, ErrStop) {
+		return false, nil
+	}
+	return more, err
+}
+
+// ScanKeys calls fn for the keys with prefix, without reading values, in
+// ascending order starting at from, or in descending order starting at the
+// last key not after from ("" starts at the first or last key). fn may
+// return ErrStop to end the scan.
+func (b *BadgerDB) ScanKeys(prefix, from string, reverse bool, fn func(key string) error) error {
+	_, err := b.Scan(ScanOptions{Prefix: prefix, Start: from, Reverse: reverse}, func(key string, _ []byte) error {
+		return fn(key)
+	})
+	return err
+}
+
+// GetEach reads the values of keys in one transaction and calls fn for each
+// one that exists, in the order given
+func (b *BadgerDB) GetEach(keys []string, fn func(key string, value []byte) error) error {
+	return b.db.View(func(txn *badger.Txn) error {
+		for _, key := range keys {
+			val, err := txnGet(txn, key)
+			if err != nil {
+				return err
+			}
+			if val == nil {
+				continue
+			}
+			if err := fn(key, val); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
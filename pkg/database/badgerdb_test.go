@@ -1,7 +1,9 @@
 package database
 
 import (
+	"encoding/json"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -118,3 +120,133 @@ func TestDelete(t *testing.T) {
 		t.Error("Key should be deleted")
 	}
 }
+
+func TestSetBatchAndKeys(t *testing.T) {
+	db, _ := InitDB()
+	defer db.Close()
+
+	err := db.SetBatch(map[string]interface{}{
+		"batch:a": 1,
+		"batch:b": 2,
+	})
+	if err != nil {
+		t.Fatalf("SetBatch failed: %v", err)
+	}
+
+	keys, err := db.KeysWithPrefix("batch:")
+	if err != nil {
+		t.Fatalf("KeysWithPrefix failed: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Errorf("Expected 2 keys, got %d", len(keys))
+	}
+
+	if err := db.DeleteBatch(keys); err != nil {
+		t.Fatalf("DeleteBatch failed: %v", err)
+	}
+	count, _ := db.CountByPrefix("batch:")
+	if count != 0 {
+		t.Errorf("Expected 0 keys after delete, got %d", count)
+	}
+}
+
+func TestSetWithIndex(t *testing.T) {
+	db, err := OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	type item struct{ Color string }
+	indexes := func(v []byte) []string {
+		var it item
+		if err := json.Unmarshal(v, &it); err != nil {
+			return nil
+		}
+		return []string{"idx:color:" + it.Color + ":item:1"}
+	}
+
+	db.SetWithIndex("item:1", item{"red"}, indexes)
+	db.SetWithIndex("item:1", item{"blue"}, indexes)
+	if keys, _ := db.KeysWithPrefix("idx:"); len(keys) != 1 || keys[0] != "idx:color:blue:item:1" {
+		t.Errorf("Expected only the blue index key, got %v", keys)
+	}
+
+	if err := db.DeleteWithIndex("item:1", indexes); err != nil {
+		t.Fatalf("DeleteWithIndex failed: %v", err)
+	}
+	if count, _ := db.CountByPrefix("idx:"); count != 0 {
+		t.Errorf("Expected index keys to be deleted, got %d", count)
+	}
+}
+
+func TestScanKeys(t *testing.T) {
+	db, err := OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.PutKeys([]string{"n:1", "n:2", "n:3", "n:4", "o:1"})
+
+	scan := func(from string, reverse bool) []string {
+		var keys []string
+		db.ScanKeys("n:", from, reverse, func(k string) error {
+			if len(keys) == 2 {
+				return ErrStop
+			}
+			keys = append(keys, k)
+			return nil
+		})
+		return keys
+	}
+	if got := scan("n:2", false); !reflect.DeepEqual(got, []string{"n:2", "n:3"}) {
+		t.Errorf("Expected [n:2 n:3], got %v", got)
+	}
+	if got := scan("n:3", true); !reflect.DeepEqual(got, []string{"n:3", "n:2"}) {
+		t.Errorf("Expected [n:3 n:2], got %v", got)
+	}
+	if got := scan("", true); !reflect.DeepEqual(got, []string{"n:4", "n:3"}) {
+		t.Errorf("Expected [n:4 n:3], got %v", got)
+	}
+
+	var values []string
+	db.Set("v:a", "A")
+	db.GetEach([]string{"v:missing", "v:a"}, func(k string, v []byte) error {
+		values = append(values, k+"="+string(v))
+		return nil
+	})
+	if !reflect.DeepEqual(values, []string{`v:a="A"`}) {
+		t.Errorf("Expected only v:a, got %v", values)
+	}
+}
+
+func TestScanPages(t *testing.T) {
+	db, err := OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetBatch(map[string]interface{}{"p:a": 1, "p:b": 2, "p:c": 3, "q:a": 4})
+
+	page := func(start string, reverse bool) ([]string, bool) {
+		var keys []string
+		more, err := db.Scan(ScanOptions{Prefix: "p:", Start: start, After: start != "", Reverse: reverse, Values: true, Limit: 2}, func(k string, v []byte) error {
+			keys = append(keys, k+"="+string(v))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		return keys, more
+	}
+
+	if keys, more := page("", false); !reflect.DeepEqual(keys, []string{"p:a=1", "p:b=2"}) || !more {
+		t.Errorf("Expected first page [p:a p:b] with more, got %v %v", keys, more)
+	}
+	if keys, more := page("p:b", false); !reflect.DeepEqual(keys, []string{"p:c=3"}) || more {
+		t.Errorf("Expected last page [p:c] without more, got %v %v", keys, more)
+	}
+	if keys, more := page("p:c", true); !reflect.DeepEqual(keys, []string{"p:b=2", "p:a=1"}) || more {
+		t.Errorf("Expected reverse page [p:b p:a] without more, got %v %v", keys, more)
+	}
+}
@@ -0,0 +1,392 @@
+// Package deps parses package manifests (go.mod, package.json,
+// requirements.txt, Cargo.toml) into the packages a repository declares
+// and the ones it depends on.
+package deps
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Ecosystems dependencies are named in
+const (
+	Go    = "go"
+	NPM   = "npm"
+	PyPI  = "pypi"
+	Cargo = "cargo"
+)
+
+// Dependency scopes; "" is a regular runtime dependency
+const (
+	ScopeDev      = "dev"
+	ScopeBuild    = "build"
+	ScopePeer     = "peer"
+	ScopeOptional = "optional"
+	ScopeIndirect = "indirect"
+)
+
+// Manifests maps the manifest file names looked for at a repository's root
+// to their ecosystem
+var Manifests = map[string]string{
+	"go.mod":           Go,
+	"package.json":     NPM,
+	"requirements.txt": PyPI,
+	"Cargo.toml":       Cargo,
+}
+
+// ManifestNames returns the keys of Manifests in a stable order
+func ManifestNames() []string {
+	names := make([]string, 0, len(Manifests))
+	for name := range Manifests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Requirement is one dependency declared in a manifest
+type Requirement struct {
+	Name    string
+	Version string
+	Scope   string
+}
+
+// Manifest is a parsed manifest file. Package is the name the repository
+// itself is published under, empty if the manifest does not say.
+type Manifest struct {
+	File         string
+	Ecosystem    string
+	Package      string
+	Requirements []Requirement
+}
+
+// Parse parses the manifest named file (a key of Manifests)
+func Parse(file string, data []byte) (*Manifest, error) {
+	var (
+		m   *Manifest
+		err error
+	)
+	switch file {
+	case "go.mod":
+		m, err = parseGoMod(data)
+	case "package.json":
+		m, err = parsePackageJSON(data)
+	case "requirements.txt":
+		m, err = parseRequirements(data)
+	case "Cargo.toml":
+		m, err = parseCargoToml(data)
+	default:
+		return nil, fmt.Errorf("unsupported manifest %q", file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	m.File = file
+	m.Ecosystem = Manifests[file]
+	return m, nil
+}
+
+// stripComment cuts a trailing comment starting with marker outside quotes
+func stripComment(line, marker string) string {
+	inQuote := byte(0)
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case inQuote != 0:
+			if c == inQuote {
+				inQuote = 0
+			}
+		case c == '"' || c == '\'':
+			inQuote = c
+		case strings.HasPrefix(line[i:], marker):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func parseGoMod(data []byte) (*Manifest, error) {
+	m := &Manifest{}
+	inRequire := false
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		raw := sc.Text()
+		indirect := strings.Contains(raw, "// indirect")
+		line := strings.TrimSpace(stripComment(raw, "//"))
+		if line == "" {
+			continue
+		}
+
+		if inRequire {
+			if line == ")" {
+				inRequire = false
+				continue
+			}
+			m.addGoRequire(line, indirect)
+			continue
+		}
+
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "module":
+			if len(fields) > 1 {
+				m.Package = strings.Trim(fields[1], `"`)
+			}
+		case "require":
+			rest := strings.TrimSpace(strings.TrimPrefix(line, "require"))
+			if rest == "(" {
+				inRequire = true
+			} else {
+				m.addGoRequire(rest, indirect)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if m.Package == "" {
+		return nil, fmt.Errorf("missing module directive")
+	}
+	return m, nil
+}
+
+func (m *Manifest) addGoRequire(line string, indirect bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return
+	}
+	r := Requirement{Name: strings.Trim(fields[0], `"`), Version: fields[1]}
+	if indirect {
+		r.Scope = ScopeIndirect
+	}
+	m.Requirements = append(m.Requirements, r)
+}
+
+func parsePackageJSON(data []byte) (*Manifest, error) {
+	var pkg struct {
+		Name                 string            `json:"name"`
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		PeerDependencies     map[string]string `json:"peerDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{Package: pkg.Name}
+	for _, group := range []struct {
+		deps  map[string]string
+		scope string
+	}{
+		{pkg.Dependencies, ""},
+		{pkg.DevDependencies, ScopeDev},
+		{pkg.PeerDependencies, ScopePeer},
+		{pkg.OptionalDependencies, ScopeOptional},
+	} {
+		names := make([]string, 0, len(group.deps))
+		for name := range group.deps {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			m.Requirements = append(m.Requirements, Requirement{Name: name, Version: group.deps[name], Scope: group.scope})
+		}
+	}
+	return m, nil
+}
+
+var (
+	pypiName      = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(\[[^\]]*\])?\s*(.*)$`)
+	pypiSeparator = regexp.MustCompile(`[-_.]+`)
+)
+
+// NormalizePyPI normalizes a Python package name as PEP 503 does
+func NormalizePyPI(name string) string {
+	return strings.ToLower(pypiSeparator.ReplaceAllString(name, "-"))
+}
+
+func parseRequirements(data []byte) (*Manifest, error) {
+	m := &Manifest{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		// Options (-r other.txt, -e ., --index-url) and direct URLs are skipped
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") || strings.Contains(line, "://") {
+			continue
+		}
+		if i := strings.Index(line, ";"); i >= 0 {
+			line = strings.TrimSpace(line[:i]) // environment marker
+		}
+
+		match := pypiName.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		m.Requirements = append(m.Requirements, Requirement{
+			Name:    NormalizePyPI(match[1]),
+			Version: strings.ReplaceAll(match[3], " ", ""),
+		})
+	}
+	return m, sc.Err()
+}
+
+// parseCargoToml reads the parts of Cargo.toml that matter here: the
+// package name and the [dependencies], [dev-dependencies] and
+// [build-dependencies] tables, including their [target.*] variants and
+// [dependencies.name] subtables. It is not a general TOML parser.
+func parseCargoToml(data []byte) (*Manifest, error) {
+	m := &Manifest{}
+	section, scope := "", ""
+	// For [dependencies.name] tables, the requirement being filled in
+	var current *Requirement
+
+	flush := func() {
+		if current != nil {
+			m.Requirements = append(m.Requirements, *current)
+			current = nil
+		}
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(stripComment(sc.Text(), "#"))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			flush()
+			section = strings.Trim(line, "[] ")
+			table, name, isDep := cargoDependencyTable(section)
+			scope = ""
+			if !isDep {
+				section = table
+				continue
+			}
+			switch table {
+			case "dev-dependencies":
+				scope = ScopeDev
+			case "build-dependencies":
+				scope = ScopeBuild
+			}
+			section = "dependencies"
+			if name != "" {
+				current = &Requirement{Name: name, Scope: scope}
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.Trim(strings.TrimSpace(key), `"`)
+		value = strings.TrimSpace(value)
+
+		switch {
+		case section == "package" && key == "name":
+			m.Package = unquoteTOML(value)
+		case section == "dependencies" && current != nil:
+			switch key {
+			case "version":
+				current.Version = unquoteTOML(value)
+			case "package":
+				current.Name = unquoteTOML(value)
+			}
+		case section == "dependencies":
+			// name.workspace = true inherits from the workspace
+			key = strings.TrimSuffix(key, ".workspace")
+			r := Requirement{Name: key, Scope: scope}
+			if strings.HasPrefix(value, "{") {
+				fields := inlineTableTOML(value)
+				r.Version = fields["version"]
+				if pkg := fields["package"]; pkg != "" {
+					r.Name = pkg
+				}
+			} else if strings.HasPrefix(value, `"`) || strings.HasPrefix(value, "'") {
+				r.Version = unquoteTOML(value)
+			}
+			m.Requirements = append(m.Requirements, r)
+		}
+	}
+	flush()
+	return m, sc.Err()
+}
+
+// cargoDependencyTable reports whether a table header names a dependency
+// table, which one, and the dependency of a [dependencies.name] table
+func cargoDependencyTable(header string) (table, name string, ok bool) {
+	parts := strings.Split(header, ".")
+	// [target.'cfg(...)'.dependencies] may contain dots inside quotes
+	if parts[0] == "target" {
+		for i := len(parts) - 1; i > 0; i-- {
+			switch parts[i] {
+			case "dependencies", "dev-dependencies", "build-dependencies":
+				return parts[i], strings.Join(parts[i+1:], "."), true
+			}
+		}
+		return header, "", false
+	}
+	switch parts[0] {
+	case "dependencies", "dev-dependencies", "build-dependencies":
+		return parts[0], strings.Trim(strings.Join(parts[1:], "."), `"`), true
+	}
+	return header, "", false
+}
+
+func unquoteTOML(value string) string {
+	return strings.Trim(strings.TrimSpace(value), `"'`)
+}
+
+// inlineTableTOML reads the string values of a flat inline table
+func inlineTableTOML(value string) map[string]string {
+	fields := map[string]string{}
+	value = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(value, "{"), "}"))
+	depth := 0
+	start := 0
+	var parts []string
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, value[start:i])
+				start = i + 1
+			}
+		}
+	}
+	parts = append(parts, value[start:])
+	for _, part := range parts {
+		if k, v, ok := strings.Cut(part, "="); ok {
+			fields[strings.TrimSpace(k)] = unquoteTOML(v)
+		}
+	}
+	return fields
+}
+
+// GoModuleRepo returns the repo ID a Go module path would have if it was
+// crawled: "owner/name" for github.com modules and "{host}/owner/name"
+// (namespaced like other sources' records) for other hosts. Paths too
+// short to name a repository return "".
+func GoModuleRepo(module string) string {
+	parts := strings.Split(module, "/")
+	if len(parts) < 3 || !strings.Contains(parts[0], ".") {
+		return ""
+	}
+	if parts[0] == "github.com" {
+		return parts[1] + "/" + parts[2]
+	}
+	return strings.Join(parts[:3], "/")
+}
@@ -0,0 +1,150 @@
+package deps
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGoMod(t *testing.T) {
+	m, err := Parse("go.mod", []byte(`module github.com/acme/tool // the tool
+
+go 1.22
+
+require github.com/spf13/cobra v1.8.0
+
+require (
+	golang.org/x/net v0.25.0
+	github.com/acme/lib v0.3.1 // indirect
+)
+
+replace github.com/acme/lib => ../lib
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if m.Ecosystem != Go || m.Package != "github.com/acme/tool" {
+		t.Errorf("Expected go module github.com/acme/tool, got %s %q", m.Ecosystem, m.Package)
+	}
+	want := []Requirement{
+		{Name: "github.com/spf13/cobra", Version: "v1.8.0"},
+		{Name: "golang.org/x/net", Version: "v0.25.0"},
+		{Name: "github.com/acme/lib", Version: "v0.3.1", Scope: ScopeIndirect},
+	}
+	if !reflect.DeepEqual(m.Requirements, want) {
+		t.Errorf("Expected %+v, got %+v", want, m.Requirements)
+	}
+
+	if _, err := Parse("go.mod", []byte("go 1.22\n")); err == nil {
+		t.Errorf("Expected an error without a module directive")
+	}
+}
+
+func TestParsePackageJSON(t *testing.T) {
+	m, err := Parse("package.json", []byte(`{
+		"name": "@acme/web",
+		"dependencies": {"react": "^18.2.0", "left-pad": "1.3.0"},
+		"devDependencies": {"jest": "^29.0.0"},
+		"peerDependencies": {"react-dom": ">=18"}
+	}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	want := []Requirement{
+		{Name: "left-pad", Version: "1.3.0"},
+		{Name: "react", Version: "^18.2.0"},
+		{Name: "jest", Version: "^29.0.0", Scope: ScopeDev},
+		{Name: "react-dom", Version: ">=18", Scope: ScopePeer},
+	}
+	if m.Package != "@acme/web" || !reflect.DeepEqual(m.Requirements, want) {
+		t.Errorf("Expected @acme/web with %+v, got %q with %+v", want, m.Package, m.Requirements)
+	}
+
+	if _, err := Parse("package.json", []byte("{")); err == nil {
+		t.Errorf("Expected an error for invalid JSON")
+	}
+}
+
+func TestParseRequirements(t *testing.T) {
+	m, err := Parse("requirements.txt", []byte(`# web stack
+Django>=4.2,<5 # LTS
+requests[security] == 2.31.0
+zope.interface
+typing_extensions; python_version < "3.11"
+-r dev.txt
+-e .
+git+https://github.com/acme/lib.git#egg=lib
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	want := []Requirement{
+		{Name: "django", Version: ">=4.2,<5"},
+		{Name: "requests", Version: "==2.31.0"},
+		{Name: "zope-interface"},
+		{Name: "typing-extensions"},
+	}
+	if m.Package != "" || !reflect.DeepEqual(m.Requirements, want) {
+		t.Errorf("Expected %+v, got %+v", want, m.Requirements)
+	}
+}
+
+func TestParseCargoToml(t *testing.T) {
+	m, err := Parse("Cargo.toml", []byte(`[package]
+name = "acme-cli"
+version = "0.1.0"
+
+[dependencies]
+serde = { version = "1.0", features = ["derive", "rc"] }
+anyhow = "1"
+rand_core = { package = "rand", version = "0.8" }
+shared.workspace = true
+
+[dependencies.tokio]
+version = "1.37"
+features = ["full"]
+
+[dev-dependencies]
+criterion = "0.5" # benches
+
+[target.'cfg(windows)'.dependencies]
+winapi = "0.3"
+
+[build-dependencies]
+cc = "1.0"
+
+[features]
+default = []
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	want := []Requirement{
+		{Name: "serde", Version: "1.0"},
+		{Name: "anyhow", Version: "1"},
+		{Name: "rand", Version: "0.8"},
+		{Name: "shared"},
+		{Name: "tokio", Version: "1.37"},
+		{Name: "criterion", Version: "0.5", Scope: ScopeDev},
+		{Name: "winapi", Version: "0.3"},
+		{Name: "cc", Version: "1.0", Scope: ScopeBuild},
+	}
+	if m.Package != "acme-cli" || !reflect.DeepEqual(m.Requirements, want) {
+		t.Errorf("Expected acme-cli with %+v, got %q with %+v", want, m.Package, m.Requirements)
+	}
+}
+
+func TestGoModuleRepo(t *testing.T) {
+	cases := map[string]string{
+		"github.com/spf13/cobra":      "spf13/cobra",
+		"github.com/acme/tool/v2/cmd": "acme/tool",
+		"gitlab.com/group/project":    "gitlab.com/group/project",
+		"golang.org/x/net":            "golang.org/x/net",
+		"github.com/acme":             "",
+		"example/local/module":        "",
+	}
+	for module, want := range cases {
+		if got := GoModuleRepo(module); got != want {
+			t.Errorf("GoModuleRepo(%q): expected %q, got %q", module, want, got)
+		}
+	}
+}
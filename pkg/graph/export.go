@@ -0,0 +1,189 @@
+package graph
+
+import (
+	"Fyne-on/pkg/models"
+	"Fyne-on/pkg/storage"
+	"sort"
+	"strings"
+)
+
+// Store is the part of the storage service the exporter reads from
+type Store interface {
+	IterateRepos(fn func(models.Repo) error) error
+	IterateContacts(fn func(models.Contact) error) error
+	IterateEdges(kind string, fn func(models.Edge) error) error
+	IterateMarkovStates(fn func(storage.MarkovState) error) error
+}
+
+// Filter restricts which part of the graph is exported
+type Filter struct {
+	Language  string
+	Owner     string
+	MinDegree int
+	// Kinds limits the edge kinds; empty means all of them
+	Kinds []string
+}
+
+// Export streams the crawl graph to w. Records are read with prefix scans
+// and written as they are read; only node ids and degrees are kept in
+// memory, which is what the degree and language/owner filters need.
+func Export(store Store, w Writer, f Filter) error {
+	kinds := map[string]bool{}
+	for _, k := range f.Kinds {
+		kinds[k] = true
+	}
+	wantKind := func(kind string) bool {
+		return len(kinds) == 0 || kinds[kind]
+	}
+
+	// Repos passing the language/owner filters. nil means every repo passes.
+	var allowedRepos map[string]bool
+	if f.Language != "" || f.Owner != "" {
+		allowedRepos = map[string]bool{}
+		err := store.IterateRepos(func(r models.Repo) error {
+			if repoMatches(r, f) {
+				allowedRepos[r.Owner+"/"+r.Name] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	allowed := func(id string) bool {
+		if allowedRepos == nil || !isRepoID(id) {
+			return true
+		}
+		return allowedRepos[id]
+	}
+
+	forEachEdge := func(fn func(Edge) error) error {
+		if wantKind(models.EdgeOwns) {
+			err := store.IterateRepos(func(r models.Repo) error {
+				e := Edge{From: r.Owner, To: r.Owner + "/" + r.Name, Kind: models.EdgeOwns, Weight: 1}
+				if allowed(e.To) {
+					return fn(e)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		for _, kind := range []string{models.EdgeContributor, models.EdgeStarred, models.EdgeDependsOn} {
+			if !wantKind(kind) {
+				continue
+			}
+			err := store.IterateEdges(kind, func(se models.Edge) error {
+				e := Edge{From: se.From, To: se.To, Kind: se.Kind, Weight: se.Weight}
+				if allowed(e.From) && allowed(e.To) {
+					return fn(e)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if wantKind(models.EdgeMarkov) {
+			return store.IterateMarkovStates(func(s storage.MarkovState) error {
+				for _, t := range s.Transitions {
+					e := Edge{From: s.ID, To: t.To, Kind: models.EdgeMarkov, Weight: float64(t.Count)}
+					if allowed(e.From) && allowed(e.To) {
+						if err := fn(e); err != nil {
+							return err
+						}
+					}
+				}
+				return nil
+			})
+		}
+		return nil
+	}
+
+	degree := map[string]int{}
+	err := forEachEdge(func(e Edge) error {
+		degree[e.From]++
+		degree[e.To]++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	filtered := allowedRepos != nil || len(kinds) > 0
+	include := func(id string) bool {
+		d := degree[id]
+		if filtered && d == 0 {
+			return false
+		}
+		return d >= f.MinDegree
+	}
+
+	emitted := map[string]bool{}
+	err = store.IterateRepos(func(r models.Repo) error {
+		id := r.Owner + "/" + r.Name
+		if !allowed(id) || !include(id) {
+			return nil
+		}
+		emitted[id] = true
+		return w.WriteNode(Node{ID: id, Kind: "repo", Label: id, Language: r.Language, Stars: r.Stars, Degree: degree[id]})
+	})
+	if err != nil {
+		return err
+	}
+	err = store.IterateContacts(func(c models.Contact) error {
+		if emitted[c.Login] || !include(c.Login) {
+			return nil
+		}
+		emitted[c.Login] = true
+		return w.WriteNode(Node{ID: c.Login, Kind: "contact", Label: c.Login, Degree: degree[c.Login]})
+	})
+	if err != nil {
+		return err
+	}
+	// Edge endpoints without a stored record, e.g. organisations that own repos
+	missing := []string{}
+	for id, d := range degree {
+		if !emitted[id] && allowed(id) && d >= f.MinDegree {
+			missing = append(missing, id)
+		}
+	}
+	sort.Strings(missing)
+	for _, id := range missing {
+		d := degree[id]
+		emitted[id] = true
+		kind := "contact"
+		if isRepoID(id) {
+			kind = "repo"
+		}
+		if err := w.WriteNode(Node{ID: id, Kind: kind, Label: id, Degree: d}); err != nil {
+			return err
+		}
+	}
+
+	err = forEachEdge(func(e Edge) error {
+		if !emitted[e.From] || !emitted[e.To] {
+			return nil
+		}
+		return w.WriteEdge(e)
+	})
+	if err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+func repoMatches(r models.Repo, f Filter) bool {
+	if f.Language != "" && !strings.EqualFold(r.Language, f.Language) {
+		return false
+	}
+	if f.Owner != "" && !strings.EqualFold(r.Owner, f.Owner) {
+		return false
+	}
+	return true
+}
+
+func isRepoID(id string) bool {
+	return strings.Contains(id, "/")
+}
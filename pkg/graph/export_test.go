@@ -0,0 +1,165 @@
+package graph
+
+import (
+	"Fyne-on/pkg/markov"
+	"Fyne-on/pkg/models"
+	"Fyne-on/pkg/storage"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+type fakeStore struct {
+	repos    []models.Repo
+	contacts []models.Contact
+	edges    []models.Edge
+	states   []storage.MarkovState
+}
+
+func (f *fakeStore) IterateRepos(fn func(models.Repo) error) error {
+	for _, r := range f.repos {
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeStore) IterateContacts(fn func(models.Contact) error) error {
+	for _, c := range f.contacts {
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeStore) IterateEdges(kind string, fn func(models.Edge) error) error {
+	for _, e := range f.edges {
+		if kind != "" && e.Kind != kind {
+			continue
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeStore) IterateMarkovStates(fn func(storage.MarkovState) error) error {
+	for _, s := range f.states {
+		if err := fn(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		repos: []models.Repo{
+			{Owner: "golang", Name: "go", Language: "Go", Stars: 100},
+			{Owner: "python", Name: "cpython", Language: "Python", Stars: 50},
+		},
+		contacts: []models.Contact{
+			{Login: "alice"},
+			{Login: "bob"},
+			{Login: "lonely"},
+		},
+		edges: []models.Edge{
+			{Kind: models.EdgeContributor, From: "alice", To: "golang/go", Weight: 1},
+			{Kind: models.EdgeContributor, From: "bob", To: "python/cpython", Weight: 1},
+		},
+		states: []storage.MarkovState{
+			{ID: "alice", Transitions: []markov.Transition{{To: "golang/go", Count: 2}}},
+		},
+	}
+}
+
+func export(t *testing.T, format string, f Filter) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(format, &buf)
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+	if err := Export(newFakeStore(), w, f); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	return buf.String()
+}
+
+func TestExportJSON(t *testing.T) {
+	out := export(t, "json", Filter{})
+
+	var g struct {
+		Nodes []Node `json:"nodes"`
+		Edges []Edge `json:"edges"`
+	}
+	if err := json.Unmarshal([]byte(out), &g); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, out)
+	}
+	// 2 repos, 3 contacts, plus the golang and python owners
+	if len(g.Nodes) != 7 {
+		t.Errorf("Expected 7 nodes, got %d", len(g.Nodes))
+	}
+	// 2 owns, 2 contributor, 1 markov
+	if len(g.Edges) != 5 {
+		t.Errorf("Expected 5 edges, got %d", len(g.Edges))
+	}
+}
+
+func TestExportXMLFormatsAreWellFormed(t *testing.T) {
+	for _, format := range []string{"graphml", "gexf"} {
+		out := export(t, format, Filter{})
+		dec := xml.NewDecoder(strings.NewReader(out))
+		for {
+			_, err := dec.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: invalid XML: %v\n%s", format, err, out)
+			}
+		}
+	}
+}
+
+func TestExportDOT(t *testing.T) {
+	out := export(t, "dot", Filter{})
+	if !strings.HasPrefix(out, "digraph crawl {") {
+		t.Errorf("Unexpected DOT header: %s", out)
+	}
+	if !strings.Contains(out, `"alice" -> "golang/go" [kind="contributor"`) {
+		t.Errorf("Missing contributor edge in:\n%s", out)
+	}
+}
+
+func TestExportFilters(t *testing.T) {
+	out := export(t, "json", Filter{Language: "go", MinDegree: 1})
+
+	var g struct {
+		Nodes []Node `json:"nodes"`
+		Edges []Edge `json:"edges"`
+	}
+	if err := json.Unmarshal([]byte(out), &g); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	for _, n := range g.Nodes {
+		if n.ID == "python/cpython" || n.ID == "bob" || n.ID == "lonely" {
+			t.Errorf("Node %s should have been filtered out", n.ID)
+		}
+	}
+	if len(g.Nodes) != 3 {
+		t.Errorf("Expected golang/go, alice and golang, got %v", g.Nodes)
+	}
+}
+
+func TestNewWriterUnknownFormat(t *testing.T) {
+	if _, err := NewWriter("csv", io.Discard); err == nil {
+		t.Fatal("Expected error for unknown format")
+	}
+}
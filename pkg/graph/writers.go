@@ -0,0 +1,311 @@
+package graph
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Node is a contact or repository in the exported graph
+type Node struct {
+	ID       string `json:"id"`
+	Kind     string `json:"kind"` // contact, repo
+	Label    string `json:"label"`
+	Language string `json:"language,omitempty"`
+	Stars    int    `json:"stars,omitempty"`
+	Degree   int    `json:"degree"`
+}
+
+// Edge is a directed, typed relation in the exported graph
+type Edge struct {
+	From   string  `json:"source"`
+	To     string  `json:"target"`
+	Kind   string  `json:"kind"`
+	Weight float64 `json:"weight"`
+}
+
+// Writer streams a graph in some file format. All nodes must be written
+// before the first edge, which is what GEXF requires.
+type Writer interface {
+	WriteNode(n Node) error
+	WriteEdge(e Edge) error
+	Close() error
+}
+
+// Formats lists the supported export formats
+var Formats = []string{"dot", "graphml", "gexf", "json"}
+
+// ContentType returns the MIME type of a format
+func ContentType(format string) string {
+	switch format {
+	case "dot":
+		return "text/vnd.graphviz; charset=utf-8"
+	case "json":
+		return "application/json"
+	default:
+		return "application/xml"
+	}
+}
+
+// NewWriter creates a writer for a format
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case "dot":
+		return &dotWriter{w: w}, nil
+	case "graphml":
+		return &graphmlWriter{w: w}, nil
+	case "gexf":
+		return &gexfWriter{w: w}, nil
+	case "json":
+		return &jsonWriter{w: w}, nil
+	}
+	return nil, fmt.Errorf("unsupported graph format: %s", format)
+}
+
+type dotWriter struct {
+	w       io.Writer
+	started bool
+}
+
+func (d *dotWriter) start() error {
+	if d.started {
+		return nil
+	}
+	d.started = true
+	_, err := io.WriteString(d.w, "digraph crawl {\n")
+	return err
+}
+
+func (d *dotWriter) WriteNode(n Node) error {
+	if err := d.start(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(d.w, "  %s [label=%s, kind=%s, language=%s, stars=%d];\n",
+		dotQuote(n.ID), dotQuote(n.Label), dotQuote(n.Kind), dotQuote(n.Language), n.Stars)
+	return err
+}
+
+func (d *dotWriter) WriteEdge(e Edge) error {
+	if err := d.start(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(d.w, "  %s -> %s [kind=%s, weight=%s];\n",
+		dotQuote(e.From), dotQuote(e.To), dotQuote(e.Kind), formatWeight(e.Weight))
+	return err
+}
+
+func (d *dotWriter) Close() error {
+	if err := d.start(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(d.w, "}\n")
+	return err
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+type graphmlWriter struct {
+	w       io.Writer
+	started bool
+	edges   int
+}
+
+func (g *graphmlWriter) start() error {
+	if g.started {
+		return nil
+	}
+	g.started = true
+	_, err := io.WriteString(g.w, xml.Header+
+		`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`+"\n"+
+		`  <key id="kind" for="all" attr.name="kind" attr.type="string"/>`+"\n"+
+		`  <key id="label" for="node" attr.name="label" attr.type="string"/>`+"\n"+
+		`  <key id="language" for="node" attr.name="language" attr.type="string"/>`+"\n"+
+		`  <key id="stars" for="node" attr.name="stars" attr.type="int"/>`+"\n"+
+		`  <key id="weight" for="edge" attr.name="weight" attr.type="double"/>`+"\n"+
+		`  <graph id="crawl" edgedefault="directed">`+"\n")
+	return err
+}
+
+func (g *graphmlWriter) WriteNode(n Node) error {
+	if err := g.start(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(g.w,
+		`    <node id="%s"><data key="kind">%s</data><data key="label">%s</data><data key="language">%s</data><data key="stars">%d</data></node>`+"\n",
+		xmlEscape(n.ID), xmlEscape(n.Kind), xmlEscape(n.Label), xmlEscape(n.Language), n.Stars)
+	return err
+}
+
+func (g *graphmlWriter) WriteEdge(e Edge) error {
+	if err := g.start(); err != nil {
+		return err
+	}
+	g.edges++
+	_, err := fmt.Fprintf(g.w,
+		`    <edge id="e%d" source="%s" target="%s"><data key="kind">%s</data><data key="weight">%s</data></edge>`+"\n",
+		g.edges, xmlEscape(e.From), xmlEscape(e.To), xmlEscape(e.Kind), formatWeight(e.Weight))
+	return err
+}
+
+func (g *graphmlWriter) Close() error {
+	if err := g.start(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(g.w, "  </graph>\n</graphml>\n")
+	return err
+}
+
+// gexfWriter keeps track of which section it is in, since GEXF wraps
+// nodes and edges in separate <nodes> and <edges> elements
+type gexfWriter struct {
+	w       io.Writer
+	section string
+	edges   int
+}
+
+func (g *gexfWriter) enter(section string) error {
+	if g.section == section {
+		return nil
+	}
+	var b strings.Builder
+	switch g.section {
+	case "":
+		b.WriteString(xml.Header)
+		b.WriteString(`<gexf xmlns="http://gexf.net/1.3" version="1.3">` + "\n")
+		b.WriteString(`  <graph defaultedgetype="directed">` + "\n")
+		b.WriteString(`    <attributes class="node">` + "\n")
+		b.WriteString(`      <attribute id="kind" title="kind" type="string"/>` + "\n")
+		b.WriteString(`      <attribute id="language" title="language" type="string"/>` + "\n")
+		b.WriteString(`      <attribute id="stars" title="stars" type="integer"/>` + "\n")
+		b.WriteString(`    </attributes>` + "\n")
+	case "nodes":
+		b.WriteString("    </nodes>\n")
+	case "edges":
+		b.WriteString("    </edges>\n")
+	}
+	switch section {
+	case "nodes":
+		b.WriteString("    <nodes>\n")
+	case "edges":
+		if g.section == "" {
+			b.WriteString("    <nodes>\n    </nodes>\n")
+		}
+		b.WriteString("    <edges>\n")
+	}
+	g.section = section
+	_, err := io.WriteString(g.w, b.String())
+	return err
+}
+
+func (g *gexfWriter) WriteNode(n Node) error {
+	if g.section == "edges" {
+		return fmt.Errorf("gexf: node %s written after edges", n.ID)
+	}
+	if err := g.enter("nodes"); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(g.w,
+		`      <node id="%s" label="%s"><attvalues><attvalue for="kind" value="%s"/><attvalue for="language" value="%s"/><attvalue for="stars" value="%d"/></attvalues></node>`+"\n",
+		xmlEscape(n.ID), xmlEscape(n.Label), xmlEscape(n.Kind), xmlEscape(n.Language), n.Stars)
+	return err
+}
+
+func (g *gexfWriter) WriteEdge(e Edge) error {
+	if err := g.enter("edges"); err != nil {
+		return err
+	}
+	g.edges++
+	_, err := fmt.Fprintf(g.w,
+		`      <edge id="e%d" source="%s" target="%s" label="%s" weight="%s"/>`+"\n",
+		g.edges, xmlEscape(e.From), xmlEscape(e.To), xmlEscape(e.Kind), formatWeight(e.Weight))
+	return err
+}
+
+func (g *gexfWriter) Close() error {
+	if err := g.enter("edges"); err != nil {
+		return err
+	}
+	_, err := io.WriteString(g.w, "    </edges>\n  </graph>\n</gexf>\n")
+	return err
+}
+
+// jsonWriter emits {"nodes":[...],"edges":[...]}, the node-link layout
+// NetworkX reads with json_graph.node_link_graph(..., edges="edges")
+type jsonWriter struct {
+	w       io.Writer
+	section string
+	count   int
+}
+
+func (j *jsonWriter) enter(section string) error {
+	if j.section == section {
+		return nil
+	}
+	var s string
+	switch {
+	case j.section == "" && section == "nodes":
+		s = `{"directed":true,"multigraph":true,"graph":{},"nodes":[`
+	case j.section == "" && section == "edges":
+		s = `{"directed":true,"multigraph":true,"graph":{},"nodes":[],"edges":[`
+	case j.section == "nodes" && section == "edges":
+		s = `],"edges":[`
+	default:
+		return fmt.Errorf("json: cannot write %s after %s", section, j.section)
+	}
+	j.section = section
+	j.count = 0
+	_, err := io.WriteString(j.w, s)
+	return err
+}
+
+func (j *jsonWriter) write(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if j.count > 0 {
+		if _, err := io.WriteString(j.w, ","); err != nil {
+			return err
+		}
+	}
+	j.count++
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonWriter) WriteNode(n Node) error {
+	if err := j.enter("nodes"); err != nil {
+		return err
+	}
+	return j.write(n)
+}
+
+func (j *jsonWriter) WriteEdge(e Edge) error {
+	if err := j.enter("edges"); err != nil {
+		return err
+	}
+	return j.write(e)
+}
+
+func (j *jsonWriter) Close() error {
+	if err := j.enter("edges"); err != nil {
+		return err
+	}
+	_, err := io.WriteString(j.w, "]}\n")
+	return err
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func formatWeight(w float64) string {
+	return strconv.FormatFloat(w, 'f', -1, 64)
+}
@@ -0,0 +1,237 @@
+// Package httpcache stores HTTP responses on disk so crawls can be
+// repeated without hitting the network.
+//
+// Bodies are content addressed: each is written once under the SHA-256 of
+// its bytes, and an index entry per request (keyed by the SHA-256 of method
+// and URL) records the status, headers and body hash.
+package httpcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNotCached is returned by Get when a request has no cache entry
+var ErrNotCached = errors.New("not cached")
+
+// Entry describes a cached response
+type Entry struct {
+	Method   string      `json:"method"`
+	URL      string      `json:"url"`
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header"`
+	Body     string      `json:"body"` // SHA-256 of the body, names the blob file
+	Size     int         `json:"size"`
+	StoredAt time.Time   `json:"stored_at"`
+}
+
+// Stats summarises the cache directory and its use since Open
+type Stats struct {
+	Dir     string `json:"dir"`
+	Entries int    `json:"entries"`
+	Blobs   int    `json:"blobs"`
+	Bytes   int64  `json:"bytes"`
+	Hits    int64  `json:"hits"`
+	Misses  int64  `json:"misses"`
+	Stores  int64  `json:"stores"`
+}
+
+type ttlRule struct {
+	prefix string
+	ttl    time.Duration
+}
+
+// Cache is an on-disk response store
+type Cache struct {
+	dir string
+
+	mu    sync.RWMutex
+	ttl   time.Duration
+	rules []ttlRule
+
+	hits, misses, stores atomic.Int64
+}
+
+// Open creates (if needed) and opens a cache directory. Entries older than
+// ttl are stale; ttl <= 0 keeps them forever.
+func Open(dir string, ttl time.Duration) (*Cache, error) {
+	for _, sub := range []string{"index", "blobs"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create cache directory: %w", err)
+		}
+	}
+	return &Cache{dir: dir, ttl: ttl}, nil
+}
+
+// Dir returns the cache directory
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// SetTTL overrides the TTL for URLs starting with prefix, e.g.
+// "https://api.github.com/". The longest matching prefix wins.
+func (c *Cache) SetTTL(prefix string, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.rules {
+		if c.rules[i].prefix == prefix {
+			c.rules[i].ttl = ttl
+			return
+		}
+	}
+	c.rules = append(c.rules, ttlRule{prefix: prefix, ttl: ttl})
+}
+
+// TTL returns how long a response for rawURL stays fresh (<= 0: forever)
+func (c *Cache) TTL(rawURL string) time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ttl, matched := c.ttl, -1
+	for _, r := range c.rules {
+		if strings.HasPrefix(rawURL, r.prefix) && len(r.prefix) > matched {
+			ttl, matched = r.ttl, len(r.prefix)
+		}
+	}
+	return ttl
+}
+
+// Fresh reports whether e is still within the TTL of its URL
+func (c *Cache) Fresh(e *Entry, now time.Time) bool {
+	ttl := c.TTL(e.URL)
+	return ttl <= 0 || now.Sub(e.StoredAt) < ttl
+}
+
+// Get returns the cached response for a request, fresh or not, or
+// ErrNotCached
+func (c *Cache) Get(method, rawURL string) (*Entry, []byte, error) {
+	data, err := os.ReadFile(c.indexPath(requestKey(method, rawURL)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, ErrNotCached
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, nil, fmt.Errorf("corrupt cache entry for %s: %w", rawURL, err)
+	}
+	body, err := os.ReadFile(c.blobPath(e.Body))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, ErrNotCached
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return &e, body, nil
+}
+
+// Put stores a response. Identical bodies share one blob.
+func (c *Cache) Put(method, rawURL string, status int, header http.Header, body []byte) error {
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:])
+
+	blob := c.blobPath(hash)
+	if _, err := os.Stat(blob); err != nil {
+		if err := writeFileAtomic(blob, body); err != nil {
+			return fmt.Errorf("failed to store body: %w", err)
+		}
+	}
+
+	header = header.Clone()
+	header.Del("Set-Cookie")
+	data, err := json.Marshal(Entry{
+		Method:   method,
+		URL:      rawURL,
+		Status:   status,
+		Header:   header,
+		Body:     hash,
+		Size:     len(body),
+		StoredAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(c.indexPath(requestKey(method, rawURL)), data); err != nil {
+		return fmt.Errorf("failed to store cache entry: %w", err)
+	}
+	c.stores.Add(1)
+	return nil
+}
+
+// Stats walks the cache directory and returns its size and hit counters
+func (c *Cache) Stats() (Stats, error) {
+	st := Stats{
+		Dir:    c.dir,
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+		Stores: c.stores.Load(),
+	}
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || strings.HasPrefix(d.Name(), ".tmp") {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		st.Bytes += info.Size()
+		if strings.HasSuffix(path, ".json") {
+			st.Entries++
+		} else {
+			st.Blobs++
+		}
+		return nil
+	})
+	if err != nil {
+		return st, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+	return st, nil
+}
+
+func requestKey(method, rawURL string) string {
+	sum := sha256.Sum256([]byte(method + " " + rawURL))
+	return hex.EncodeToString(sum[:])
+}
+
+// indexPath and blobPath fan files out over 256 subdirectories
+func (c *Cache) indexPath(key string) string {
+	return filepath.Join(c.dir, "index", key[:2], key+".json")
+}
+
+func (c *Cache) blobPath(hash string) string {
+	return filepath.Join(c.dir, "blobs", hash[:2], hash)
+}
+
+// writeFileAtomic writes through a temporary file so readers never see a
+// partial file
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
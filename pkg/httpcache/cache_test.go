@@ -0,0 +1,168 @@
+package httpcache
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newCountingServer(t *testing.T) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/limited":
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Header().Set("Set-Cookie", "session=secret")
+			io.WriteString(w, "same body")
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func get(t *testing.T, client *http.Client, url string) (*http.Response, string) {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body)
+}
+
+func TestTransportServesFromCache(t *testing.T) {
+	srv, calls := newCountingServer(t)
+	c, err := Open(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: Wrap(nil, c, false)}
+
+	get(t, client, srv.URL+"/a")
+	resp, body := get(t, client, srv.URL+"/a")
+	if calls.Load() != 1 {
+		t.Errorf("Expected 1 request to the server, got %d", calls.Load())
+	}
+	if body != "same body" || resp.Header.Get("X-From-Cache") != "1" {
+		t.Errorf("Expected cached body, got %q (X-From-Cache=%q)", body, resp.Header.Get("X-From-Cache"))
+	}
+	if resp.Header.Get("Set-Cookie") != "" {
+		t.Errorf("Expected Set-Cookie not to be cached")
+	}
+
+	// 404s are replayed too, rate limit responses are not
+	get(t, client, srv.URL+"/missing")
+	if resp, _ := get(t, client, srv.URL+"/missing"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected cached 404, got %d", resp.StatusCode)
+	}
+	get(t, client, srv.URL+"/limited")
+	get(t, client, srv.URL+"/limited")
+	if calls.Load() != 4 {
+		t.Errorf("Expected 4 requests to the server, got %d", calls.Load())
+	}
+}
+
+func TestCacheSharesIdenticalBodies(t *testing.T) {
+	srv, _ := newCountingServer(t)
+	c, err := Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: Wrap(nil, c, false)}
+	get(t, client, srv.URL+"/a")
+	get(t, client, srv.URL+"/b")
+
+	st, err := c.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Entries != 2 || st.Blobs != 1 {
+		t.Errorf("Expected 2 entries sharing 1 blob, got %d entries and %d blobs", st.Entries, st.Blobs)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	srv, calls := newCountingServer(t)
+	c, err := Open(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetTTL(srv.URL+"/short", time.Nanosecond)
+	client := &http.Client{Transport: Wrap(nil, c, false)}
+
+	get(t, client, srv.URL+"/short")
+	time.Sleep(time.Millisecond)
+	get(t, client, srv.URL+"/short")
+	if calls.Load() != 2 {
+		t.Errorf("Expected stale entry to be fetched again, got %d requests", calls.Load())
+	}
+
+	if ttl := c.TTL(srv.URL + "/other"); ttl != time.Hour {
+		t.Errorf("Expected default TTL 1h, got %v", ttl)
+	}
+}
+
+func TestOfflineMode(t *testing.T) {
+	srv, calls := newCountingServer(t)
+	c, err := Open(t.TempDir(), time.Nanosecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get(t, &http.Client{Transport: Wrap(nil, c, false)}, srv.URL+"/a")
+
+	offline := &http.Client{Transport: Wrap(nil, c, true)}
+	// Stale entries are still served offline
+	time.Sleep(time.Millisecond)
+	if _, body := get(t, offline, srv.URL+"/a"); body != "same body" {
+		t.Errorf("Expected cached body offline, got %q", body)
+	}
+
+	_, err = offline.Get(srv.URL + "/b")
+	if !errors.Is(err, ErrOfflineMiss) {
+		t.Errorf("Expected ErrOfflineMiss, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected no requests while offline, got %d", calls.Load()-1)
+	}
+}
+
+func TestWrapReplacesCache(t *testing.T) {
+	c, err := Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt := Wrap(http.DefaultTransport, c, false)
+	rt = Wrap(rt, c, true)
+	tr, ok := rt.(*Transport)
+	if !ok || tr.Next != http.DefaultTransport || !tr.Offline {
+		t.Errorf("Expected a single offline transport over the default, got %#v", rt)
+	}
+	if Wrap(rt, nil, false) != http.DefaultTransport {
+		t.Errorf("Expected nil cache to unwrap the transport")
+	}
+}
+
+func TestRebaseKeepsCache(t *testing.T) {
+	c, err := Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := &http.Transport{}
+	tr, ok := Rebase(Wrap(nil, c, true), next).(*Transport)
+	if !ok || tr.Cache != c || tr.Next != next || !tr.Offline {
+		t.Errorf("Expected the cache to stay in front of the new transport, got %#v", tr)
+	}
+	if Rebase(http.DefaultTransport, next) != next {
+		t.Errorf("Expected an uncached transport to be replaced")
+	}
+}
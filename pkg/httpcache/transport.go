@@ -0,0 +1,123 @@
+package httpcache
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// ErrOfflineMiss is returned in offline mode for requests that are not cached
+var ErrOfflineMiss = errors.New("not in cache (offline mode)")
+
+// cacheable lists the statuses worth replaying; errors and rate limit
+// responses are always fetched again
+var cacheable = map[int]bool{
+	http.StatusOK:                true,
+	http.StatusMovedPermanently:  true,
+	http.StatusFound:             true,
+	http.StatusPermanentRedirect: true,
+	http.StatusNotFound:          true,
+	http.StatusGone:              true,
+}
+
+// Transport is an http.RoundTripper answering GET requests from a Cache.
+// Online, fresh entries are served and misses are fetched through Next and
+// stored. Offline, every cached entry is served regardless of age and
+// misses fail with ErrOfflineMiss, so a crawl never touches the network.
+type Transport struct {
+	Cache   *Cache
+	Next    http.RoundTripper
+	Offline bool
+}
+
+// Wrap puts a cache in front of rt. If rt already is a *Transport its cache
+// is replaced rather than stacked; a nil cache removes caching.
+func Wrap(rt http.RoundTripper, c *Cache, offline bool) http.RoundTripper {
+	if t, ok := rt.(*Transport); ok {
+		rt = t.Next
+	}
+	if c == nil {
+		return rt
+	}
+	return &Transport{Cache: c, Next: rt, Offline: offline}
+}
+
+// Rebase swaps the transport underneath a cache, keeping the cache in front.
+// If rt is not a *Transport, next is returned as is.
+func Rebase(rt, next http.RoundTripper) http.RoundTripper {
+	if t, ok := rt.(*Transport); ok {
+		return &Transport{Cache: t.Cache, Next: next, Offline: t.Offline}
+	}
+	return next
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		if t.Offline {
+			return nil, fmt.Errorf("%w: %s %s", ErrOfflineMiss, req.Method, req.URL)
+		}
+		return t.next().RoundTrip(req)
+	}
+
+	rawURL := req.URL.String()
+	e, body, err := t.Cache.Get(req.Method, rawURL)
+	if err != nil && !errors.Is(err, ErrNotCached) {
+		log.Printf("httpcache: %v", err)
+	}
+	if e != nil && (t.Offline || t.Cache.Fresh(e, time.Now())) {
+		t.Cache.hits.Add(1)
+		return cachedResponse(req, e, body), nil
+	}
+	t.Cache.misses.Add(1)
+	if t.Offline {
+		return nil, fmt.Errorf("%w: %s", ErrOfflineMiss, rawURL)
+	}
+
+	resp, err := t.next().RoundTrip(req)
+	if err != nil || !cacheable[resp.StatusCode] {
+		return resp, err
+	}
+
+	body, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Cache.Put(req.Method, rawURL, resp.StatusCode, resp.Header, body); err != nil {
+		log.Printf("httpcache: %v", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	return resp, nil
+}
+
+func (t *Transport) next() http.RoundTripper {
+	if t.Next != nil {
+		return t.Next
+	}
+	return http.DefaultTransport
+}
+
+func cachedResponse(req *http.Request, e *Entry, body []byte) *http.Response {
+	header := e.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("X-From-Cache", "1")
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status)),
+		StatusCode:    e.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
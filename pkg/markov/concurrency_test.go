@@ -0,0 +1,170 @@
+package markov
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// These tests are meant to be run with -race
+
+func TestConcurrentAddTransition(t *testing.T) {
+	mc := NewMarkovChain(42)
+
+	const workers = 8
+	const perWorker = 1000
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				mc.AddTransition(fmt.Sprintf("user%d", i%50), fmt.Sprintf("repo%d", w))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if got := mc.GetTransitionCount(); got != workers*perWorker {
+		t.Errorf("Expected %d transitions, got %d", workers*perWorker, got)
+	}
+	if got := mc.GetStateCount(); got != 50 {
+		t.Errorf("Expected 50 states, got %d", got)
+	}
+}
+
+func TestConcurrentReadersAndWriters(t *testing.T) {
+	mc := NewMarkovChain(42)
+	mc.AddTransition("state1", "state2")
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				mc.AddTransition("state1", fmt.Sprintf("state%d", i%10))
+				mc.AddTransition(fmt.Sprintf("state%d", i%10), "state1")
+				if i%100 == 0 {
+					mc.RemoveTransition("state1", "state3")
+				}
+			}
+		}(w)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				mc.GetNextState("state1")
+				mc.Probability("state1", "state2")
+				mc.TopK("state1", 3)
+				mc.Distribution("state1")
+				mc.GetState("state1")
+				if i%100 == 0 {
+					mc.Snapshot()
+					mc.StationaryDistribution(0.85, 5, 1e-6)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !mc.HasTransitions("state1") {
+		t.Error("Expected state1 to have transitions")
+	}
+}
+
+func TestConcurrentHigherOrderChain(t *testing.T) {
+	hc := NewHigherOrderChain(2, 42)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				hc.Train([]string{"a", fmt.Sprintf("a/repo%d", i%5), fmt.Sprintf("user%d", w)})
+			}
+		}(w)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				hc.Generate([]string{"a"}, 3)
+				hc.Predict([]string{"a"}, 2)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkAddTransitionParallel(b *testing.B) {
+	mc := NewMarkovChain(42)
+	states := make([]string, 1024)
+	for i := range states {
+		states[i] = fmt.Sprintf("user%d", i)
+	}
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			mc.AddTransition(states[i%len(states)], states[(i+1)%len(states)])
+			i++
+		}
+	})
+}
+
+func BenchmarkAddTransitionParallelHotState(b *testing.B) {
+	mc := NewMarkovChain(42)
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			mc.AddTransition("hot", "next")
+		}
+	})
+}
+
+func BenchmarkMixedParallel(b *testing.B) {
+	mc := NewMarkovChain(42)
+	states := make([]string, 1024)
+	for i := range states {
+		states[i] = fmt.Sprintf("user%d", i)
+		mc.AddTransition(states[i], "seed")
+	}
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			s := states[i%len(states)]
+			if i%10 == 0 {
+				mc.AddTransition(s, states[(i+7)%len(states)])
+			} else {
+				mc.Probability(s, "seed")
+			}
+			i++
+		}
+	})
+}
+
+// BenchmarkGlobalLockBaseline is the same workload as
+// BenchmarkAddTransitionParallel guarded by a single mutex, for comparison
+func BenchmarkGlobalLockBaseline(b *testing.B) {
+	var mu sync.Mutex
+	transitions := map[string]map[string]int{}
+	states := make([]string, 1024)
+	for i := range states {
+		states[i] = fmt.Sprintf("user%d", i)
+	}
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			from, to := states[i%len(states)], states[(i+1)%len(states)]
+			mu.Lock()
+			if transitions[from] == nil {
+				transitions[from] = map[string]int{}
+			}
+			transitions[from][to]++
+			mu.Unlock()
+			i++
+		}
+	})
+}
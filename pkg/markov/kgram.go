@@ -0,0 +1,146 @@
+package markov
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+)
+
+// contextSep joins the states of a history into a single context key.
+// It is a control character so it never collides with logins or repo ids.
+const contextSep = "\x1f"
+
+// HigherOrderChain is an order-k Markov chain: the next state depends on
+// the last k visited states instead of only the current one. Chains of
+// every order from 1 to k are trained together, so predictions back off
+// to shorter histories when a long one has never been observed.
+type HigherOrderChain struct {
+	order  int
+	chains []*MarkovChain // chains[i] has order i+1
+
+	rngMu sync.Mutex // guards rng, the chains guard themselves
+	rng   *rand.Rand
+}
+
+// NewHigherOrderChain creates an order-k chain
+func NewHigherOrderChain(order int, seed int64) *HigherOrderChain {
+	if order < 1 {
+		order = 1
+	}
+	chains := make([]*MarkovChain, order)
+	for i := range chains {
+		chains[i] = NewMarkovChain(seed)
+		if seed == 0 {
+			seed = chains[i].Seed()
+		}
+	}
+	return &HigherOrderChain{
+		order:  order,
+		chains: chains,
+		rng:    rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Order returns k
+func (hc *HigherOrderChain) Order() int {
+	return hc.order
+}
+
+// AddTransition records that next followed the given history.
+// Only the last k states of history are used.
+func (hc *HigherOrderChain) AddTransition(history []string, next string) {
+	for k := 1; k <= hc.order && k <= len(history); k++ {
+		hc.chains[k-1].AddTransition(contextKey(history, k), next)
+	}
+}
+
+// Train records every transition along a path
+func (hc *HigherOrderChain) Train(path []string) {
+	for i := 1; i < len(path); i++ {
+		hc.AddTransition(path[:i], path[i])
+	}
+}
+
+// Predict returns the n most likely next states after history, using the
+// longest suffix of history that has been observed
+func (hc *HigherOrderChain) Predict(history []string, n int) ([]Transition, error) {
+	chain, key := hc.lookup(history)
+	if chain == nil {
+		return nil, fmt.Errorf("no transitions from history: %s", strings.Join(history, " -> "))
+	}
+	return chain.TopK(key, n), nil
+}
+
+// Probability returns P(next | history) using the same back-off as Predict
+func (hc *HigherOrderChain) Probability(history []string, next string) float64 {
+	chain, key := hc.lookup(history)
+	if chain == nil {
+		return 0
+	}
+	return chain.Probability(key, next)
+}
+
+// Generate extends start by sampling up to length more states.
+// Generation stops early if the path reaches a history with no transitions.
+func (hc *HigherOrderChain) Generate(start []string, length int) ([]string, error) {
+	if len(start) == 0 {
+		return nil, fmt.Errorf("generate needs at least one start state")
+	}
+
+	hc.rngMu.Lock()
+	defer hc.rngMu.Unlock()
+
+	path := append([]string{}, start...)
+	for i := 0; i < length; i++ {
+		chain, key := hc.lookup(path)
+		if chain == nil {
+			break
+		}
+		next, err := chain.GetNextStateWithRand(key, hc.rng)
+		if err != nil {
+			break
+		}
+		path = append(path, next)
+	}
+	return path, nil
+}
+
+// Score returns the log-likelihood of a path, i.e. the sum of
+// log P(path[i] | path[:i]) for every step. Unseen steps give -Inf.
+func (hc *HigherOrderChain) Score(path []string) float64 {
+	score := 0.0
+	for i := 1; i < len(path); i++ {
+		p := hc.Probability(path[:i], path[i])
+		if p == 0 {
+			return math.Inf(-1)
+		}
+		score += math.Log(p)
+	}
+	return score
+}
+
+// GetContextCount returns the number of distinct histories per order
+func (hc *HigherOrderChain) GetContextCount() []int {
+	counts := make([]int, hc.order)
+	for i, c := range hc.chains {
+		counts[i] = c.GetStateCount()
+	}
+	return counts
+}
+
+// lookup finds the longest observed suffix of history
+func (hc *HigherOrderChain) lookup(history []string) (*MarkovChain, string) {
+	for k := min(hc.order, len(history)); k >= 1; k-- {
+		key := contextKey(history, k)
+		if hc.chains[k-1].HasTransitions(key) {
+			return hc.chains[k-1], key
+		}
+	}
+	return nil, ""
+}
+
+func contextKey(history []string, k int) string {
+	return strings.Join(history[len(history)-k:], contextSep)
+}
@@ -0,0 +1,108 @@
+package markov
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewHigherOrderChain(t *testing.T) {
+	hc := NewHigherOrderChain(3, 42)
+	if hc == nil {
+		t.Fatal("NewHigherOrderChain returned nil")
+	}
+	if hc.Order() != 3 {
+		t.Errorf("Expected order 3, got %d", hc.Order())
+	}
+}
+
+func TestHigherOrderTrain(t *testing.T) {
+	hc := NewHigherOrderChain(2, 42)
+
+	hc.Train([]string{"user1", "user1/repo", "user2"})
+	hc.Train([]string{"user3", "user1/repo", "user4"})
+
+	counts := hc.GetContextCount()
+	if counts[0] != 3 {
+		t.Errorf("Expected 3 first-order contexts, got %d", counts[0])
+	}
+	if counts[1] != 2 {
+		t.Errorf("Expected 2 second-order contexts, got %d", counts[1])
+	}
+}
+
+func TestHigherOrderPredictUsesHistory(t *testing.T) {
+	hc := NewHigherOrderChain(2, 42)
+
+	hc.Train([]string{"user1", "user1/repo", "user2"})
+	hc.Train([]string{"user3", "user1/repo", "user4"})
+
+	next, err := hc.Predict([]string{"user1", "user1/repo"}, 1)
+	if err != nil {
+		t.Fatalf("Predict returned error: %v", err)
+	}
+	if next[0].To != "user2" {
+		t.Errorf("Expected 'user2', got '%s'", next[0].To)
+	}
+
+	next, _ = hc.Predict([]string{"user3", "user1/repo"}, 1)
+	if next[0].To != "user4" {
+		t.Errorf("Expected 'user4', got '%s'", next[0].To)
+	}
+}
+
+func TestHigherOrderPredictBacksOff(t *testing.T) {
+	hc := NewHigherOrderChain(2, 42)
+	hc.Train([]string{"user1", "user1/repo", "user2"})
+
+	next, err := hc.Predict([]string{"unseen", "user1/repo"}, 1)
+	if err != nil {
+		t.Fatalf("Predict returned error: %v", err)
+	}
+	if next[0].To != "user2" {
+		t.Errorf("Expected 'user2', got '%s'", next[0].To)
+	}
+}
+
+func TestHigherOrderPredictNoTransition(t *testing.T) {
+	hc := NewHigherOrderChain(2, 42)
+
+	_, err := hc.Predict([]string{"nonexistent"}, 1)
+	if err == nil {
+		t.Fatal("Expected error for nonexistent history")
+	}
+}
+
+func TestHigherOrderGenerate(t *testing.T) {
+	hc := NewHigherOrderChain(2, 42)
+	hc.Train([]string{"user1", "user1/repo", "user2", "user2/repo"})
+
+	path, err := hc.Generate([]string{"user1"}, 5)
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	expected := []string{"user1", "user1/repo", "user2", "user2/repo"}
+	if len(path) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, path)
+	}
+	for i := range expected {
+		if path[i] != expected[i] {
+			t.Errorf("Step %d: expected '%s', got '%s'", i, expected[i], path[i])
+		}
+	}
+}
+
+func TestHigherOrderScore(t *testing.T) {
+	hc := NewHigherOrderChain(2, 42)
+	hc.Train([]string{"a", "b", "c"})
+	hc.Train([]string{"a", "b", "d"})
+
+	score := hc.Score([]string{"a", "b", "c"})
+	if math.Abs(score-math.Log(0.5)) > 1e-9 {
+		t.Errorf("Expected log(0.5), got %f", score)
+	}
+
+	if !math.IsInf(hc.Score([]string{"a", "c"}), -1) {
+		t.Error("Expected -Inf for unseen path")
+	}
+}
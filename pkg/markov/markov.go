@@ -1,16 +1,68 @@
 package markov
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/rand"
+	"sort"
+	"sync"
 	"time"
 )
 
-// MarkovChain represents a Markov chain for URL traversal
+// shardCount is the number of independently locked partitions of the
+// transition map. Writers only contend when their source states hash
+// to the same shard.
+const shardCount = 32
+
+// MarkovChain represents a Markov chain for URL traversal.
+// It is safe for concurrent use by multiple goroutines.
 type MarkovChain struct {
-	transitions map[string][]string
-	seed        int64
-	rng         *rand.Rand
+	shards [shardCount]*shard
+	seed   int64
+
+	rngMu sync.Mutex // guards src and rng
+	src   *countingSource
+	rng   *rand.Rand
+}
+
+type shard struct {
+	mu          sync.RWMutex
+	transitions map[string]*edges
+}
+
+// edges holds the weighted transitions out of a single state.
+// order keeps first-seen order so sampling is reproducible for a seed.
+type edges struct {
+	order  []string
+	counts map[string]int
+	total  int
+}
+
+// Transition is a weighted edge to a next state
+type Transition struct {
+	To          string  `json:"to"`
+	Count       int     `json:"count"`
+	Probability float64 `json:"probability,omitempty"`
+}
+
+// UnmarshalJSON also accepts a bare state name, the format used before
+// transitions were counted, and treats it as a single observation
+func (t *Transition) UnmarshalJSON(data []byte) error {
+	var to string
+	if err := json.Unmarshal(data, &to); err == nil {
+		*t = Transition{To: to, Count: 1}
+		return nil
+	}
+	type alias Transition
+	return json.Unmarshal(data, (*alias)(t))
+}
+
+// Snapshot is a serializable copy of a chain, including enough of the
+// random source state to continue the same sequence after a restore
+type Snapshot struct {
+	Seed        int64                   `json:"seed"`
+	Draws       uint64                  `json:"draws"`
+	Transitions map[string][]Transition `json:"transitions"`
 }
 
 // NewMarkovChain creates a new Markov chain
@@ -18,77 +70,340 @@ func NewMarkovChain(seed int64) *MarkovChain {
 	if seed == 0 {
 		seed = time.Now().UnixNano()
 	}
-	return &MarkovChain{
-		transitions: make(map[string][]string),
-		seed:        seed,
-		rng:         rand.New(rand.NewSource(seed)),
+	src := newCountingSource(seed)
+	mc := &MarkovChain{
+		seed: seed,
+		src:  src,
+		rng:  rand.New(src),
+	}
+	for i := range mc.shards {
+		mc.shards[i] = &shard{transitions: make(map[string]*edges)}
+	}
+	return mc
+}
+
+// shardFor returns the shard that owns a source state.
+// The hash is an inlined FNV-1a so the hot path does not allocate.
+func (mc *MarkovChain) shardFor(state string) *shard {
+	h := uint32(2166136261)
+	for i := 0; i < len(state); i++ {
+		h ^= uint32(state[i])
+		h *= 16777619
+	}
+	return mc.shards[h%shardCount]
+}
+
+// forEachState calls fn for every state while holding its shard's read lock.
+// fn must not call back into the chain.
+func (mc *MarkovChain) forEachState(fn func(from string, e *edges)) {
+	for _, sh := range mc.shards {
+		sh.mu.RLock()
+		for from, e := range sh.transitions {
+			fn(from, e)
+		}
+		sh.mu.RUnlock()
+	}
+}
+
+// Restore rebuilds a chain from a snapshot and fast-forwards its random
+// source to where the snapshot was taken
+func Restore(snap Snapshot) *MarkovChain {
+	mc := NewMarkovChain(snap.Seed)
+	for i := uint64(0); i < snap.Draws; i++ {
+		mc.src.Int63()
+	}
+	for from, next := range snap.Transitions {
+		for _, t := range next {
+			mc.AddTransitionCount(from, t.To, t.Count)
+		}
+	}
+	return mc
+}
+
+// Snapshot returns a copy of the chain that can be serialized.
+// Each shard is copied atomically; concurrent writers may land in
+// shards that were already copied.
+func (mc *MarkovChain) Snapshot() Snapshot {
+	transitions := make(map[string][]Transition)
+	mc.forEachState(func(from string, e *edges) {
+		next := make([]Transition, 0, len(e.order))
+		for _, to := range e.order {
+			next = append(next, Transition{To: to, Count: e.counts[to]})
+		}
+		transitions[from] = next
+	})
+
+	mc.rngMu.Lock()
+	draws := mc.src.draws
+	mc.rngMu.Unlock()
+
+	return Snapshot{
+		Seed:        mc.seed,
+		Draws:       draws,
+		Transitions: transitions,
 	}
 }
 
+// Seed returns the seed the chain's random source was created with
+func (mc *MarkovChain) Seed() int64 {
+	return mc.seed
+}
+
 // AddTransition adds a transition from one state to another
 func (mc *MarkovChain) AddTransition(from, to string) {
-	if mc.transitions[from] == nil {
-		mc.transitions[from] = []string{}
+	mc.AddTransitionCount(from, to, 1)
+}
+
+// AddTransitionCount records n observations of a transition at once
+func (mc *MarkovChain) AddTransitionCount(from, to string, n int) {
+	if n <= 0 {
+		return
+	}
+	sh := mc.shardFor(from)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	e := sh.transitions[from]
+	if e == nil {
+		e = &edges{counts: make(map[string]int)}
+		sh.transitions[from] = e
+	}
+	if _, ok := e.counts[to]; !ok {
+		e.order = append(e.order, to)
 	}
-	mc.transitions[from] = append(mc.transitions[from], to)
+	e.counts[to] += n
+	e.total += n
 }
 
 // GetNextState returns the next state based on Markov chain
 func (mc *MarkovChain) GetNextState(current string) (string, error) {
-	nextStates, exists := mc.transitions[current]
-	if !exists || len(nextStates) == 0 {
+	mc.rngMu.Lock()
+	defer mc.rngMu.Unlock()
+	return mc.GetNextStateWithRand(current, mc.rng)
+}
+
+// GetNextStateWithRand picks the next state using the caller's random source,
+// so a walk can be replayed independently of the chain's own seed.
+// States are sampled proportionally to their transition counts.
+// rng itself is not synchronized and must not be shared between goroutines.
+func (mc *MarkovChain) GetNextStateWithRand(current string, rng *rand.Rand) (string, error) {
+	sh := mc.shardFor(current)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	e, exists := sh.transitions[current]
+	if !exists || e.total == 0 {
 		return "", fmt.Errorf("no transitions from state: %s", current)
 	}
 
-	// Randomly select one of the next states
-	idx := mc.rng.Intn(len(nextStates))
-	return nextStates[idx], nil
+	r := rng.Intn(e.total)
+	for _, to := range e.order {
+		r -= e.counts[to]
+		if r < 0 {
+			return to, nil
+		}
+	}
+	return e.order[len(e.order)-1], nil
+}
+
+// HasTransitions reports whether a state has any outgoing transitions
+func (mc *MarkovChain) HasTransitions(state string) bool {
+	sh := mc.shardFor(state)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	e := sh.transitions[state]
+	return e != nil && e.total > 0
+}
+
+// Count returns how many times a transition was observed
+func (mc *MarkovChain) Count(from, to string) int {
+	sh := mc.shardFor(from)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	e := sh.transitions[from]
+	if e == nil {
+		return 0
+	}
+	return e.counts[to]
+}
+
+// Probability returns P(to | from)
+func (mc *MarkovChain) Probability(from, to string) float64 {
+	sh := mc.shardFor(from)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	e := sh.transitions[from]
+	if e == nil || e.total == 0 {
+		return 0
+	}
+	return float64(e.counts[to]) / float64(e.total)
+}
+
+// Distribution returns the normalized distribution over next states
+func (mc *MarkovChain) Distribution(from string) map[string]float64 {
+	dist := make(map[string]float64)
+	sh := mc.shardFor(from)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	e := sh.transitions[from]
+	if e == nil || e.total == 0 {
+		return dist
+	}
+	for to, n := range e.counts {
+		dist[to] = float64(n) / float64(e.total)
+	}
+	return dist
+}
+
+// TopK returns the k most likely next states, most likely first.
+// Ties keep first-seen order; k <= 0 returns all of them.
+func (mc *MarkovChain) TopK(from string, k int) []Transition {
+	sh := mc.shardFor(from)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	e := sh.transitions[from]
+	if e == nil || e.total == 0 {
+		return []Transition{}
+	}
+
+	out := make([]Transition, 0, len(e.order))
+	for _, to := range e.order {
+		n := e.counts[to]
+		out = append(out, Transition{To: to, Count: n, Probability: float64(n) / float64(e.total)})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Count > out[j].Count
+	})
+
+	if k > 0 && k < len(out) {
+		out = out[:k]
+	}
+	return out
 }
 
-// GetAllTransitions returns all transitions
-func (mc *MarkovChain) GetAllTransitions() map[string][]string {
-	return mc.transitions
+// GetAllTransitions returns a copy of all transition counts
+func (mc *MarkovChain) GetAllTransitions() map[string]map[string]int {
+	out := make(map[string]map[string]int)
+	mc.forEachState(func(from string, e *edges) {
+		counts := make(map[string]int, len(e.counts))
+		for to, n := range e.counts {
+			counts[to] = n
+		}
+		out[from] = counts
+	})
+	return out
 }
 
-// GetState returns transitions from a specific state
+// GetState returns the distinct next states of a specific state
 func (mc *MarkovChain) GetState(state string) []string {
-	return mc.transitions[state]
+	sh := mc.shardFor(state)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	e := sh.transitions[state]
+	if e == nil {
+		return nil
+	}
+	return append([]string{}, e.order...)
 }
 
-// RemoveTransition removes a specific transition
+// RemoveTransition removes a single observation of a transition
 func (mc *MarkovChain) RemoveTransition(from, to string) {
-	states, exists := mc.transitions[from]
+	sh := mc.shardFor(from)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	e, exists := sh.transitions[from]
 	if !exists {
 		return
 	}
 
-	for i, s := range states {
-		if s == to {
-			mc.transitions[from] = append(states[:i], states[i+1:]...)
-			break
+	if n, ok := e.counts[to]; ok {
+		e.total--
+		if n > 1 {
+			e.counts[to] = n - 1
+		} else {
+			delete(e.counts, to)
+			for i, s := range e.order {
+				if s == to {
+					e.order = append(e.order[:i], e.order[i+1:]...)
+					break
+				}
+			}
 		}
 	}
 
-	if len(mc.transitions[from]) == 0 {
-		delete(mc.transitions, from)
+	if e.total == 0 {
+		delete(sh.transitions, from)
 	}
 }
 
 // ClearTransitions clears all transitions
 func (mc *MarkovChain) ClearTransitions() {
-	mc.transitions = make(map[string][]string)
+	for _, sh := range mc.shards {
+		sh.mu.Lock()
+		sh.transitions = make(map[string]*edges)
+		sh.mu.Unlock()
+	}
 }
 
 // GetStateCount returns the number of unique states
 func (mc *MarkovChain) GetStateCount() int {
-	return len(mc.transitions)
+	count := 0
+	for _, sh := range mc.shards {
+		sh.mu.RLock()
+		count += len(sh.transitions)
+		sh.mu.RUnlock()
+	}
+	return count
 }
 
-// GetTransitionCount returns the total number of transitions
+// GetTransitionCount returns the total number of observed transitions
 func (mc *MarkovChain) GetTransitionCount() int {
 	count := 0
-	for _, states := range mc.transitions {
-		count += len(states)
-	}
+	mc.forEachState(func(_ string, e *edges) {
+		count += e.total
+	})
 	return count
 }
+
+// GetEdgeCount returns the number of distinct transitions
+func (mc *MarkovChain) GetEdgeCount() int {
+	count := 0
+	mc.forEachState(func(_ string, e *edges) {
+		count += len(e.order)
+	})
+	return count
+}
+
+// countingSource wraps the default source and counts how many values
+// were drawn, so the sequence position can be persisted and replayed.
+// Like rand.Source it is not synchronized; MarkovChain guards it with rngMu.
+type countingSource struct {
+	src   rand.Source64
+	draws uint64
+}
+
+func newCountingSource(seed int64) *countingSource {
+	return &countingSource{src: rand.NewSource(seed).(rand.Source64)}
+}
+
+func (cs *countingSource) Int63() int64 {
+	cs.draws++
+	return cs.src.Int63()
+}
+
+func (cs *countingSource) Uint64() uint64 {
+	cs.draws++
+	return cs.src.Uint64()
+}
+
+func (cs *countingSource) Seed(seed int64) {
+	cs.draws = 0
+	cs.src.Seed(seed)
+}
@@ -1,6 +1,8 @@
 package markov
 
 import (
+	"encoding/json"
+	"math/rand"
 	"testing"
 )
 
@@ -100,3 +102,162 @@ func TestClearTransitions(t *testing.T) {
 		t.Errorf("Expected 0 states after clear, got %d", count)
 	}
 }
+
+func TestGetNextStateWithRandReproducible(t *testing.T) {
+	mc := NewMarkovChain(42)
+	mc.AddTransition("state1", "state2")
+	mc.AddTransition("state1", "state3")
+	mc.AddTransition("state1", "state4")
+
+	walk := func(seed int64) []string {
+		rng := rand.New(rand.NewSource(seed))
+		out := []string{}
+		for i := 0; i < 10; i++ {
+			next, err := mc.GetNextStateWithRand("state1", rng)
+			if err != nil {
+				t.Fatalf("GetNextStateWithRand returned error: %v", err)
+			}
+			out = append(out, next)
+		}
+		return out
+	}
+
+	first, second := walk(7), walk(7)
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("Expected identical walks for the same seed, got %v and %v", first, second)
+		}
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	mc := NewMarkovChain(42)
+	mc.AddTransition("state1", "state2")
+	mc.AddTransition("state1", "state3")
+	mc.AddTransition("state2", "state3")
+
+	for i := 0; i < 5; i++ {
+		mc.GetNextState("state1")
+	}
+
+	restored := Restore(mc.Snapshot())
+
+	if restored.GetTransitionCount() != mc.GetTransitionCount() {
+		t.Errorf("Expected %d transitions, got %d", mc.GetTransitionCount(), restored.GetTransitionCount())
+	}
+
+	for i := 0; i < 10; i++ {
+		want, _ := mc.GetNextState("state1")
+		got, _ := restored.GetNextState("state1")
+		if want != got {
+			t.Fatalf("Draw %d: expected '%s', got '%s'", i, want, got)
+		}
+	}
+}
+
+func TestTransitionCounts(t *testing.T) {
+	mc := NewMarkovChain(42)
+
+	mc.AddTransition("state1", "state2")
+	mc.AddTransition("state1", "state2")
+	mc.AddTransition("state1", "state3")
+
+	if got := mc.Count("state1", "state2"); got != 2 {
+		t.Errorf("Expected count 2, got %d", got)
+	}
+	if got := len(mc.GetState("state1")); got != 2 {
+		t.Errorf("Expected 2 distinct next states, got %d", got)
+	}
+	if got := mc.GetEdgeCount(); got != 2 {
+		t.Errorf("Expected 2 edges, got %d", got)
+	}
+	if got := mc.GetTransitionCount(); got != 3 {
+		t.Errorf("Expected 3 transitions, got %d", got)
+	}
+
+	mc.RemoveTransition("state1", "state2")
+	if got := mc.Count("state1", "state2"); got != 1 {
+		t.Errorf("Expected count 1 after remove, got %d", got)
+	}
+}
+
+func TestProbability(t *testing.T) {
+	mc := NewMarkovChain(42)
+
+	mc.AddTransition("state1", "state2")
+	mc.AddTransition("state1", "state2")
+	mc.AddTransition("state1", "state2")
+	mc.AddTransition("state1", "state3")
+
+	if p := mc.Probability("state1", "state2"); p != 0.75 {
+		t.Errorf("Expected 0.75, got %f", p)
+	}
+	if p := mc.Probability("state1", "missing"); p != 0 {
+		t.Errorf("Expected 0, got %f", p)
+	}
+
+	dist := mc.Distribution("state1")
+	sum := 0.0
+	for _, p := range dist {
+		sum += p
+	}
+	if sum < 0.999 || sum > 1.001 {
+		t.Errorf("Expected distribution to sum to 1, got %f", sum)
+	}
+}
+
+func TestTopK(t *testing.T) {
+	mc := NewMarkovChain(42)
+
+	mc.AddTransition("state1", "state2")
+	mc.AddTransition("state1", "state3")
+	mc.AddTransition("state1", "state3")
+	mc.AddTransition("state1", "state4")
+
+	top := mc.TopK("state1", 2)
+	if len(top) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(top))
+	}
+	if top[0].To != "state3" || top[0].Count != 2 {
+		t.Errorf("Expected 'state3' with count 2, got '%s' with %d", top[0].To, top[0].Count)
+	}
+	if top[1].To != "state2" {
+		t.Errorf("Expected ties in first-seen order, got '%s'", top[1].To)
+	}
+}
+
+func TestSamplingFollowsWeights(t *testing.T) {
+	mc := NewMarkovChain(42)
+
+	for i := 0; i < 9; i++ {
+		mc.AddTransition("state1", "heavy")
+	}
+	mc.AddTransition("state1", "light")
+
+	heavy := 0
+	for i := 0; i < 1000; i++ {
+		next, _ := mc.GetNextState("state1")
+		if next == "heavy" {
+			heavy++
+		}
+	}
+	if heavy < 850 || heavy > 950 {
+		t.Errorf("Expected roughly 900 heavy draws, got %d", heavy)
+	}
+}
+
+func TestTransitionUnmarshalLegacy(t *testing.T) {
+	var snap Snapshot
+	data := []byte(`{"seed":1,"transitions":{"a":["b","b",{"to":"c","count":3}]}}`)
+	if err := json.Unmarshal(data, &snap); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	mc := Restore(snap)
+	if got := mc.Count("a", "b"); got != 2 {
+		t.Errorf("Expected count 2, got %d", got)
+	}
+	if got := mc.Count("a", "c"); got != 3 {
+		t.Errorf("Expected count 3, got %d", got)
+	}
+}
@@ -0,0 +1,123 @@
+package markov
+
+import (
+	"math"
+	"sort"
+)
+
+// Default parameters for StationaryDistribution
+const (
+	DefaultDamping       = 0.85
+	DefaultMaxIterations = 100
+	DefaultTolerance     = 1e-9
+)
+
+// RankResult is the outcome of a stationary distribution computation
+type RankResult struct {
+	Scores     map[string]float64
+	Iterations int
+	Converged  bool
+}
+
+// StationaryDistribution computes the stationary distribution of the chain
+// by power iteration, PageRank style: with probability damping the walk
+// follows a weighted transition, otherwise it teleports to a uniformly
+// random state. Mass on states without outgoing transitions (dangling
+// nodes) is spread uniformly as well. Scores sum to 1.
+func (mc *MarkovChain) StationaryDistribution(damping float64, maxIter int, tol float64) RankResult {
+	if damping <= 0 || damping >= 1 {
+		damping = DefaultDamping
+	}
+	if maxIter <= 0 {
+		maxIter = DefaultMaxIterations
+	}
+	if tol <= 0 {
+		tol = DefaultTolerance
+	}
+
+	// Work on a copy so writers are not blocked for the whole computation
+	rows := make(map[string]*edges)
+	mc.forEachState(func(from string, e *edges) {
+		counts := make(map[string]int, len(e.counts))
+		for to, c := range e.counts {
+			counts[to] = c
+		}
+		rows[from] = &edges{order: append([]string{}, e.order...), counts: counts, total: e.total}
+	})
+
+	nodes := allStates(rows)
+	n := len(nodes)
+	if n == 0 {
+		return RankResult{Scores: map[string]float64{}, Converged: true}
+	}
+
+	index := make(map[string]int, n)
+	for i, s := range nodes {
+		index[s] = i
+	}
+
+	rank := make([]float64, n)
+	for i := range rank {
+		rank[i] = 1 / float64(n)
+	}
+	next := make([]float64, n)
+
+	result := RankResult{}
+	for iter := 1; iter <= maxIter; iter++ {
+		dangling := 0.0
+		for i, s := range nodes {
+			if e := rows[s]; e == nil || e.total == 0 {
+				dangling += rank[i]
+			}
+		}
+
+		base := (1-damping)/float64(n) + damping*dangling/float64(n)
+		for i := range next {
+			next[i] = base
+		}
+		for from, e := range rows {
+			if e.total == 0 {
+				continue
+			}
+			share := damping * rank[index[from]] / float64(e.total)
+			for to, c := range e.counts {
+				next[index[to]] += share * float64(c)
+			}
+		}
+
+		delta := 0.0
+		for i := range rank {
+			delta += math.Abs(next[i] - rank[i])
+		}
+		rank, next = next, rank
+		result.Iterations = iter
+
+		if delta < tol {
+			result.Converged = true
+			break
+		}
+	}
+
+	result.Scores = make(map[string]float64, n)
+	for i, s := range nodes {
+		result.Scores[s] = rank[i]
+	}
+	return result
+}
+
+// allStates returns every state that appears as a source or a target, sorted
+func allStates(rows map[string]*edges) []string {
+	seen := make(map[string]struct{}, len(rows))
+	for from, e := range rows {
+		seen[from] = struct{}{}
+		for _, to := range e.order {
+			seen[to] = struct{}{}
+		}
+	}
+	states := make([]string, 0, len(seen))
+	for s := range seen {
+		states = append(states, s)
+	}
+	sort.Strings(states)
+	return states
+}
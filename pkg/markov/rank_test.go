@@ -0,0 +1,54 @@
+package markov
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStationaryDistributionSumsToOne(t *testing.T) {
+	mc := NewMarkovChain(42)
+	mc.AddTransition("a", "b")
+	mc.AddTransition("b", "c")
+	mc.AddTransition("c", "a")
+	mc.AddTransition("c", "d")
+
+	res := mc.StationaryDistribution(0, 0, 0)
+	if !res.Converged {
+		t.Fatalf("Expected convergence, stopped after %d iterations", res.Iterations)
+	}
+
+	sum := 0.0
+	for _, s := range res.Scores {
+		sum += s
+	}
+	if math.Abs(sum-1) > 1e-6 {
+		t.Errorf("Expected scores to sum to 1, got %f", sum)
+	}
+	if len(res.Scores) != 4 {
+		t.Errorf("Expected 4 scored states, got %d", len(res.Scores))
+	}
+}
+
+func TestStationaryDistributionFavoursHubs(t *testing.T) {
+	mc := NewMarkovChain(42)
+	mc.AddTransition("a", "hub")
+	mc.AddTransition("b", "hub")
+	mc.AddTransition("c", "hub")
+	mc.AddTransition("hub", "a")
+
+	res := mc.StationaryDistribution(0.85, 100, 1e-9)
+	for _, s := range []string{"a", "b", "c"} {
+		if res.Scores["hub"] <= res.Scores[s] {
+			t.Errorf("Expected hub to outrank %s: %f <= %f", s, res.Scores["hub"], res.Scores[s])
+		}
+	}
+}
+
+func TestStationaryDistributionEmpty(t *testing.T) {
+	mc := NewMarkovChain(42)
+
+	res := mc.StationaryDistribution(0.85, 10, 1e-6)
+	if len(res.Scores) != 0 {
+		t.Errorf("Expected no scores, got %d", len(res.Scores))
+	}
+}
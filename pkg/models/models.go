@@ -5,34 +5,53 @@ import (
 	"time"
 )
 
+// Sources records are crawled from. Records without a source predate
+// multi-source crawling and come from GitHub.
+const (
+	SourceGitHub = "github"
+	SourceGitLab = "gitlab"
+	SourceGitea  = "gitea" // also Forgejo
+)
+
 // Contact represents a GitHub user/contributor
 type Contact struct {
-	ID        string    `json:"id"`
-	Login     string    `json:"login"`
-	URL       string    `json:"url"`
-	Avatar    string    `json:"avatar"`
-	Company   string    `json:"company"`
-	Email     string    `json:"email"`
-	Location  string    `json:"location"`
-	Bio       string    `json:"bio"`
-	Hash      string    `json:"hash"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID            string    `json:"id"`
+	Login         string    `json:"login"`
+	URL           string    `json:"url"`
+	Avatar        string    `json:"avatar"`
+	Company       string    `json:"company"`
+	Email         string    `json:"email"`
+	Location      string    `json:"location"`
+	Bio           string    `json:"bio"`
+	Followers     int       `json:"followers"`
+	Contributions int       `json:"contributions"`
+	Source        string    `json:"source"`
+	Hash          string    `json:"hash"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
 // Repo represents a GitHub repository
 type Repo struct {
-	ID             string    `json:"id"`
-	Name           string    `json:"name"`
-	Owner          string    `json:"owner"`
-	URL            string    `json:"url"`
-	Description    string    `json:"description"`
-	Stars          int       `json:"stars"`
-	Language       string    `json:"language"`
-	HasOpenLicense bool      `json:"has_open_license"`
-	License        string    `json:"license"`
-	Hash           string    `json:"hash"`
-	UpdatedAt      time.Time `json:"updated_at"`
-	CreatedAt      time.Time `json:"createdAt"`
+	ID             string             `json:"id"`
+	Name           string             `json:"name"`
+	Owner          string             `json:"owner"`
+	URL            string             `json:"url"`
+	Description    string             `json:"description"`
+	Stars          int                `json:"stars"`
+	Forks          int                `json:"forks"`
+	Watchers       int                `json:"watchers"`
+	Language       string             `json:"language"`
+	HasOpenLicense bool               `json:"has_open_license"`
+	License        string             `json:"license"`
+	Homepage       string             `json:"homepage,omitempty"`
+	Topics         []string           `json:"topics,omitempty"`
+	Languages      map[string]float64 `json:"languages,omitempty"` // percent of code per language
+	Source         string             `json:"source"`
+	Hash           string             `json:"hash"`
+	PushedAt       time.Time          `json:"pushed_at"`
+	LastCommitAt   time.Time          `json:"last_commit_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+	CreatedAt      time.Time          `json:"createdAt"`
 }
 
 // Issue represents a GitHub issue
@@ -46,6 +65,7 @@ type Issue struct {
 	Author    string    `json:"author"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	Source    string    `json:"source"`
 	Hash      string    `json:"hash"`
 	Responses string    `json:"responses"`
 }
@@ -61,9 +81,29 @@ type PullRequest struct {
 	Author    string    `json:"author"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	Source    string    `json:"source"`
 	Hash      string    `json:"hash"`
 }
 
+// FieldChange is a field that differs between two versions of a record.
+// Old or New is null when the field was added or removed.
+type FieldChange struct {
+	Field string          `json:"field"`
+	Old   json.RawMessage `json:"old"`
+	New   json.RawMessage `json:"new"`
+}
+
+// Change is an entry of a record's change log: the version a save
+// replaced and the fields the new version changed
+type Change struct {
+	Key       string          `json:"key"` // record key, e.g. repo:owner/name
+	OldHash   string          `json:"old_hash"`
+	NewHash   string          `json:"new_hash"`
+	Fields    []FieldChange   `json:"fields"`
+	Previous  json.RawMessage `json:"previous"`
+	ChangedAt time.Time       `json:"changed_at"`
+}
+
 // MarkovState represents a state in the Markov chain traversal
 type MarkovState struct {
 	CurrentURL string    `json:"current_url"`
@@ -71,6 +111,83 @@ type MarkovState struct {
 	Timestamp  time.Time `json:"timestamp"`
 }
 
+// Edge kinds stored in the crawl graph
+const (
+	EdgeContributor = "contributor" // contact -> repo
+	EdgeOwns        = "owns"        // owner -> repo
+	EdgeStarred     = "starred"     // contact -> repo
+	EdgeMarkov      = "markov"      // any state -> any state
+	EdgeDependsOn   = "depends_on"  // repo -> repo
+)
+
+// Edge is a relation between two crawled entities
+type Edge struct {
+	Kind      string    `json:"kind"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Weight    float64   `json:"weight"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Dependency is a package a repo's manifest depends on. ResolvedRepo is
+// the crawled repo the package comes from, when known.
+type Dependency struct {
+	RepoID       string    `json:"repo_id"`
+	Ecosystem    string    `json:"ecosystem"` // go, npm, pypi, cargo
+	Name         string    `json:"name"`
+	Version      string    `json:"version,omitempty"`
+	Scope        string    `json:"scope,omitempty"` // "" for runtime, dev, build, peer, optional, indirect
+	Manifest     string    `json:"manifest"`
+	ResolvedRepo string    `json:"resolved_repo,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Package is a package name a repo publishes, read from its manifest
+type Package struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+	RepoID    string `json:"repo_id"`
+	Manifest  string `json:"manifest"`
+}
+
+// RepoManifests records which manifests of a repo were parsed and the
+// packages they declare
+type RepoManifests struct {
+	RepoID    string    `json:"repo_id"`
+	Files     []string  `json:"files"`
+	Packages  []Package `json:"packages"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// CrawlPath is a sequence of states (user logins and owner/name repo ids)
+// the crawler actually traversed, used to train higher-order Markov chains
+type CrawlPath struct {
+	States    []string  `json:"states"`
+	Strategy  string    `json:"strategy"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TrendingRepo is a repository's row on a GitHub trending page
+type TrendingRepo struct {
+	Rank        int      `json:"rank"`
+	RepoID      string   `json:"repo_id"` // owner/name
+	Description string   `json:"description"`
+	Language    string   `json:"language"`
+	Stars       int      `json:"stars"`
+	Forks       int      `json:"forks"`
+	StarsGained int      `json:"stars_gained"` // during the snapshot's period
+	BuiltBy     []string `json:"built_by"`
+}
+
+// TrendingSnapshot is a trending page as it looked on one day
+type TrendingSnapshot struct {
+	Date      string         `json:"date"`     // YYYY-MM-DD (UTC)
+	Language  string         `json:"language"` // "" for all languages
+	Since     string         `json:"since"`    // daily, weekly or monthly
+	Repos     []TrendingRepo `json:"repos"`
+	FetchedAt time.Time      `json:"fetched_at"`
+}
+
 // MarshalJSON for Contact
 func (c Contact) MarshalJSON() ([]byte, error) {
 	type Alias Contact
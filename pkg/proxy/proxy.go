@@ -0,0 +1,212 @@
+// Package proxy builds HTTP transports that send outbound requests through
+// an HTTP, HTTPS or SOCKS5 proxy, with no-proxy lists and extra CA roots.
+package proxy
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Direct is the proxy name that disables proxying for a job
+const Direct = "direct"
+
+// Config describes how outbound requests leave the network. An empty URL
+// means requests go out directly.
+type Config struct {
+	// URL of the proxy: http://, https://, socks5:// or socks5h:// (the
+	// proxy resolves host names)
+	URL string `yaml:"url" json:"url"`
+	// Username and Password override credentials in URL
+	Username string `yaml:"username" json:"username,omitempty"`
+	Password string `yaml:"password" json:"password,omitempty"`
+	// NoProxy lists hosts reached directly: "example.com" (and its
+	// subdomains), ".example.com" (subdomains only), "host:port",
+	// CIDR ranges like "10.0.0.0/8", or "*" for everything
+	NoProxy []string `yaml:"no_proxy" json:"no_proxy,omitempty"`
+	// CABundle is a PEM file of extra root certificates, e.g. for a
+	// TLS-intercepting proxy
+	CABundle string `yaml:"ca_bundle" json:"ca_bundle,omitempty"`
+}
+
+// proxyURL parses URL and applies the credentials
+func (c Config) proxyURL() (*url.URL, error) {
+	if c.URL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q (use http, https, socks5 or socks5h)", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy URL %q has no host", c.URL)
+	}
+	if c.Username != "" {
+		u.User = url.UserPassword(c.Username, c.Password)
+	}
+	return u, nil
+}
+
+// Redacted returns the proxy URL with its password hidden, or "direct"
+func (c Config) Redacted() string {
+	u, err := c.proxyURL()
+	if err != nil || u == nil {
+		return Direct
+	}
+	return u.Redacted()
+}
+
+// Bypass reports whether requests to host ("name" or "name:port") skip
+// the proxy
+func (c Config) Bypass(host string) bool {
+	name, port, err := net.SplitHostPort(host)
+	if err != nil {
+		name, port = host, ""
+	}
+	name = strings.ToLower(strings.Trim(name, "[]"))
+	ip := net.ParseIP(name)
+
+	for _, entry := range c.NoProxy {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+		case entry == "*":
+			return true
+		case strings.Contains(entry, "/"):
+			if _, cidr, err := net.ParseCIDR(entry); err == nil && ip != nil && cidr.Contains(ip) {
+				return true
+			}
+		default:
+			entryName, entryPort, err := net.SplitHostPort(entry)
+			if err != nil {
+				entryName, entryPort = entry, ""
+			}
+			if entryPort != "" && entryPort != port {
+				continue
+			}
+			if strings.HasPrefix(entryName, ".") {
+				if strings.HasSuffix(name, entryName) {
+					return true
+				}
+			} else if name == entryName || strings.HasSuffix(name, "."+entryName) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Transport returns a transport with http.DefaultTransport's settings that
+// proxies requests as configured
+func (c Config) Transport() (*http.Transport, error) {
+	u, err := c.proxyURL()
+	if err != nil {
+		return nil, err
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = nil
+	if u != nil {
+		t.Proxy = func(req *http.Request) (*url.URL, error) {
+			if c.Bypass(req.URL.Host) {
+				return nil, nil
+			}
+			return u, nil
+		}
+	}
+
+	if c.CABundle != "" {
+		pem, err := os.ReadFile(c.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil || roots == nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", c.CABundle)
+		}
+		t.TLSClientConfig = &tls.Config{RootCAs: roots}
+	}
+	return t, nil
+}
+
+// Settings is a proxy configuration file: named proxies that crawl jobs
+// pick from, plus no-proxy hosts and CA roots shared by all of them
+type Settings struct {
+	Default  string            `yaml:"default" json:"default"`
+	NoProxy  []string          `yaml:"no_proxy" json:"no_proxy,omitempty"`
+	CABundle string            `yaml:"ca_bundle" json:"ca_bundle,omitempty"`
+	Proxies  map[string]Config `yaml:"proxies" json:"proxies"`
+}
+
+// ParseSettings reads proxy settings from YAML or JSON and checks every proxy
+func ParseSettings(data []byte) (*Settings, error) {
+	var s Settings
+	var err error
+	// JSON is mostly valid YAML, but tab indentation is not
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		err = json.Unmarshal(data, &s)
+	} else {
+		err = yaml.Unmarshal(data, &s)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse proxy settings: %w", err)
+	}
+	if s.Default != "" && s.Default != Direct {
+		if _, ok := s.Proxies[s.Default]; !ok {
+			return nil, fmt.Errorf("default proxy %q is not defined", s.Default)
+		}
+	}
+	for name := range s.Proxies {
+		cfg, _ := s.Get(name)
+		if _, err := cfg.proxyURL(); err != nil {
+			return nil, fmt.Errorf("proxy %s: %w", name, err)
+		}
+	}
+	return &s, nil
+}
+
+// LoadSettings reads proxy settings from a file
+func LoadSettings(path string) (*Settings, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read proxy settings: %w", err)
+	}
+	return ParseSettings(data)
+}
+
+// Get returns the proxy called name ("" for the default, "direct" for no
+// proxy) with the shared no-proxy hosts and CA bundle applied
+func (s *Settings) Get(name string) (Config, bool) {
+	if name == "" {
+		name = s.Default
+	}
+	var cfg Config
+	if name != "" && name != Direct {
+		var ok bool
+		if cfg, ok = s.Proxies[name]; !ok {
+			return Config{}, false
+		}
+	}
+	cfg.NoProxy = append(append([]string{}, s.NoProxy...), cfg.NoProxy...)
+	if cfg.CABundle == "" {
+		cfg.CABundle = s.CABundle
+	}
+	return cfg, true
+}
@@ -0,0 +1,178 @@
+package proxy
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBypass(t *testing.T) {
+	cfg := Config{NoProxy: []string{"example.com", ".internal", "10.0.0.0/8", "localhost:8080"}}
+	cases := map[string]bool{
+		"example.com":       true,
+		"api.example.com":   true,
+		"notexample.com":    false,
+		"internal":          false,
+		"git.internal:443":  true,
+		"10.1.2.3":          true,
+		"11.1.2.3":          false,
+		"localhost:8080":    true,
+		"localhost:9090":    false,
+		"github.com":        false,
+		"[::1]:443":         false,
+		"EXAMPLE.COM:443":   true,
+		"api.github.com:80": false,
+	}
+	for host, want := range cases {
+		if got := cfg.Bypass(host); got != want {
+			t.Errorf("Bypass(%q): expected %v, got %v", host, want, got)
+		}
+	}
+	if !(Config{NoProxy: []string{"*"}}).Bypass("github.com") {
+		t.Errorf("Expected * to bypass every host")
+	}
+}
+
+func TestTransportUsesProxyWithAuth(t *testing.T) {
+	var seenURL, seenAuth string
+	proxySrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenURL = r.URL.String()
+		seenAuth = r.Header.Get("Proxy-Authorization")
+		io.WriteString(w, "via proxy")
+	}))
+	defer proxySrv.Close()
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "direct")
+	}))
+	defer target.Close()
+	targetHost := strings.TrimPrefix(target.URL, "http://")
+
+	tr, err := Config{
+		URL:      proxySrv.URL,
+		Username: "crawler",
+		Password: "s3cret",
+		NoProxy:  []string{targetHost},
+	}.Transport()
+	if err != nil {
+		t.Fatalf("Transport failed: %v", err)
+	}
+	client := &http.Client{Transport: tr}
+
+	resp, err := client.Get("http://github.example/octocat")
+	if err != nil {
+		t.Fatalf("GET through proxy failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "via proxy" || seenURL != "http://github.example/octocat" {
+		t.Errorf("Expected request for http://github.example/octocat via proxy, got %q (%s)", seenURL, body)
+	}
+	wantAuth := "Basic " + base64.StdEncoding.EncodeToString([]byte("crawler:s3cret"))
+	if seenAuth != wantAuth {
+		t.Errorf("Expected Proxy-Authorization %q, got %q", wantAuth, seenAuth)
+	}
+
+	resp, err = client.Get(target.URL)
+	if err != nil {
+		t.Fatalf("GET of no-proxy host failed: %v", err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "direct" {
+		t.Errorf("Expected no-proxy host to be reached directly, got %q", body)
+	}
+}
+
+func TestSocksProxyURL(t *testing.T) {
+	tr, err := Config{URL: "socks5h://proxy.local:1080", Username: "u", Password: "p"}.Transport()
+	if err != nil {
+		t.Fatalf("Transport failed: %v", err)
+	}
+	req, _ := http.NewRequest("GET", "https://api.github.com/users/octocat", nil)
+	u, err := tr.Proxy(req)
+	if err != nil || u == nil {
+		t.Fatalf("Expected a proxy URL, got %v, %v", u, err)
+	}
+	if u.Scheme != "socks5h" || u.User.String() != "u:p" {
+		t.Errorf("Expected socks5h proxy with credentials, got %s", u.Redacted())
+	}
+
+	if _, err := (Config{URL: "ftp://proxy.local"}).Transport(); err == nil {
+		t.Errorf("Expected an error for an ftp proxy")
+	}
+}
+
+func TestCABundle(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	tr, _ := Config{}.Transport()
+	if _, err := (&http.Client{Transport: tr}).Get(srv.URL); err == nil {
+		t.Fatalf("Expected an unknown authority error without the CA bundle")
+	}
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(bundle, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tr, err := Config{CABundle: bundle}.Transport()
+	if err != nil {
+		t.Fatalf("Transport failed: %v", err)
+	}
+	resp, err := (&http.Client{Transport: tr}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Expected the CA bundle to be trusted: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestSettings(t *testing.T) {
+	s, err := ParseSettings([]byte(`
+default: corp
+no_proxy: ["localhost"]
+proxies:
+  corp:
+    url: http://proxy.corp:3128
+    username: crawler
+    password: s3cret
+    no_proxy: ["git.corp"]
+  tor:
+    url: socks5h://127.0.0.1:9050
+`))
+	if err != nil {
+		t.Fatalf("ParseSettings failed: %v", err)
+	}
+
+	cfg, ok := s.Get("")
+	if !ok || cfg.URL != "http://proxy.corp:3128" {
+		t.Fatalf("Expected default proxy corp, got %+v", cfg)
+	}
+	if !cfg.Bypass("localhost") || !cfg.Bypass("git.corp") {
+		t.Errorf("Expected shared and own no_proxy hosts, got %v", cfg.NoProxy)
+	}
+	if r := cfg.Redacted(); r != (&url.URL{Scheme: "http", User: url.UserPassword("crawler", "xxxxx"), Host: "proxy.corp:3128"}).String() {
+		t.Errorf("Expected password to be redacted, got %s", r)
+	}
+
+	if cfg, ok := s.Get(Direct); !ok || cfg.URL != "" {
+		t.Errorf("Expected direct to have no proxy, got %+v", cfg)
+	}
+	if _, ok := s.Get("missing"); ok {
+		t.Errorf("Expected unknown proxy name to be rejected")
+	}
+
+	if _, err := ParseSettings([]byte(`{"default": "nope", "proxies": {}}`)); err == nil {
+		t.Errorf("Expected an error for an undefined default proxy")
+	}
+}
@@ -0,0 +1,102 @@
+package scraper
+
+import (
+	"flag"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"Fyne-on/pkg/scraper/scrapertest"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files from the current parser output")
+
+// newFixtureScraper returns a scraper reading from a server that replays
+// testdata/fixtures, without rate limiting
+func newFixtureScraper(t *testing.T) *HTTPScraper {
+	t.Helper()
+	srv, err := scrapertest.NewServer(filepath.Join("testdata", "fixtures"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(srv.Close)
+
+	s := NewHTTPScraper(5)
+	s.SetBaseURL(srv.URL)
+	s.SetHostLimits(4, 0, 1)
+	return s
+}
+
+func TestGoldenTrendingDevelopers(t *testing.T) {
+	got, err := newFixtureScraper(t).FetchTrendingDevelopers()
+	if err != nil {
+		t.Fatalf("FetchTrendingDevelopers failed: %v", err)
+	}
+	scrapertest.Golden(t, filepath.Join("testdata", "golden", "trending_developers.json"), got, *update)
+}
+
+func TestGoldenTrendingRepos(t *testing.T) {
+	got, err := newFixtureScraper(t).FetchTrendingRepos("Go", "weekly")
+	if err != nil {
+		t.Fatalf("FetchTrendingRepos failed: %v", err)
+	}
+	scrapertest.Golden(t, filepath.Join("testdata", "golden", "trending_go_weekly.json"), got, *update)
+}
+
+func TestTrendingReposInvalidPeriod(t *testing.T) {
+	if _, err := NewHTTPScraper(5).FetchTrendingRepos("", "yearly"); err == nil {
+		t.Errorf("Expected an error for period yearly")
+	}
+}
+
+func TestGoldenUserRepos(t *testing.T) {
+	got, err := newFixtureScraper(t).FetchUserRepos("octocat")
+	if err != nil {
+		t.Fatalf("FetchUserRepos failed: %v", err)
+	}
+	scrapertest.Golden(t, filepath.Join("testdata", "golden", "user_repos_octocat.json"), got, *update)
+}
+
+func TestGoldenUserStarred(t *testing.T) {
+	got, err := newFixtureScraper(t).FetchUserStarred("octocat")
+	if err != nil {
+		t.Fatalf("FetchUserStarred failed: %v", err)
+	}
+	scrapertest.Golden(t, filepath.Join("testdata", "golden", "user_stars_octocat.json"), got, *update)
+}
+
+func TestUserReposMaxPages(t *testing.T) {
+	s := newFixtureScraper(t)
+	s.SetMaxPages(1)
+	got, err := s.FetchUserRepos("octocat")
+	if err != nil {
+		t.Fatalf("FetchUserRepos failed: %v", err)
+	}
+	if len(got) != 3 {
+		t.Errorf("Expected 3 repos from the first page, got %d", len(got))
+	}
+}
+
+func TestNextPageURLResolvesRelativeLinks(t *testing.T) {
+	s := NewHTTPScraper(5)
+	s.SetBaseURL("http://mirror.test")
+	pageURL := "http://mirror.test/octocat?tab=repositories"
+	for href, want := range map[string]string{
+		"?page=2&tab=repositories":          "http://mirror.test/octocat?page=2&tab=repositories",
+		"/octocat?page=2":                   "http://mirror.test/octocat?page=2",
+		"https://github.com/octocat?page=2": "http://mirror.test/octocat?page=2",
+		"repositories?page=2":               "http://mirror.test/repositories?page=2",
+	} {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<a rel="next" href="` + href + `">Next</a>`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		run := s.selectors.Run(pageURL)
+		if got := s.nextPageURL(run, doc, pageURL, 1, 0); got != want {
+			t.Errorf("Expected %s for %q, got %s", want, href, got)
+		}
+		run.Done()
+	}
+}
@@ -2,27 +2,276 @@ package scraper
 
 import (
 	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"Fyne-on/pkg/httpcache"
+
 	"github.com/PuerkitoBio/goquery"
 )
 
-// HTTPScraper provides web scraping utilities
+// DefaultBaseURL is the site the scraper reads from
+const DefaultBaseURL = "https://github.com"
+
+// DefaultMaxPages is how many pages of a paginated list are read
+const DefaultMaxPages = 10
+
+// fullListPage is the number of cards GitHub shows on a full list page
+const fullListPage = 30
+
+// DefaultUserAgent identifies the scraper to servers and in robots.txt groups
+const DefaultUserAgent = "Fyne-on-Crawler/1.0"
+
+// robotsTTL is how long a fetched robots.txt is trusted
+const robotsTTL = 24 * time.Hour
+
+// HTTPScraper provides web scraping utilities.
+// Every request is checked against the host's robots.txt, spaced by its
+// Crawl-delay, limited per host and retried on 429/5xx responses.
+// Requests may be made concurrently, also while SetUserAgent,
+// SetRetryPolicy, SetHeader or SetHostLimits run; the other setters
+// configure the scraper before it is shared.
 type HTTPScraper struct {
-	client *http.Client
+	client    *http.Client
+	baseURL   string
+	robots    *RobotsCache
+	limits    *limiters
+	selectors *Selectors
+	maxPages  int
+	offline   bool
+
+	// configMu guards userAgent and retry
+	configMu  sync.RWMutex
+	userAgent string
+	retry     RetryPolicy
+
+	headersMu sync.RWMutex
+	headers   http.Header
 }
 
 func NewHTTPScraper(timeoutSec int) *HTTPScraper {
+	client := &http.Client{Timeout: time.Duration(timeoutSec) * time.Second}
 	return &HTTPScraper{
-		client: &http.Client{Timeout: time.Duration(timeoutSec) * time.Second},
+		client:    client,
+		baseURL:   DefaultBaseURL,
+		userAgent: DefaultUserAgent,
+		robots:    NewRobotsCache(client, robotsTTL),
+		limits:    newLimiters(DefaultHostConcurrency, DefaultRatePerSecond, DefaultBurst),
+		selectors: NewSelectors(),
+		maxPages:  DefaultMaxPages,
+		retry: RetryPolicy{
+			MaxRetries: DefaultMaxRetries,
+			BaseDelay:  DefaultRetryBaseDelay,
+			MaxDelay:   DefaultRetryMaxDelay,
+		},
+		headers: http.Header{
+			"Accept":          {"text/html,application/xhtml+xml"},
+			"Accept-Language": {"en-US,en;q=0.8"},
+		},
+	}
+}
+
+// Clone returns a scraper with the settings of s that shares its per-host
+// limits, selector profile and cache. Its settings and transport change
+// without affecting s, e.g. to give one crawl job its own proxy; it keeps
+// its own robots.txt cache, fetched through its own transport.
+func (s *HTTPScraper) Clone() *HTTPScraper {
+	client := &http.Client{Timeout: s.client.Timeout, Transport: s.client.Transport}
+	s.configMu.RLock()
+	ua, retry := s.userAgent, s.retry
+	s.configMu.RUnlock()
+	s.headersMu.RLock()
+	headers := s.headers.Clone()
+	s.headersMu.RUnlock()
+
+	return &HTTPScraper{
+		client:    client,
+		baseURL:   s.baseURL,
+		userAgent: ua,
+		robots:    NewRobotsCache(client, robotsTTL),
+		limits:    s.limits,
+		selectors: s.selectors,
+		maxPages:  s.maxPages,
+		offline:   s.offline,
+		retry:     retry,
+		headers:   headers,
+	}
+}
+
+// SetSelectors replaces the selector profile used by every parser
+func (s *HTTPScraper) SetSelectors(sel *Selectors) {
+	if sel != nil {
+		s.selectors = sel
 	}
 }
 
+// Selectors returns the selector profile and its match diagnostics
+func (s *HTTPScraper) Selectors() *Selectors {
+	return s.selectors
+}
+
+// SetHostLimits sets how many requests may be in flight per host and the
+// sustained request rate per host. rate <= 0 disables rate limiting.
+func (s *HTTPScraper) SetHostLimits(concurrency int, rate float64, burst int) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	s.limits.reset(concurrency, rate, burst)
+}
+
+// SetRetryPolicy sets how 429 and 5xx responses are retried
+func (s *HTTPScraper) SetRetryPolicy(p RetryPolicy) {
+	if p.MaxRetries < 0 {
+		p.MaxRetries = 0
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = DefaultRetryBaseDelay
+	}
+	if p.MaxDelay < p.BaseDelay {
+		p.MaxDelay = p.BaseDelay
+	}
+	s.configMu.Lock()
+	s.retry = p
+	s.configMu.Unlock()
+}
+
+// SetHeader sets a header sent with every request. An empty value removes it.
+func (s *HTTPScraper) SetHeader(key, value string) {
+	s.headersMu.Lock()
+	defer s.headersMu.Unlock()
+	if value == "" {
+		s.headers.Del(key)
+		return
+	}
+	s.headers.Set(key, value)
+}
+
+// SetBaseURL points the scraper at another host, e.g. a fixture server.
+// Scraped URLs still refer to github.com.
+func (s *HTTPScraper) SetBaseURL(u string) {
+	if u != "" {
+		s.baseURL = strings.TrimSuffix(u, "/")
+	}
+}
+
+// BaseURL returns the site the scraper reads from
+func (s *HTTPScraper) BaseURL() string {
+	return s.baseURL
+}
+
+// SetCache serves responses from an on-disk cache (nil disables it). In
+// offline mode only cached pages are read and rate limits and Crawl-delay
+// are skipped, since nothing reaches the network.
+func (s *HTTPScraper) SetCache(c *httpcache.Cache, offline bool) {
+	s.client.Transport = httpcache.Wrap(s.client.Transport, c, offline)
+	s.offline = c != nil && offline
+}
+
+// SetTransport changes how requests reach the network, e.g. through a
+// proxy. A cache set with SetCache stays in front of it.
+func (s *HTTPScraper) SetTransport(rt http.RoundTripper) {
+	s.client.Transport = httpcache.Rebase(s.client.Transport, rt)
+}
+
+// SetMaxPages limits how many pages of a paginated list are read
+func (s *HTTPScraper) SetMaxPages(n int) {
+	if n > 0 {
+		s.maxPages = n
+	}
+}
+
+// SetUserAgent changes the User-Agent sent with requests and matched
+// against robots.txt
+func (s *HTTPScraper) SetUserAgent(ua string) {
+	if ua != "" {
+		s.configMu.Lock()
+		s.userAgent = ua
+		s.configMu.Unlock()
+	}
+}
+
+// UserAgent returns the User-Agent used for requests
+func (s *HTTPScraper) UserAgent() string {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.userAgent
+}
+
+// get performs a GET after checking robots.txt and waiting out Crawl-delay.
+// It returns a *DisallowedError if robots.txt forbids the URL.
+func (s *HTTPScraper) get(rawURL string) (*http.Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// The settings a request starts with apply to all its attempts
+	s.configMu.RLock()
+	ua, retry := s.userAgent, s.retry
+	s.configMu.RUnlock()
+
+	robots := s.robots.Get(u, ua)
+	if !robots.Allowed(ua, u.RequestURI()) {
+		return nil, &DisallowedError{URL: rawURL, UserAgent: ua}
+	}
+
+	limit := s.limits.get(u.Host)
+	limit.acquire()
+
+	for attempt := 0; ; attempt++ {
+		if !s.offline {
+			s.robots.Wait(u, robots.CrawlDelay(ua))
+			limit.wait()
+		}
+
+		req, err := http.NewRequest("GET", rawURL, nil)
+		if err != nil {
+			limit.release()
+			return nil, err
+		}
+		s.setHeaders(req, ua)
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			limit.release()
+			return nil, err
+		}
+
+		if !retryable(resp.StatusCode) || attempt >= retry.MaxRetries {
+			resp.Body = &releasingBody{ReadCloser: resp.Body, release: limit.release}
+			return resp, nil
+		}
+
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+
+		wait := retry.backoff(attempt)
+		if ra, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok && ra > wait {
+			wait = ra
+		}
+		log.Printf("%s returned %d, retrying in %v (%d/%d)", rawURL, resp.StatusCode, wait, attempt+1, retry.MaxRetries)
+		time.Sleep(wait)
+	}
+}
+
+func (s *HTTPScraper) setHeaders(req *http.Request, ua string) {
+	s.headersMu.RLock()
+	for k, v := range s.headers {
+		req.Header[k] = append([]string(nil), v...)
+	}
+	s.headersMu.RUnlock()
+	req.Header.Set("User-Agent", ua)
+}
+
 // FetchDocument загружает страницу и возвращает goquery.Document
 func (s *HTTPScraper) FetchDocument(url string) (*goquery.Document, error) {
-	resp, err := s.client.Get(url)
+	resp, err := s.get(url)
 	if err != nil {
 		return nil, err
 	}
@@ -39,13 +288,29 @@ func (s *HTTPScraper) FetchDocument(url string) (*goquery.Document, error) {
 	return doc, nil
 }
 
+// FetchRaw downloads a page under the same robots.txt, rate limit and
+// retry rules as FetchDocument
+func (s *HTTPScraper) FetchRaw(url string) ([]byte, error) {
+	resp, err := s.get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
 // RepositoryIssue represents a repository issue
 type RepositoryIssue struct {
+	Number    int
 	Title     string
 	URL       string
 	State     string // open, closed
 	Author    string
-	CreatedAt string
+	CreatedAt string // RFC 3339
 }
 
 // Contributor represents a repository contributor
@@ -58,22 +323,20 @@ type Contributor struct {
 
 // FetchTrendingDevelopers scrapes GitHub Trending Developers (HTML)
 func (hs *HTTPScraper) FetchTrendingDevelopers() ([]string, error) {
-	resp, err := hs.client.Get("https://github.com/trending/developers")
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	url := hs.baseURL + "/trending/developers"
+	doc, err := hs.FetchDocument(url)
 	if err != nil {
 		return nil, err
 	}
+	run := hs.selectors.Run(url)
+	defer run.Done()
 
 	out := []string{}
-	doc.Find("article h1 a[href]").Each(func(i int, s *goquery.Selection) {
+	run.Find(doc.Selection, "trending.developer").Each(func(i int, s *goquery.Selection) {
 		if href, ok := s.Attr("href"); ok && strings.HasPrefix(href, "/") {
 			login := strings.TrimPrefix(href, "/")
-			if login != "" {
+			// Each developer card also links its popular repo as owner/name
+			if login != "" && !strings.Contains(login, "/") {
 				out = append(out, login)
 			}
 		}
@@ -81,74 +344,124 @@ func (hs *HTTPScraper) FetchTrendingDevelopers() ([]string, error) {
 	return unique(out), nil
 }
 
-// FetchUserRepos scrapes a user's repositories page (HTML)
-func (hs *HTTPScraper) FetchUserRepos(username string) ([]struct {
+// UserRepo is a repository card from a user's repositories or stars tab
+type UserRepo struct {
 	Name        string
 	Owner       string
 	URL         string
 	Description string
 	Language    string
 	Stars       int
-}, error) {
-	url := fmt.Sprintf("https://github.com/%s?tab=repositories", username)
-	resp, err := hs.client.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+}
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
-	if err != nil {
-		return nil, err
-	}
+// repoListing names the selector fields of one kind of repo list page
+type repoListing struct {
+	link, card, description, language, stars string
+}
 
-	repos := []struct {
-		Name        string
-		Owner       string
-		URL         string
-		Description string
-		Language    string
-		Stars       int
-	}{}
-
-	// NOTE: GitHub markup can change; selectors are best-effort.
-	// Try anchors inside repo cards
-	doc.Find("h3 a[href*='/'']").Each(func(i int, s *goquery.Selection) {
-		href, ok := s.Attr("href")
-		if !ok || !strings.HasPrefix(href, "/") {
-			return
-		}
-		parts := strings.Split(strings.TrimPrefix(href, "/"), "/")
-		if len(parts) < 2 {
-			return
+var (
+	userReposListing = repoListing{"user_repos.link", "user_repos.card", "user_repos.description", "user_repos.language", "user_repos.stars"}
+	starredListing   = repoListing{"starred_repos.link", "starred_repos.card", "user_repos.description", "user_repos.language", "user_repos.stars"}
+)
+
+// FetchUserRepos scrapes a user's repositories tab (HTML), following
+// pagination up to the page limit
+func (hs *HTTPScraper) FetchUserRepos(username string) ([]UserRepo, error) {
+	return hs.fetchRepoList(fmt.Sprintf("%s/%s?tab=repositories", hs.baseURL, username), userReposListing)
+}
+
+// FetchUserStarred scrapes the repositories a user starred (?tab=stars),
+// following pagination up to the page limit
+func (hs *HTTPScraper) FetchUserStarred(username string) ([]UserRepo, error) {
+	return hs.fetchRepoList(fmt.Sprintf("%s/%s?tab=stars", hs.baseURL, username), starredListing)
+}
+
+// fetchRepoList reads repo cards page by page. It follows the "Next" link
+// and, where a full page has none, tries the following page= number. An
+// error after the first page ends the walk but keeps what was read.
+func (hs *HTTPScraper) fetchRepoList(firstURL string, listing repoListing) ([]UserRepo, error) {
+	repos := []UserRepo{}
+	seen := map[string]bool{}
+	visited := map[string]bool{}
+
+	pageURL := firstURL
+	for page := 1; page <= hs.maxPages && pageURL != "" && !visited[pageURL]; page++ {
+		visited[pageURL] = true
+
+		doc, err := hs.FetchDocument(pageURL)
+		if err != nil {
+			if page == 1 {
+				return nil, err
+			}
+			log.Printf("Stopping pagination at %s: %v", pageURL, err)
+			break
 		}
-		owner := parts[0]
-		name := parts[1]
-		fullURL := "https://github.com/" + owner + "/" + name
-
-		desc := strings.TrimSpace(s.Closest("article").Find("p").First().Text())
-		lang := strings.TrimSpace(s.Closest("article").Find("[itemprop='programmingLanguage']").First().Text())
-		// Stars often not present on list; default to 0
-		repos = append(repos, struct {
-			Name        string
-			Owner       string
-			URL         string
-			Description string
-			Language    string
-			Stars       int
-		}{
-			Name:        name,
-			Owner:       owner,
-			URL:         fullURL,
-			Description: desc,
-			Language:    lang,
-			Stars:       0,
+
+		run := hs.selectors.Run(pageURL)
+		found := 0
+		// NOTE: GitHub markup can change; selectors come from the profile
+		run.Find(doc.Selection, listing.link).Each(func(i int, s *goquery.Selection) {
+			href, ok := s.Attr("href")
+			if !ok || !strings.HasPrefix(href, "/") {
+				return
+			}
+			parts := strings.Split(strings.TrimPrefix(href, "/"), "/")
+			if len(parts) < 2 {
+				return
+			}
+			owner := parts[0]
+			name := parts[1]
+			if seen[owner+"/"+name] {
+				return
+			}
+			seen[owner+"/"+name] = true
+
+			card := run.Closest(s, listing.card)
+			stars, _ := parseCount(run.Find(card, listing.stars).First().Text())
+			repos = append(repos, UserRepo{
+				Name:        name,
+				Owner:       owner,
+				URL:         "https://github.com/" + owner + "/" + name,
+				Description: strings.TrimSpace(run.Find(card, listing.description).First().Text()),
+				Language:    strings.TrimSpace(run.Find(card, listing.language).First().Text()),
+				Stars:       stars,
+			})
+			found++
 		})
-	})
+		next := hs.nextPageURL(run, doc, pageURL, page, found)
+		run.Done()
 
+		if found == 0 {
+			break
+		}
+		pageURL = next
+	}
 	return repos, nil
 }
 
+// nextPageURL returns the URL of the page after pageURL, or "" if there is
+// none. The "Next" link is resolved against pageURL, so "?page=2" keeps
+// the page's path, and then rebased onto the scraper's base URL.
+func (hs *HTTPScraper) nextPageURL(run *SelectorRun, doc *goquery.Document, pageURL string, page, found int) string {
+	if href, ok := run.Find(doc.Selection, "pagination.next").First().Attr("href"); ok {
+		base, err := url.Parse(pageURL)
+		if u, err2 := url.Parse(href); err == nil && err2 == nil {
+			return hs.baseURL + base.ResolveReference(u).RequestURI()
+		}
+	}
+	if found < fullListPage {
+		return ""
+	}
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+	q := u.Query()
+	q.Set("page", strconv.Itoa(page+1))
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
 // Close is a no-op for HTTP scraper
 func (hs *HTTPScraper) Close() error {
 	return nil
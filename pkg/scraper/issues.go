@@ -0,0 +1,109 @@
+package scraper
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// FetchRepoIssues scrapes one page of a repository's issue list.
+// state is "open" or "closed"; an empty result means there are no more pages.
+func (hs *HTTPScraper) FetchRepoIssues(owner, name, state string, page int) ([]RepositoryIssue, error) {
+	q := url.QueryEscape("is:issue is:" + state)
+	pageURL := fmt.Sprintf("%s/%s/%s/issues?q=%s&page=%d", hs.baseURL, owner, name, q, page)
+	doc, err := hs.FetchDocument(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	run := hs.selectors.Run(pageURL)
+	defer run.Done()
+	return parseIssueList(doc, run, owner, name, state), nil
+}
+
+// parseIssueList reads issue rows from both the classic server-rendered
+// list and the newer list markup
+func parseIssueList(doc *goquery.Document, run *SelectorRun, owner, name, state string) []RepositoryIssue {
+	prefix := "/" + owner + "/" + name + "/issues/"
+	seen := map[int]bool{}
+	issues := []RepositoryIssue{}
+
+	run.Find(doc.Selection, "issues.link").Each(func(i int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		href = strings.TrimPrefix(href, "https://github.com")
+		if !strings.HasPrefix(href, prefix) {
+			return
+		}
+		number, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(href, prefix), "/"))
+		if err != nil || seen[number] {
+			return
+		}
+		seen[number] = true
+
+		row := run.Closest(s, "issues.row")
+		author := strings.TrimSpace(run.Find(row, "issues.author").First().Text())
+		createdAt, _ := run.Find(row, "issues.created").First().Attr("datetime")
+
+		issues = append(issues, RepositoryIssue{
+			Number:    number,
+			Title:     strings.TrimSpace(s.Text()),
+			URL:       "https://github.com" + href,
+			State:     state,
+			Author:    author,
+			CreatedAt: createdAt,
+		})
+	})
+	return issues
+}
+
+// FetchContributors scrapes the contributors shown for a repository.
+// The HTML pages do not include commit counts, so Contributions is 0.
+func (hs *HTTPScraper) FetchContributors(owner, name string) ([]Contributor, error) {
+	listURL := fmt.Sprintf("%s/%s/%s/contributors_list?deferred=true", hs.baseURL, owner, name)
+	doc, err := hs.FetchDocument(listURL)
+	if err == nil {
+		run := hs.selectors.Run(listURL)
+		out := parseContributors(doc, run)
+		run.Done()
+		if len(out) > 0 {
+			return out, nil
+		}
+	}
+	// The sidebar of the landing page lists the top contributors too
+	pageURL := fmt.Sprintf("%s/%s/%s", hs.baseURL, owner, name)
+	doc, err = hs.FetchDocument(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	run := hs.selectors.Run(pageURL)
+	defer run.Done()
+	return parseContributors(doc, run), nil
+}
+
+func parseContributors(doc *goquery.Document, run *SelectorRun) []Contributor {
+	seen := map[string]bool{}
+	out := []Contributor{}
+
+	run.Find(doc.Selection, "contributors.link").Each(func(i int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		login := strings.Trim(strings.TrimPrefix(href, "https://github.com"), "/")
+		if login == "" || strings.Contains(login, "/") || seen[login] {
+			return
+		}
+		img := s.Find("img")
+		if img.Length() == 0 {
+			// Only avatar links belong to the contributor list
+			return
+		}
+		seen[login] = true
+		avatar, _ := img.Attr("src")
+		out = append(out, Contributor{
+			Login:  login,
+			URL:    "https://github.com/" + login,
+			Avatar: avatar,
+		})
+	})
+	return out
+}
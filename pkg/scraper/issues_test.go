@@ -0,0 +1,62 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const testIssueList = `<html><body>
+<div class="js-issue-row" id="issue_42">
+  <a class="Link--primary js-navigation-open" href="/octo/demo/issues/42" data-hovercard-type="issue">Crash on start</a>
+  <span class="opened-by">#42 opened <relative-time datetime="2024-03-02T08:00:00Z">Mar 2</relative-time> by <a class="Link--muted" href="/alice">alice</a></span>
+</div>
+<div class="js-issue-row" id="issue_41">
+  <a class="Link--primary js-navigation-open" href="/octo/demo/issues/41" data-hovercard-type="issue">Docs typo</a>
+  <span class="opened-by">#41 opened <relative-time datetime="2024-03-01T08:00:00Z">Mar 1</relative-time> by <a class="Link--muted" href="/bob">bob</a></span>
+</div>
+<a href="/octo/demo/issues/new">New issue</a>
+<a href="/other/repo/issues/7" data-hovercard-type="issue">Cross-reference</a>
+</body></html>`
+
+func TestParseIssueList(t *testing.T) {
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(testIssueList))
+	issues := parseIssueList(doc, NewSelectors().Run("test"), "octo", "demo", "open")
+
+	if len(issues) != 2 {
+		t.Fatalf("Expected 2 issues, got %d: %+v", len(issues), issues)
+	}
+	first := issues[0]
+	if first.Number != 42 || first.Title != "Crash on start" || first.Author != "alice" {
+		t.Errorf("Unexpected first issue: %+v", first)
+	}
+	if first.URL != "https://github.com/octo/demo/issues/42" || first.State != "open" {
+		t.Errorf("Unexpected first issue URL or state: %+v", first)
+	}
+	if first.CreatedAt != "2024-03-02T08:00:00Z" {
+		t.Errorf("Expected created at 2024-03-02T08:00:00Z, got %q", first.CreatedAt)
+	}
+}
+
+const testContributors = `<html><body><ul>
+<li><a href="https://github.com/alice" data-hovercard-type="user"><img class="avatar" src="https://avatars.example/alice" alt="@alice"></a></li>
+<li><a href="/bob" data-hovercard-type="user"><img class="avatar" src="https://avatars.example/bob" alt="@bob"></a></li>
+<li><a href="/alice" data-hovercard-type="user"><img class="avatar" src="https://avatars.example/alice" alt="@alice"></a></li>
+<li><a href="/carol" data-hovercard-type="user">carol</a></li>
+</ul></body></html>`
+
+func TestParseContributors(t *testing.T) {
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(testContributors))
+	contributors := parseContributors(doc, NewSelectors().Run("test"))
+
+	if len(contributors) != 2 {
+		t.Fatalf("Expected 2 contributors, got %d: %+v", len(contributors), contributors)
+	}
+	if contributors[0].Login != "alice" || contributors[0].Avatar != "https://avatars.example/alice" {
+		t.Errorf("Unexpected first contributor: %+v", contributors[0])
+	}
+	if contributors[1].URL != "https://github.com/bob" {
+		t.Errorf("Expected URL https://github.com/bob, got %q", contributors[1].URL)
+	}
+}
@@ -0,0 +1,183 @@
+package scraper
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Default per-host limits. GitHub throttles HTML pages much harder than
+// the API, so the defaults are conservative.
+const (
+	DefaultHostConcurrency = 2
+	DefaultRatePerSecond   = 1.0
+	DefaultBurst           = 2
+	DefaultMaxRetries      = 4
+	DefaultRetryBaseDelay  = time.Second
+	DefaultRetryMaxDelay   = time.Minute
+)
+
+// RetryPolicy controls how failed requests are retried
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// backoff returns the delay before retry number attempt (starting at 0):
+// exponential growth capped at MaxDelay, with the upper half jittered
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay << attempt
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryable reports whether a response status is worth retrying
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := t.Sub(now)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// tokenBucket is a reservation based token bucket: take never fails, it
+// returns how long the caller has to wait for its token
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // tokens per second, <= 0 means unlimited
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+func (b *tokenBucket) take(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.rate <= 0 {
+		return 0
+	}
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// hostLimiter caps concurrent requests and request rate for one host
+type hostLimiter struct {
+	sem    chan struct{}
+	bucket *tokenBucket
+}
+
+func (l *hostLimiter) acquire() {
+	l.sem <- struct{}{}
+}
+
+func (l *hostLimiter) release() {
+	<-l.sem
+}
+
+// wait blocks until the token bucket grants a request
+func (l *hostLimiter) wait() {
+	if d := l.bucket.take(time.Now()); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// limiters hands out one hostLimiter per host, created on first use
+type limiters struct {
+	mu          sync.Mutex
+	hosts       map[string]*hostLimiter
+	concurrency int
+	rate        float64
+	burst       int
+}
+
+func newLimiters(concurrency int, rate float64, burst int) *limiters {
+	return &limiters{
+		hosts:       make(map[string]*hostLimiter),
+		concurrency: concurrency,
+		rate:        rate,
+		burst:       burst,
+	}
+}
+
+func (ls *limiters) get(host string) *hostLimiter {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	l, ok := ls.hosts[host]
+	if !ok {
+		l = &hostLimiter{
+			sem:    make(chan struct{}, ls.concurrency),
+			bucket: newTokenBucket(ls.rate, ls.burst),
+		}
+		ls.hosts[host] = l
+	}
+	return l
+}
+
+// reset drops existing limiters so new settings apply to every host
+func (ls *limiters) reset(concurrency int, rate float64, burst int) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.hosts = make(map[string]*hostLimiter)
+	ls.concurrency = concurrency
+	ls.rate = rate
+	ls.burst = burst
+}
+
+// releasingBody frees the host slot once the caller closes the body,
+// so the concurrency cap covers reading the response too
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,179 @@
+package scraper
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	b := newTokenBucket(10, 2)
+	b.last = now
+
+	if d := b.take(now); d != 0 {
+		t.Errorf("Expected first token immediately, got %v", d)
+	}
+	if d := b.take(now); d != 0 {
+		t.Errorf("Expected burst token immediately, got %v", d)
+	}
+	if d := b.take(now); d != 100*time.Millisecond {
+		t.Errorf("Expected 100ms wait, got %v", d)
+	}
+	// The previous caller reserved the next token, so this one waits twice as long
+	if d := b.take(now); d != 200*time.Millisecond {
+		t.Errorf("Expected 200ms wait, got %v", d)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if d, ok := parseRetryAfter("3", now); !ok || d != 3*time.Second {
+		t.Errorf("Expected 3s, got %v %v", d, ok)
+	}
+	if d, ok := parseRetryAfter("Mon, 01 Jan 2024 12:00:10 GMT", now); !ok || d != 10*time.Second {
+		t.Errorf("Expected 10s, got %v %v", d, ok)
+	}
+	if _, ok := parseRetryAfter("soon", now); ok {
+		t.Errorf("Expected invalid Retry-After to be ignored")
+	}
+}
+
+func TestBackoffBounds(t *testing.T) {
+	p := RetryPolicy{MaxRetries: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt := 0; attempt < 10; attempt++ {
+		d := p.backoff(attempt)
+		full := p.BaseDelay << attempt
+		if full > p.MaxDelay {
+			full = p.MaxDelay
+		}
+		if d < full/2 || d > full {
+			t.Errorf("Attempt %d: expected delay in [%v, %v], got %v", attempt, full/2, full, d)
+		}
+	}
+}
+
+func newTestScraper() *HTTPScraper {
+	s := NewHTTPScraper(5)
+	s.SetHostLimits(2, 0, 1)
+	s.SetRetryPolicy(RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond})
+	return s
+}
+
+func TestFetchDocumentRetries(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			fmt.Fprint(w, "<p>ok</p>")
+		}
+	}))
+	defer srv.Close()
+
+	doc, err := newTestScraper().FetchDocument(srv.URL + "/page")
+	if err != nil {
+		t.Fatalf("FetchDocument failed: %v", err)
+	}
+	if doc.Find("p").Text() != "ok" {
+		t.Errorf("Expected body ok, got %q", doc.Find("p").Text())
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", calls)
+	}
+}
+
+func TestFetchDocumentGivesUp(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	if _, err := newTestScraper().FetchDocument(srv.URL + "/page"); err == nil {
+		t.Errorf("Expected an error after retries are exhausted")
+	}
+	if calls != 4 {
+		t.Errorf("Expected 1 call plus 3 retries, got %d", calls)
+	}
+}
+
+func TestHostConcurrencyCap(t *testing.T) {
+	var inFlight, peak int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		fmt.Fprint(w, "<p>ok</p>")
+	}))
+	defer srv.Close()
+
+	s := newTestScraper()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := s.FetchDocument(fmt.Sprintf("%s/page/%d", srv.URL, i)); err != nil {
+				t.Errorf("FetchDocument failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if peak > 2 {
+		t.Errorf("Expected at most 2 concurrent requests, got %d", peak)
+	}
+}
+
+func TestSetHeader(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if got := r.Header.Get("X-Test"); got != "yes" {
+			t.Errorf("Expected X-Test header yes, got %q", got)
+		}
+		if got := r.Header.Get("Accept-Language"); got != "" {
+			t.Errorf("Expected Accept-Language to be removed, got %q", got)
+		}
+		fmt.Fprint(w, "<p>ok</p>")
+	}))
+	defer srv.Close()
+
+	s := newTestScraper()
+	s.SetHeader("X-Test", "yes")
+	s.SetHeader("Accept-Language", "")
+	if _, err := s.FetchDocument(srv.URL + "/page"); err != nil {
+		t.Fatalf("FetchDocument failed: %v", err)
+	}
+}
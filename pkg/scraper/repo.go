@@ -0,0 +1,143 @@
+package scraper
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// RepoPage is the data shown on a repository landing page
+type RepoPage struct {
+	Owner       string
+	Name        string
+	URL         string
+	Description string
+	Stars       int
+	Forks       int
+	Watchers    int
+	Topics      []string
+	License     string // lower-case SPDX-like key as in the API, "other" if unknown
+	Homepage    string
+	Language    string             // the language with the largest share
+	Languages   map[string]float64 // percent of code per language
+	LastCommit  time.Time
+}
+
+// FetchRepoPage scrapes https://github.com/{owner}/{name}
+func (hs *HTTPScraper) FetchRepoPage(owner, name string) (*RepoPage, error) {
+	url := fmt.Sprintf("%s/%s/%s", hs.baseURL, owner, name)
+	doc, err := hs.FetchDocument(url)
+	if err != nil {
+		return nil, err
+	}
+
+	run := hs.selectors.Run(url)
+	defer run.Done()
+
+	page := parseRepoPage(doc, run)
+	page.Owner = owner
+	page.Name = name
+	page.URL = "https://github.com/" + owner + "/" + name
+	return page, nil
+}
+
+// parseRepoPage extracts repository data from a landing page.
+// NOTE: GitHub markup can change; selectors come from the profile, which
+// lists the current markup first and older markup as fallbacks.
+func parseRepoPage(doc *goquery.Document, run *SelectorRun) *RepoPage {
+	page := &RepoPage{Languages: map[string]float64{}}
+	root := doc.Selection
+
+	page.Stars = counter(run.Find(root, "repo.stars").First())
+	page.Forks = counter(run.Find(root, "repo.forks").First())
+	page.Watchers = counter(run.Find(root, "repo.watchers").First())
+
+	page.Description = strings.TrimSpace(run.Find(root, "repo.description").First().Text())
+	page.Homepage, _ = run.Find(root, "repo.homepage").First().Attr("href")
+
+	run.Find(root, "repo.topics").Each(func(i int, s *goquery.Selection) {
+		if t := strings.TrimSpace(s.Text()); t != "" {
+			page.Topics = append(page.Topics, t)
+		}
+	})
+	page.Topics = unique(page.Topics)
+
+	if law := run.Find(root, "repo.license").First(); law.Length() > 0 {
+		page.License = licenseKey(law.Parent().Text())
+	}
+
+	// Language bar: "<span>Go</span> <span>97.3%</span>" per entry
+	run.Find(root, "repo.sidebar_cell").Each(func(i int, cell *goquery.Selection) {
+		if !strings.EqualFold(strings.TrimSpace(cell.Find("h2").First().Text()), "Languages") {
+			return
+		}
+		cell.Find("li").Each(func(i int, li *goquery.Selection) {
+			spans := li.Find("span")
+			if spans.Length() < 2 {
+				return
+			}
+			lang := strings.TrimSpace(spans.Eq(spans.Length() - 2).Text())
+			pct, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(spans.Last().Text()), "%"), 64)
+			if lang != "" && err == nil {
+				page.Languages[lang] = pct
+			}
+		})
+	})
+	best := -1.0
+	for lang, pct := range page.Languages {
+		if pct > best || (pct == best && lang < page.Language) {
+			page.Language, best = lang, pct
+		}
+	}
+
+	if dt, ok := run.Find(root, "repo.last_commit").First().Attr("datetime"); ok {
+		if t, err := time.Parse(time.RFC3339, dt); err == nil {
+			page.LastCommit = t
+		}
+	}
+
+	return page
+}
+
+// counter reads a count, preferring the exact value in a title attribute
+// over the abbreviated text
+func counter(s *goquery.Selection) int {
+	if title, ok := s.Attr("title"); ok {
+		if n, ok := parseCount(title); ok {
+			return n
+		}
+	}
+	n, _ := parseCount(s.Text())
+	return n
+}
+
+// parseCount parses "1,234", "12.3k" or "1.2m"
+func parseCount(text string) (int, bool) {
+	text = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(text), ",", ""))
+	mult := 1.0
+	switch {
+	case strings.HasSuffix(text, "k"):
+		mult, text = 1e3, strings.TrimSuffix(text, "k")
+	case strings.HasSuffix(text, "m"):
+		mult, text = 1e6, strings.TrimSuffix(text, "m")
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, false
+	}
+	return int(f*mult + 0.5), true
+}
+
+// licenseKey turns sidebar text like "Apache-2.0 license" into the key the
+// API uses ("apache-2.0")
+func licenseKey(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	lower := strings.ToLower(text)
+	if lower == "" || lower == "view license" || strings.HasPrefix(lower, "unknown") {
+		return "other"
+	}
+	return strings.TrimSuffix(lower, " license")
+}
@@ -0,0 +1,85 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const testRepoPage = `<html><body>
+<ul class="pagehead-actions">
+  <li><a href="/golang/go/watchers"><strong>3.4k</strong> watching</a></li>
+  <li><a href="/golang/go/forks"><span id="repo-network-counter" title="17,612">17.6k</span></a></li>
+  <li><a href="/golang/go/stargazers"><span id="repo-stars-counter-star" title="124,567">125k</span></a></li>
+</ul>
+<div data-testid="latest-commit"><relative-time datetime="2024-05-01T10:20:30Z">May 1</relative-time></div>
+<div class="BorderGrid">
+  <div class="BorderGrid-cell">
+    <h2>About</h2>
+    <p class="f4 my-3">The Go programming language</p>
+    <span><a href="https://go.dev" rel="noopener noreferrer nofollow" role="link">go.dev</a></span>
+    <a class="topic-tag" href="/topics/go"> go </a>
+    <a class="topic-tag" href="/topics/language">language</a>
+    <a class="topic-tag" href="/topics/go">go</a>
+    <a href="#BSD-3-Clause-1-ov-file"><svg class="octicon octicon-law"></svg> BSD-3-Clause license </a>
+  </div>
+  <div class="BorderGrid-cell">
+    <h2>Languages</h2>
+    <ul>
+      <li><a><svg></svg><span class="text-bold">Go</span> <span>88.1%</span></a></li>
+      <li><a><svg></svg><span class="text-bold">Assembly</span> <span>5.6%</span></a></li>
+      <li><a><svg></svg><span class="text-bold">HTML</span> <span>4.9%</span></a></li>
+    </ul>
+  </div>
+</div>
+</body></html>`
+
+func TestParseRepoPage(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(testRepoPage))
+	if err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+	page := parseRepoPage(doc, NewSelectors().Run("test"))
+
+	if page.Stars != 124567 {
+		t.Errorf("Expected 124567 stars, got %d", page.Stars)
+	}
+	if page.Forks != 17612 {
+		t.Errorf("Expected 17612 forks, got %d", page.Forks)
+	}
+	if page.Watchers != 3400 {
+		t.Errorf("Expected 3400 watchers, got %d", page.Watchers)
+	}
+	if page.Description != "The Go programming language" {
+		t.Errorf("Expected description, got %q", page.Description)
+	}
+	if page.Homepage != "https://go.dev" {
+		t.Errorf("Expected homepage https://go.dev, got %q", page.Homepage)
+	}
+	if len(page.Topics) != 2 || page.Topics[0] != "go" || page.Topics[1] != "language" {
+		t.Errorf("Expected topics [go language], got %v", page.Topics)
+	}
+	if page.License != "bsd-3-clause" {
+		t.Errorf("Expected license bsd-3-clause, got %q", page.License)
+	}
+	if page.Language != "Go" || page.Languages["Assembly"] != 5.6 || len(page.Languages) != 3 {
+		t.Errorf("Expected Go with 3 languages, got %q %v", page.Language, page.Languages)
+	}
+	if want := time.Date(2024, 5, 1, 10, 20, 30, 0, time.UTC); !page.LastCommit.Equal(want) {
+		t.Errorf("Expected last commit %v, got %v", want, page.LastCommit)
+	}
+}
+
+func TestParseCount(t *testing.T) {
+	tests := map[string]int{"1,234": 1234, "12.3k": 12300, "1.2m": 1200000, " 7 ": 7}
+	for in, want := range tests {
+		if got, ok := parseCount(in); !ok || got != want {
+			t.Errorf("parseCount(%q): expected %d, got %d", in, want, got)
+		}
+	}
+	if _, ok := parseCount("n/a"); ok {
+		t.Errorf("Expected parseCount to reject n/a")
+	}
+}
@@ -0,0 +1,327 @@
+package scraper
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"Fyne-on/pkg/httpcache"
+)
+
+// ErrDisallowed matches every DisallowedError with errors.Is
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// DisallowedError is returned when robots.txt forbids fetching a URL
+type DisallowedError struct {
+	URL       string
+	UserAgent string
+}
+
+func (e *DisallowedError) Error() string {
+	return fmt.Sprintf("robots.txt disallows %s for user agent %q", e.URL, e.UserAgent)
+}
+
+// Is makes errors.Is(err, ErrDisallowed) work
+func (e *DisallowedError) Is(target error) bool {
+	return target == ErrDisallowed
+}
+
+// robotsRule is a single Allow or Disallow line
+type robotsRule struct {
+	pattern string
+	allow   bool
+}
+
+// robotsGroup holds the rules that apply to a set of user agents
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// Robots is a parsed robots.txt file
+type Robots struct {
+	groups []*robotsGroup
+	// allowAll and disallowAll short-circuit evaluation for missing or
+	// unreachable robots.txt files
+	allowAll    bool
+	disallowAll bool
+}
+
+// ParseRobots parses a robots.txt body
+func ParseRobots(r io.Reader) (*Robots, error) {
+	robots := &Robots{}
+	var current *robotsGroup
+	lastWasAgent := false
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// Consecutive user-agent lines share one group
+			if current == nil || !lastWasAgent {
+				current = &robotsGroup{}
+				robots.groups = append(robots.groups, current)
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			lastWasAgent = true
+			continue
+		case "allow", "disallow":
+			if current != nil && !(key == "disallow" && value == "") {
+				current.rules = append(current.rules, robotsRule{pattern: value, allow: key == "allow"})
+			}
+		case "crawl-delay":
+			if current != nil {
+				if secs, err := strconv.ParseFloat(value, 64); err == nil && secs >= 0 {
+					current.crawlDelay = time.Duration(secs * float64(time.Second))
+				}
+			}
+		}
+		lastWasAgent = false
+	}
+	return robots, sc.Err()
+}
+
+// group returns the group that applies to userAgent: the one naming the
+// longest matching product token, falling back to "*"
+func (r *Robots) group(userAgent string) *robotsGroup {
+	token := strings.ToLower(userAgent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+
+	var best, wildcard *robotsGroup
+	bestLen := 0
+	for _, g := range r.groups {
+		for _, agent := range g.agents {
+			if agent == "*" {
+				if wildcard == nil {
+					wildcard = g
+				}
+				continue
+			}
+			if strings.HasPrefix(token, agent) && len(agent) > bestLen {
+				best, bestLen = g, len(agent)
+			}
+		}
+	}
+	if best != nil {
+		return best
+	}
+	return wildcard
+}
+
+// Allowed reports whether userAgent may fetch path (including the query).
+// The longest matching rule wins and Allow wins ties.
+func (r *Robots) Allowed(userAgent, path string) bool {
+	if r.allowAll {
+		return true
+	}
+	if r.disallowAll {
+		return false
+	}
+	if path == "" {
+		path = "/"
+	}
+
+	g := r.group(userAgent)
+	if g == nil {
+		return true
+	}
+
+	allowed := true
+	matchLen := -1
+	for _, rule := range g.rules {
+		if !robotsMatch(rule.pattern, path) {
+			continue
+		}
+		l := len(rule.pattern)
+		if l > matchLen || (l == matchLen && rule.allow) {
+			allowed = rule.allow
+			matchLen = l
+		}
+	}
+	return allowed
+}
+
+// CrawlDelay returns the Crawl-delay for userAgent, zero if none
+func (r *Robots) CrawlDelay(userAgent string) time.Duration {
+	if g := r.group(userAgent); g != nil {
+		return g.crawlDelay
+	}
+	return 0
+}
+
+// robotsMatch matches a path against a pattern supporting the
+// "*" wildcard and a trailing "$" end anchor
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+	for i, part := range parts[1:] {
+		if anchored && i == len(parts)-2 {
+			// The final wildcard can absorb anything before the anchored suffix
+			return strings.HasSuffix(path[pos:], part)
+		}
+		j := strings.Index(path[pos:], part)
+		if j < 0 {
+			return false
+		}
+		pos += j + len(part)
+	}
+	return !anchored || pos == len(path)
+}
+
+// RobotsCache fetches and caches robots.txt per host
+type RobotsCache struct {
+	client *http.Client
+	ttl    time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*robotsEntry
+}
+
+type robotsEntry struct {
+	robots  *Robots
+	expires time.Time
+	// fetching is closed when the fetch in progress for the host ends
+	fetching chan struct{}
+	// lastFetch is used to honour Crawl-delay between requests to the host
+	lastFetch time.Time
+}
+
+// NewRobotsCache creates a cache that refetches robots.txt after ttl
+func NewRobotsCache(client *http.Client, ttl time.Duration) *RobotsCache {
+	return &RobotsCache{
+		client:  client,
+		ttl:     ttl,
+		entries: make(map[string]*robotsEntry),
+	}
+}
+
+// entry returns the entry of host, creating it; rc.mu must be held
+func (rc *RobotsCache) entry(host string) *robotsEntry {
+	entry, ok := rc.entries[host]
+	if !ok {
+		entry = &robotsEntry{}
+		rc.entries[host] = entry
+	}
+	return entry
+}
+
+// Get returns the robots.txt rules for the host of u. Concurrent misses
+// for a host share one fetch.
+func (rc *RobotsCache) Get(u *url.URL, userAgent string) *Robots {
+	host := u.Scheme + "://" + u.Host
+
+	rc.mu.Lock()
+	entry := rc.entry(host)
+	for {
+		if entry.robots != nil && time.Now().Before(entry.expires) {
+			robots := entry.robots
+			rc.mu.Unlock()
+			return robots
+		}
+		if entry.fetching == nil {
+			break
+		}
+		done := entry.fetching
+		rc.mu.Unlock()
+		<-done
+		rc.mu.Lock()
+	}
+	done := make(chan struct{})
+	entry.fetching = done
+	rc.mu.Unlock()
+
+	robots, reachable := rc.fetch(host, userAgent)
+	// Retry unreachable hosts soon instead of blocking them for the full TTL
+	ttl := rc.ttl
+	if !reachable {
+		ttl = time.Minute
+	}
+
+	rc.mu.Lock()
+	entry.robots = robots
+	entry.expires = time.Now().Add(ttl)
+	entry.fetching = nil
+	rc.mu.Unlock()
+	close(done)
+	return robots
+}
+
+// Wait blocks until Crawl-delay has passed since the last request to the
+// host of u, then records the current request
+func (rc *RobotsCache) Wait(u *url.URL, delay time.Duration) {
+	host := u.Scheme + "://" + u.Host
+
+	rc.mu.Lock()
+	entry := rc.entry(host)
+	next := entry.lastFetch.Add(delay)
+	now := time.Now()
+	if next.Before(now) {
+		next = now
+	}
+	entry.lastFetch = next
+	rc.mu.Unlock()
+
+	time.Sleep(time.Until(next))
+}
+
+// fetch downloads robots.txt following RFC 9309: a missing file (4xx)
+// allows everything, a server error or unreachable host disallows everything.
+// Offline, an uncached robots.txt allows everything too: only cached pages
+// can be read, and they were fetched online under the robots.txt of the time.
+// The second result is false when the file could not be retrieved.
+func (rc *RobotsCache) fetch(host, userAgent string) (*Robots, bool) {
+	req, err := http.NewRequest("GET", host+"/robots.txt", nil)
+	if err != nil {
+		return &Robots{disallowAll: true}, false
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := rc.client.Do(req)
+	if errors.Is(err, httpcache.ErrOfflineMiss) {
+		return &Robots{allowAll: true}, true
+	}
+	if err != nil {
+		return &Robots{disallowAll: true}, false
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return &Robots{disallowAll: true}, false
+	case resp.StatusCode != http.StatusOK:
+		return &Robots{allowAll: true}, true
+	}
+
+	robots, err := ParseRobots(io.LimitReader(resp.Body, 512<<10))
+	if err != nil {
+		return &Robots{disallowAll: true}, false
+	}
+	return robots, true
+}
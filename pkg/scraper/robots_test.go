@@ -0,0 +1,229 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"Fyne-on/pkg/httpcache"
+)
+
+const testRobots = `
+# comment
+User-agent: *
+Disallow: /search
+Allow: /search/about
+Disallow: /*.json$
+
+User-agent: Fyne-on-Crawler
+User-agent: other-bot
+Disallow: /private
+Crawl-delay: 2.5
+`
+
+func TestParseRobotsGroups(t *testing.T) {
+	robots, err := ParseRobots(strings.NewReader(testRobots))
+	if err != nil {
+		t.Fatalf("ParseRobots failed: %v", err)
+	}
+
+	if len(robots.groups) != 2 {
+		t.Fatalf("Expected 2 groups, got %d", len(robots.groups))
+	}
+	if got := robots.CrawlDelay("Fyne-on-Crawler/1.0"); got != 2500*time.Millisecond {
+		t.Errorf("Expected crawl delay 2.5s, got %v", got)
+	}
+	if got := robots.CrawlDelay("SomeBot"); got != 0 {
+		t.Errorf("Expected no crawl delay for the wildcard group, got %v", got)
+	}
+}
+
+func TestRobotsAllowed(t *testing.T) {
+	robots, _ := ParseRobots(strings.NewReader(testRobots))
+
+	tests := []struct {
+		ua, path string
+		want     bool
+	}{
+		{"SomeBot", "/torvalds", true},
+		{"SomeBot", "/search?q=go", false},
+		{"SomeBot", "/search/about", true},
+		{"SomeBot", "/data.json", false},
+		{"SomeBot", "/data.json?x=1", true},
+		// The specific group replaces the wildcard group entirely
+		{"Fyne-on-Crawler/1.0", "/search", true},
+		{"Fyne-on-Crawler/1.0", "/private/x", false},
+		{"other-bot", "/private", false},
+	}
+	for _, tt := range tests {
+		if got := robots.Allowed(tt.ua, tt.path); got != tt.want {
+			t.Errorf("Allowed(%q, %q): expected %v, got %v", tt.ua, tt.path, tt.want, got)
+		}
+	}
+}
+
+func TestRobotsMatch(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/", "/anything", true},
+		{"/a*b", "/axxb/c", true},
+		{"/a*b$", "/axxb", true},
+		{"/a*b$", "/axxb/c", false},
+		{"/x$", "/x", true},
+		{"/x$", "/xy", false},
+		{"/*/issues", "/owner/repo/issues", true},
+	}
+	for _, tt := range tests {
+		if got := robotsMatch(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("robotsMatch(%q, %q): expected %v, got %v", tt.pattern, tt.path, tt.want, got)
+		}
+	}
+}
+
+func TestFetchDocumentHonoursRobots(t *testing.T) {
+	robotsHits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			robotsHits++
+			fmt.Fprint(w, "User-agent: *\nDisallow: /blocked\n")
+		default:
+			if ua := r.Header.Get("User-Agent"); ua != DefaultUserAgent {
+				t.Errorf("Expected User-Agent %q, got %q", DefaultUserAgent, ua)
+			}
+			fmt.Fprint(w, "<html><body><p>ok</p></body></html>")
+		}
+	}))
+	defer srv.Close()
+
+	s := NewHTTPScraper(5)
+
+	doc, err := s.FetchDocument(srv.URL + "/open")
+	if err != nil {
+		t.Fatalf("FetchDocument failed: %v", err)
+	}
+	if got := doc.Find("p").Text(); got != "ok" {
+		t.Errorf("Expected body text ok, got %q", got)
+	}
+
+	_, err = s.FetchDocument(srv.URL + "/blocked/page")
+	if !errors.Is(err, ErrDisallowed) {
+		t.Fatalf("Expected ErrDisallowed, got %v", err)
+	}
+	var de *DisallowedError
+	if !errors.As(err, &de) || de.UserAgent != DefaultUserAgent {
+		t.Errorf("Expected a DisallowedError for %q, got %v", DefaultUserAgent, err)
+	}
+
+	if robotsHits != 1 {
+		t.Errorf("Expected robots.txt to be fetched once, got %d", robotsHits)
+	}
+}
+
+func TestRobotsCacheStatusHandling(t *testing.T) {
+	status := http.StatusNotFound
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	s := NewHTTPScraper(5)
+	if _, err := s.FetchDocument(srv.URL + "/x"); errors.Is(err, ErrDisallowed) {
+		t.Errorf("Expected a missing robots.txt to allow everything, got %v", err)
+	}
+
+	status = http.StatusServiceUnavailable
+	s = NewHTTPScraper(5)
+	if _, err := s.FetchDocument(srv.URL + "/x"); !errors.Is(err, ErrDisallowed) {
+		t.Errorf("Expected an unavailable robots.txt to disallow everything, got %v", err)
+	}
+}
+
+func TestOfflineWithoutCachedRobots(t *testing.T) {
+	cache, err := httpcache.Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A page cached without the host's robots.txt
+	header := http.Header{"Content-Type": {"text/html"}}
+	if err := cache.Put("GET", "http://github.test/octocat", http.StatusOK, header, []byte("<html><body><p>ok</p></body></html>")); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewHTTPScraper(5)
+	s.SetCache(cache, true)
+	doc, err := s.FetchDocument("http://github.test/octocat")
+	if err != nil {
+		t.Fatalf("Expected the cached page to be read offline, got %v", err)
+	}
+	if got := doc.Find("p").Text(); got != "ok" {
+		t.Errorf("Expected body text ok, got %q", got)
+	}
+	if _, err := s.FetchDocument("http://github.test/other"); !errors.Is(err, httpcache.ErrOfflineMiss) {
+		t.Errorf("Expected ErrOfflineMiss for an uncached page, got %v", err)
+	}
+}
+
+func TestRobotsCacheSharesFetch(t *testing.T) {
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprint(w, testRobots)
+	}))
+	defer srv.Close()
+
+	rc := NewRobotsCache(srv.Client(), time.Hour)
+	u, _ := url.Parse(srv.URL + "/x")
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if rc.Get(u, "Fyne-on-Crawler").Allowed("Fyne-on-Crawler", "/private") {
+				t.Errorf("Expected /private to be disallowed")
+			}
+		}()
+	}
+	wg.Wait()
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("Expected one robots.txt fetch, got %d", n)
+	}
+}
+
+func TestSetUserAgentDuringRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html></html>")
+	}))
+	defer srv.Close()
+
+	s := NewHTTPScraper(5)
+	s.SetHostLimits(4, 0, 0)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.FetchDocument(srv.URL + "/x"); err != nil {
+				t.Errorf("FetchDocument failed: %v", err)
+			}
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		s.SetUserAgent(fmt.Sprintf("bot-%d", i))
+		s.SetRetryPolicy(RetryPolicy{MaxRetries: i})
+	}
+	wg.Wait()
+	if ua := s.UserAgent(); ua != "bot-7" {
+		t.Errorf("Expected user agent bot-7, got %q", ua)
+	}
+}
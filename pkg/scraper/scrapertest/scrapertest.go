@@ -0,0 +1,137 @@
+// Package scrapertest replays recorded GitHub pages for scraper tests and
+// compares parser output against golden files.
+package scrapertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ManifestFile is the name of the fixture index inside a fixture directory
+const ManifestFile = "manifest.json"
+
+// Page maps a recorded HTML file to the request URI it answers
+type Page struct {
+	File string `json:"file"`
+	Path string `json:"path"` // path and query, e.g. /octocat?tab=repositories
+	// Synthetic marks a page written by hand after GitHub's markup rather
+	// than recorded from the site; cmd/fixtures clears it on recording
+	Synthetic bool `json:"synthetic,omitempty"`
+}
+
+// Manifest lists the recorded pages of a fixture directory
+type Manifest struct {
+	Note  string `json:"note,omitempty"`
+	Pages []Page `json:"pages"`
+}
+
+// LoadManifest reads dir/manifest.json
+func LoadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("read fixture manifest: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse fixture manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// WriteManifest writes m to dir/manifest.json, one page per line
+func WriteManifest(dir string, m *Manifest) error {
+	var buf bytes.Buffer
+	buf.WriteString("{\n")
+	if m.Note != "" {
+		fmt.Fprintf(&buf, "  \"note\": %s,\n", quote(m.Note))
+	}
+	buf.WriteString("  \"pages\": [\n")
+	for i, p := range m.Pages {
+		fmt.Fprintf(&buf, "    {\"file\": %s, \"path\": %s", quote(p.File), quote(p.Path))
+		if p.Synthetic {
+			buf.WriteString(", \"synthetic\": true")
+		}
+		buf.WriteString("}")
+		if i < len(m.Pages)-1 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("  ]\n}\n")
+	return os.WriteFile(filepath.Join(dir, ManifestFile), buf.Bytes(), 0o644)
+}
+
+// quote encodes s as a JSON string, leaving "&" in query strings readable
+func quote(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// NewServer starts a server that answers every request URI listed in the
+// manifest of dir with its recorded page. Other URIs, robots.txt included,
+// get a 404, which the scraper treats as "no robots.txt".
+func NewServer(dir string) (*httptest.Server, error) {
+	m, err := LoadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make(map[string][]byte, len(m.Pages))
+	for _, p := range m.Pages {
+		data, err := os.ReadFile(filepath.Join(dir, p.File))
+		if err != nil {
+			return nil, fmt.Errorf("read fixture %s: %w", p.File, err)
+		}
+		pages[p.Path] = data
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := pages[r.URL.RequestURI()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(data)
+	})), nil
+}
+
+// Golden compares got, encoded as indented JSON, with the golden file at
+// path. With update set the file is rewritten instead.
+func Golden(t testing.TB, path string, got interface{}, update bool) {
+	t.Helper()
+
+	data, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatalf("marshal %s: %v", path, err)
+	}
+	data = append(data, '\n')
+
+	if update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("output does not match %s (run with -update to accept)\ngot:\n%s\nwant:\n%s", path, data, want)
+	}
+}
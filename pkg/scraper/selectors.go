@@ -0,0 +1,322 @@
+package scraper
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed selectors.yaml
+var defaultProfileYAML []byte
+
+// maxRecentFetches is how many fetch reports Diagnostics keeps
+const maxRecentFetches = 50
+
+// SelectorProfile lists, per field, CSS selectors tried in order
+type SelectorProfile struct {
+	Version string              `yaml:"version" json:"version"`
+	Fields  map[string][]string `yaml:"fields" json:"fields"`
+}
+
+// ParseSelectorProfile parses a YAML or JSON profile and checks that every
+// selector compiles
+func ParseSelectorProfile(data []byte) (*SelectorProfile, error) {
+	var p SelectorProfile
+	var err error
+	// JSON is mostly valid YAML, but tab indentation is not
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		err = json.Unmarshal(data, &p)
+	} else {
+		err = yaml.Unmarshal(data, &p)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse selector profile: %w", err)
+	}
+	for field, sels := range p.Fields {
+		if len(sels) == 0 {
+			return nil, fmt.Errorf("field %s has no selectors", field)
+		}
+		for _, sel := range sels {
+			if _, err := cascadia.Parse(sel); err != nil {
+				return nil, fmt.Errorf("field %s: invalid selector %q: %w", field, sel, err)
+			}
+		}
+	}
+	return &p, nil
+}
+
+// DefaultSelectorProfile returns the built-in profile
+func DefaultSelectorProfile() *SelectorProfile {
+	p, err := ParseSelectorProfile(defaultProfileYAML)
+	if err != nil {
+		panic("scraper: invalid built-in selector profile: " + err.Error())
+	}
+	return p
+}
+
+// merge returns p with fields missing from it taken from base
+func (p *SelectorProfile) merge(base *SelectorProfile) *SelectorProfile {
+	out := &SelectorProfile{Version: p.Version, Fields: map[string][]string{}}
+	for f, sels := range base.Fields {
+		out.Fields[f] = sels
+	}
+	for f, sels := range p.Fields {
+		out.Fields[f] = sels
+	}
+	if out.Version == "" {
+		out.Version = base.Version
+	}
+	return out
+}
+
+// FieldStats counts how often each selector of a field matched
+type FieldStats struct {
+	Hits   map[string]int `json:"hits"`
+	Misses int            `json:"misses"`
+}
+
+// FieldMatch records which selector matched a field during one fetch
+type FieldMatch struct {
+	Selector string `json:"selector,omitempty"` // empty if none matched
+	Fallback int    `json:"fallback"`           // index of the selector, -1 if none matched
+	Count    int    `json:"count"`
+}
+
+// FetchReport describes the selector matches of one parsed page
+type FetchReport struct {
+	URL    string                `json:"url"`
+	Time   time.Time             `json:"time"`
+	Fields map[string]FieldMatch `json:"fields"`
+}
+
+// SelectorDiagnostics is a snapshot of the active profile and recent matches
+type SelectorDiagnostics struct {
+	Version  string                `json:"version"`
+	Source   string                `json:"source"`
+	LoadedAt time.Time             `json:"loaded_at"`
+	Fields   map[string]FieldStats `json:"fields"`
+	Recent   []FetchReport         `json:"recent"`
+}
+
+// Selectors holds the active profile, reloads it from disk and records
+// which selectors matched. It is safe for concurrent use.
+type Selectors struct {
+	mu       sync.RWMutex
+	profile  *SelectorProfile
+	path     string
+	modTime  time.Time
+	loadedAt time.Time
+
+	statsMu sync.Mutex
+	stats   map[string]*FieldStats
+	recent  []FetchReport
+}
+
+// NewSelectors uses the built-in profile
+func NewSelectors() *Selectors {
+	return &Selectors{
+		profile:  DefaultSelectorProfile(),
+		loadedAt: time.Now(),
+		stats:    map[string]*FieldStats{},
+	}
+}
+
+// LoadSelectors reads a profile file; fields it does not define keep the
+// built-in selectors
+func LoadSelectors(path string) (*Selectors, error) {
+	s := NewSelectors()
+	s.path = path
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload rereads the profile file. The active profile is kept on error.
+func (s *Selectors) Reload() error {
+	s.mu.RLock()
+	path := s.path
+	s.mu.RUnlock()
+	if path == "" {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("stat selector profile: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read selector profile: %w", err)
+	}
+
+	p, err := ParseSelectorProfile(data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.profile = p.merge(DefaultSelectorProfile())
+	s.modTime = info.ModTime()
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// Watch reloads the profile whenever the file's modification time changes,
+// checking every interval until stop is closed
+func (s *Selectors) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.RLock()
+		path, modTime := s.path, s.modTime
+		s.mu.RUnlock()
+
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().Equal(modTime) {
+			continue
+		}
+		if err := s.Reload(); err != nil {
+			log.Printf("Selector profile reload failed, keeping version %s: %v", s.Profile().Version, err)
+			continue
+		}
+		log.Printf("Reloaded selector profile %s (version %s)", path, s.Profile().Version)
+	}
+}
+
+// Profile returns the active profile
+func (s *Selectors) Profile() *SelectorProfile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.profile
+}
+
+// Run starts recording matches for one parsed page
+func (s *Selectors) Run(url string) *SelectorRun {
+	return &SelectorRun{
+		s:       s,
+		profile: s.Profile(),
+		report:  FetchReport{URL: url, Time: time.Now(), Fields: map[string]FieldMatch{}},
+	}
+}
+
+// Diagnostics returns the active profile version and recent matches,
+// newest first
+func (s *Selectors) Diagnostics() SelectorDiagnostics {
+	s.mu.RLock()
+	d := SelectorDiagnostics{Version: s.profile.Version, Source: s.path, LoadedAt: s.loadedAt}
+	s.mu.RUnlock()
+	if d.Source == "" {
+		d.Source = "built-in"
+	}
+
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	d.Fields = make(map[string]FieldStats, len(s.stats))
+	for f, st := range s.stats {
+		hits := make(map[string]int, len(st.Hits))
+		for sel, n := range st.Hits {
+			hits[sel] = n
+		}
+		d.Fields[f] = FieldStats{Hits: hits, Misses: st.Misses}
+	}
+	d.Recent = make([]FetchReport, len(s.recent))
+	for i, r := range s.recent {
+		d.Recent[len(s.recent)-1-i] = r
+	}
+	return d
+}
+
+func (s *Selectors) record(r FetchReport) {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+
+	for f, m := range r.Fields {
+		st := s.stats[f]
+		if st == nil {
+			st = &FieldStats{Hits: map[string]int{}}
+			s.stats[f] = st
+		}
+		if m.Fallback < 0 {
+			st.Misses++
+		} else {
+			st.Hits[m.Selector]++
+		}
+	}
+	s.recent = append(s.recent, r)
+	if len(s.recent) > maxRecentFetches {
+		s.recent = s.recent[len(s.recent)-maxRecentFetches:]
+	}
+}
+
+// SelectorRun resolves fields against one page. A run is not safe for
+// concurrent use; start one per page.
+type SelectorRun struct {
+	s       *Selectors
+	profile *SelectorProfile
+	report  FetchReport
+}
+
+// Find returns the matches of the first selector of field that matches
+// anything under root
+func (r *SelectorRun) Find(root *goquery.Selection, field string) *goquery.Selection {
+	sels := r.profile.Fields[field]
+	for i, sel := range sels {
+		if m := root.Find(sel); m.Length() > 0 {
+			r.note(field, sel, i, m.Length())
+			return m
+		}
+	}
+	r.note(field, "", -1, 0)
+	return root.Slice(0, 0)
+}
+
+// Closest returns the closest ancestor of s matching the first selector of
+// field that matches one
+func (r *SelectorRun) Closest(s *goquery.Selection, field string) *goquery.Selection {
+	sels := r.profile.Fields[field]
+	for i, sel := range sels {
+		if m := s.Closest(sel); m.Length() > 0 {
+			r.note(field, sel, i, m.Length())
+			return m
+		}
+	}
+	r.note(field, "", -1, 0)
+	return s.Slice(0, 0)
+}
+
+// note keeps the best (lowest fallback index) match per field, since a
+// field can be resolved once per row
+func (r *SelectorRun) note(field, sel string, idx, count int) {
+	m, ok := r.report.Fields[field]
+	switch {
+	case !ok:
+		r.report.Fields[field] = FieldMatch{Selector: sel, Fallback: idx, Count: count}
+	case idx >= 0 && (m.Fallback < 0 || idx < m.Fallback):
+		r.report.Fields[field] = FieldMatch{Selector: sel, Fallback: idx, Count: m.Count + count}
+	case idx == m.Fallback:
+		m.Count += count
+		r.report.Fields[field] = m
+	}
+}
+
+// Done records the run in the diagnostics
+func (r *SelectorRun) Done() {
+	r.s.record(r.report)
+}
@@ -0,0 +1,98 @@
+package scraper
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestDefaultSelectorProfile(t *testing.T) {
+	p := DefaultSelectorProfile()
+	if p.Version == "" {
+		t.Errorf("Expected the built-in profile to have a version")
+	}
+	for _, field := range []string{"trending.developer", "user_repos.link", "org_repos.link", "repo.stars", "issues.link"} {
+		if len(p.Fields[field]) == 0 {
+			t.Errorf("Expected selectors for %s", field)
+		}
+	}
+}
+
+func TestParseSelectorProfileRejectsInvalidSelectors(t *testing.T) {
+	_, err := ParseSelectorProfile([]byte(`fields: {user_repos.link: ["h3 a[href*='/'']"]}`))
+	if err == nil {
+		t.Errorf("Expected an error for a malformed selector")
+	}
+}
+
+func TestLoadSelectorsMergesAndReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profile.json")
+	if err := os.WriteFile(path, []byte("{\n\t\"version\": \"v1\",\n\t\"fields\": {\"trending.developer\": [\"h2 a\"]}\n}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := LoadSelectors(path)
+	if err != nil {
+		t.Fatalf("LoadSelectors failed: %v", err)
+	}
+	p := s.Profile()
+	if p.Version != "v1" || p.Fields["trending.developer"][0] != "h2 a" {
+		t.Errorf("Expected override from file, got %+v", p)
+	}
+	if len(p.Fields["repo.stars"]) == 0 {
+		t.Errorf("Expected fields missing from the file to keep their defaults")
+	}
+
+	os.WriteFile(path, []byte("version: v2\nfields:\n  trending.developer: [\"h3 a\"]\n"), 0o644)
+	if err := s.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if s.Profile().Version != "v2" {
+		t.Errorf("Expected version v2 after reload, got %s", s.Profile().Version)
+	}
+
+	// A broken file keeps the previous profile
+	os.WriteFile(path, []byte(`fields: {repo.stars: ["a[["]}`), 0o644)
+	if err := s.Reload(); err == nil {
+		t.Errorf("Expected reload of an invalid profile to fail")
+	}
+	if s.Profile().Version != "v2" {
+		t.Errorf("Expected version v2 to stay active, got %s", s.Profile().Version)
+	}
+}
+
+func TestSelectorRunFallbackAndDiagnostics(t *testing.T) {
+	s := NewSelectors()
+	s.profile = &SelectorProfile{Version: "test", Fields: map[string][]string{
+		"link":    {"a.new", "a.old"},
+		"missing": {"span.none"},
+	}}
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(`<a class="old">x</a><a class="old">y</a>`))
+
+	run := s.Run("https://example.com/page")
+	if got := run.Find(doc.Selection, "link").Length(); got != 2 {
+		t.Errorf("Expected 2 matches from the fallback selector, got %d", got)
+	}
+	if got := run.Find(doc.Selection, "missing").Length(); got != 0 {
+		t.Errorf("Expected no matches, got %d", got)
+	}
+	run.Done()
+
+	d := s.Diagnostics()
+	if len(d.Recent) != 1 || d.Recent[0].URL != "https://example.com/page" {
+		t.Fatalf("Expected one recent report, got %+v", d.Recent)
+	}
+	link := d.Recent[0].Fields["link"]
+	if link.Selector != "a.old" || link.Fallback != 1 || link.Count != 2 {
+		t.Errorf("Unexpected link match: %+v", link)
+	}
+	if d.Fields["missing"].Misses != 1 {
+		t.Errorf("Expected 1 miss for missing, got %+v", d.Fields["missing"])
+	}
+	if d.Fields["link"].Hits["a.old"] != 1 {
+		t.Errorf("Expected 1 hit for a.old, got %+v", d.Fields["link"])
+	}
+}
@@ -0,0 +1,113 @@
+package scraper
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Trending periods accepted by FetchTrendingRepos
+const (
+	TrendingDaily   = "daily"
+	TrendingWeekly  = "weekly"
+	TrendingMonthly = "monthly"
+)
+
+// TrendingRepo is one row of https://github.com/trending
+type TrendingRepo struct {
+	Rank        int
+	Owner       string
+	Name        string
+	URL         string
+	Description string
+	Language    string
+	Stars       int
+	Forks       int
+	StarsGained int      // stars gained during the period
+	BuiltBy     []string // logins of the contributors shown on the row
+}
+
+// ValidTrendingPeriod reports whether since is a period GitHub trending
+// accepts ("" means daily)
+func ValidTrendingPeriod(since string) bool {
+	switch since {
+	case "", TrendingDaily, TrendingWeekly, TrendingMonthly:
+		return true
+	}
+	return false
+}
+
+// FetchTrendingRepos scrapes GitHub Trending repositories, optionally for
+// one language ("go", "c++") and period (daily, weekly or monthly)
+func (hs *HTTPScraper) FetchTrendingRepos(language, since string) ([]TrendingRepo, error) {
+	if !ValidTrendingPeriod(since) {
+		return nil, fmt.Errorf("invalid trending period %q (use daily, weekly or monthly)", since)
+	}
+
+	u := hs.baseURL + "/trending"
+	if language != "" {
+		u += "/" + url.PathEscape(strings.ToLower(language))
+	}
+	if since != "" {
+		u += "?since=" + since
+	}
+
+	doc, err := hs.FetchDocument(u)
+	if err != nil {
+		return nil, err
+	}
+	run := hs.selectors.Run(u)
+	defer run.Done()
+
+	return parseTrendingRepos(doc, run), nil
+}
+
+// parseTrendingRepos extracts the ranked rows of a trending page.
+// NOTE: GitHub markup can change; selectors come from the profile.
+func parseTrendingRepos(doc *goquery.Document, run *SelectorRun) []TrendingRepo {
+	out := []TrendingRepo{}
+	seen := map[string]bool{}
+
+	run.Find(doc.Selection, "trending.repo_row").Each(func(i int, row *goquery.Selection) {
+		href, ok := run.Find(row, "trending.repo_link").First().Attr("href")
+		if !ok || !strings.HasPrefix(href, "/") {
+			return
+		}
+		parts := strings.Split(strings.Trim(href, "/"), "/")
+		if len(parts) != 2 || seen[href] {
+			return
+		}
+		seen[href] = true
+
+		repo := TrendingRepo{
+			Rank:        len(out) + 1,
+			Owner:       parts[0],
+			Name:        parts[1],
+			URL:         "https://github.com/" + parts[0] + "/" + parts[1],
+			Description: strings.TrimSpace(run.Find(row, "trending.repo_description").First().Text()),
+			Language:    strings.TrimSpace(run.Find(row, "trending.repo_language").First().Text()),
+			Stars:       counter(run.Find(row, "trending.repo_stars").First()),
+			Forks:       counter(run.Find(row, "trending.repo_forks").First()),
+			BuiltBy:     []string{},
+		}
+
+		// "1,234 stars today" / "5,678 stars this week"
+		if fields := strings.Fields(run.Find(row, "trending.stars_gained").First().Text()); len(fields) > 0 {
+			repo.StarsGained, _ = parseCount(fields[0])
+		}
+
+		run.Find(row, "trending.built_by").Each(func(i int, s *goquery.Selection) {
+			if href, ok := s.Attr("href"); ok {
+				if login := strings.Trim(href, "/"); login != "" && !strings.Contains(login, "/") {
+					repo.BuiltBy = append(repo.BuiltBy, login)
+				}
+			}
+		})
+		repo.BuiltBy = unique(repo.BuiltBy)
+
+		out = append(out, repo)
+	})
+	return out
+}
@@ -0,0 +1,250 @@
+package storage
+
+import (
+	"Fyne-on/pkg/deps"
+	"Fyne-on/pkg/models"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Dependencies are stored twice, so both directions are a prefix scan:
+//
+//	dep:{repoID}:{ecosystem}:{name}        what a repo depends on
+//	dependent:{ecosystem}:{name}:{repoID}  who depends on a package
+//	package:{ecosystem}:{name}             the repo publishing a package
+//	manifests:{repoID}                     parsed manifests of a repo
+const (
+	depPrefix       = "dep:"
+	dependentPrefix = "dependent:"
+	packagePrefix   = "package:"
+	manifestsPrefix = "manifests:"
+)
+
+func depKey(repoID, ecosystem, name string) string {
+	return depPrefix + repoID + ":" + ecosystem + ":" + name
+}
+
+func dependentKey(ecosystem, name, repoID string) string {
+	return dependentPrefix + ecosystem + ":" + name + ":" + repoID
+}
+
+func packageKey(ecosystem, name string) string {
+	return packagePrefix + ecosystem + ":" + name
+}
+
+// SaveDependencies replaces the dependencies and published packages stored
+// for m.RepoID. Dependencies are resolved to crawled repos where possible
+// and a depends_on edge is stored for each resolved one.
+func (s *StorageService) SaveDependencies(m models.RepoManifests, list []models.Dependency) error {
+	repoID := m.RepoID
+	stale, err := s.dependencyKeys(repoID)
+	if err != nil {
+		return err
+	}
+
+	if m.FetchedAt.IsZero() {
+		m.FetchedAt = time.Now()
+	}
+	items := map[string]interface{}{manifestsPrefix + repoID: m}
+	for _, p := range m.Packages {
+		p.RepoID = repoID
+		items[packageKey(p.Ecosystem, p.Name)] = p
+	}
+
+	published := func(ecosystem, name string) string {
+		if p, ok := items[packageKey(ecosystem, name)].(models.Package); ok {
+			return p.RepoID
+		}
+		return s.resolvePackage(ecosystem, name)
+	}
+
+	for _, d := range list {
+		key := depKey(repoID, d.Ecosystem, d.Name)
+		// A package listed in several scopes keeps the first one
+		if _, seen := items[key]; seen {
+			continue
+		}
+		d.RepoID = repoID
+		d.ResolvedRepo = published(d.Ecosystem, d.Name)
+		d.UpdatedAt = m.FetchedAt
+		items[key] = d
+		items[dependentKey(d.Ecosystem, d.Name, repoID)] = d
+
+		if d.ResolvedRepo != "" && d.ResolvedRepo != repoID {
+			items[edgeKey(models.EdgeDependsOn, repoID, d.ResolvedRepo)] = models.Edge{
+				Kind:      models.EdgeDependsOn,
+				From:      repoID,
+				To:        d.ResolvedRepo,
+				Weight:    1,
+				UpdatedAt: m.FetchedAt,
+			}
+		}
+	}
+
+	var deleted []string
+	for _, key := range stale {
+		if _, kept := items[key]; !kept {
+			deleted = append(deleted, key)
+		}
+	}
+	if err := s.db.DeleteBatch(deleted); err != nil {
+		return fmt.Errorf("failed to delete old dependencies of %s: %w", repoID, err)
+	}
+	if err := s.db.SetBatch(items); err != nil {
+		return fmt.Errorf("failed to save dependencies of %s: %w", repoID, err)
+	}
+	return nil
+}
+
+// dependencyKeys lists every key SaveDependencies wrote for repoID
+func (s *StorageService) dependencyKeys(repoID string) ([]string, error) {
+	var keys []string
+	err := s.db.IterateWithPrefix(depPrefix+repoID+":", func(k string, v []byte) error {
+		var d models.Dependency
+		if err := json.Unmarshal(v, &d); err != nil {
+			return err
+		}
+		keys = append(keys, k, dependentKey(d.Ecosystem, d.Name, repoID))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dependencies of %s: %w", repoID, err)
+	}
+
+	edges, err := s.db.KeysWithPrefix(edgeKey(models.EdgeDependsOn, repoID, ""))
+	if err != nil {
+		return nil, err
+	}
+	keys = append(keys, edges...)
+
+	if m, err := s.GetRepoManifests(repoID); err == nil {
+		keys = append(keys, manifestsPrefix+repoID)
+		for _, p := range m.Packages {
+			// Another repo may have claimed the name since
+			if s.resolvePackage(p.Ecosystem, p.Name) == repoID {
+				keys = append(keys, packageKey(p.Ecosystem, p.Name))
+			}
+		}
+	}
+	return keys, nil
+}
+
+// deleteDependencies removes everything SaveDependencies stored for repoID
+func (s *StorageService) deleteDependencies(repoID string) error {
+	keys, err := s.dependencyKeys(repoID)
+	if err != nil {
+		return err
+	}
+	return s.db.DeleteBatch(keys)
+}
+
+// resolvePackage returns the crawled repo a package comes from: the repo
+// whose manifest declares it, or for Go modules the repo their path names
+func (s *StorageService) resolvePackage(ecosystem, name string) string {
+	var p models.Package
+	if err := s.db.GetJSON(packageKey(ecosystem, name), &p); err == nil {
+		return p.RepoID
+	}
+	if ecosystem == deps.Go {
+		if repoID := deps.GoModuleRepo(name); repoID != "" {
+			if ok, _ := s.db.Exists("repo:" + repoID); ok {
+				return repoID
+			}
+		}
+	}
+	return ""
+}
+
+// GetRepoManifests returns the manifests parsed for a repo
+func (s *StorageService) GetRepoManifests(repoID string) (*models.RepoManifests, error) {
+	var m models.RepoManifests
+	if err := s.db.GetJSON(manifestsPrefix+repoID, &m); err != nil {
+		return nil, fmt.Errorf("manifests not found: %w", err)
+	}
+	return &m, nil
+}
+
+// GetDependencies returns what a repo depends on. Dependencies unresolved
+// when saved are resolved again, as their repo may have been crawled since.
+func (s *StorageService) GetDependencies(repoID string) ([]models.Dependency, error) {
+	out := []models.Dependency{}
+	err := s.db.IteratePrefix(depPrefix+repoID+":", func(_ []byte, v []byte) error {
+		var d models.Dependency
+		if err := json.Unmarshal(v, &d); err != nil {
+			return err
+		}
+		if d.ResolvedRepo == "" {
+			d.ResolvedRepo = s.resolvePackage(d.Ecosystem, d.Name)
+		}
+		out = append(out, d)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dependencies of %s: %w", repoID, err)
+	}
+	return out, nil
+}
+
+// GetDependents returns the dependencies of other repos on a repo: on the
+// packages its manifests declare and, for Go, on module paths naming it
+func (s *StorageService) GetDependents(repoID string) ([]models.Dependency, error) {
+	seen := map[string]bool{}
+	out := []models.Dependency{}
+	collect := func(prefix string, match func(models.Dependency) bool) error {
+		return s.db.IteratePrefix(prefix, func(k []byte, v []byte) error {
+			var d models.Dependency
+			if err := json.Unmarshal(v, &d); err != nil {
+				return err
+			}
+			if seen[string(k)] || d.RepoID == repoID || !match(d) {
+				return nil
+			}
+			seen[string(k)] = true
+			d.ResolvedRepo = repoID
+			out = append(out, d)
+			return nil
+		})
+	}
+
+	if m, err := s.GetRepoManifests(repoID); err == nil {
+		for _, p := range m.Packages {
+			err := collect(dependentPrefix+p.Ecosystem+":"+p.Name+":", func(models.Dependency) bool { return true })
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	module := repoID
+	if strings.Count(repoID, "/") == 1 {
+		module = "github.com/" + repoID
+	}
+	err := collect(dependentPrefix+deps.Go+":"+module, func(d models.Dependency) bool {
+		return deps.GoModuleRepo(d.Name) == repoID
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dependents of %s: %w", repoID, err)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].RepoID != out[j].RepoID {
+			return out[i].RepoID < out[j].RepoID
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out, nil
+}
+
+// IterateDependencies calls fn for every stored dependency
+func (s *StorageService) IterateDependencies(fn func(models.Dependency) error) error {
+	return s.db.IteratePrefix(depPrefix, func(_ []byte, v []byte) error {
+		var d models.Dependency
+		if err := json.Unmarshal(v, &d); err != nil {
+			return err
+		}
+		return fn(d)
+	})
+}
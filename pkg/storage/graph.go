@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"Fyne-on/pkg/models"
+	"encoding/json"
+	"time"
+)
+
+const edgePrefix = "edge:"
+
+// SaveEdge stores a relation between two entities. Keys are
+// edge:{kind}:{from}:{to}, so saving the same relation twice is a no-op.
+func (s *StorageService) SaveEdge(kind, from, to string) error {
+	return s.db.Set(edgeKey(kind, from, to), models.Edge{
+		Kind:      kind,
+		From:      from,
+		To:        to,
+		Weight:    1,
+		UpdatedAt: time.Now(),
+	})
+}
+
+func edgeKey(kind, from, to string) string {
+	return edgePrefix + kind + ":" + from + ":" + to
+}
+
+// IterateEdges calls fn for every stored edge of a kind ("" for all kinds)
+func (s *StorageService) IterateEdges(kind string, fn func(models.Edge) error) error {
+	prefix := edgePrefix
+	if kind != "" {
+		prefix += kind + ":"
+	}
+	return s.db.IteratePrefix(prefix, func(_ []byte, v []byte) error {
+		var e models.Edge
+		if err := json.Unmarshal(v, &e); err != nil {
+			return err
+		}
+		return fn(e)
+	})
+}
+
+// IterateRepos calls fn for every stored repository without loading them all
+func (s *StorageService) IterateRepos(fn func(models.Repo) error) error {
+	return s.db.IteratePrefix("repo:", func(_ []byte, v []byte) error {
+		var repo models.Repo
+		if err := json.Unmarshal(v, &repo); err != nil {
+			return err
+		}
+		return fn(repo)
+	})
+}
+
+// IterateContacts calls fn for every stored contact without loading them all
+func (s *StorageService) IterateContacts(fn func(models.Contact) error) error {
+	return s.db.IteratePrefix("contact:", func(_ []byte, v []byte) error {
+		var contact models.Contact
+		if err := json.Unmarshal(v, &contact); err != nil {
+			return err
+		}
+		return fn(contact)
+	})
+}
+
+// IterateMarkovStates calls fn for every persisted Markov state
+func (s *StorageService) IterateMarkovStates(fn func(MarkovState) error) error {
+	return s.db.IteratePrefix(markovStatePrefix, func(_ []byte, v []byte) error {
+		var state MarkovState
+		if err := json.Unmarshal(v, &state); err != nil {
+			return err
+		}
+		return fn(state)
+	})
+}
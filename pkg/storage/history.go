@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"Fyne-on/pkg/database"
+	"Fyne-on/pkg/models"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Repos, issues and pull requests are only written when their content
+// changes. Every write that replaces a version appends a change log entry
+// in the same transaction:
+//
+//	history:{record key}:{unix nanoseconds, zero padded}
+//
+// holding the replaced version and the fields that changed. Entries are
+// never rewritten; they are deleted together with their record.
+const historyPrefix = "history:"
+
+// unhashedFields change on every save without the record itself changing
+var unhashedFields = []string{"hash", "updated_at"}
+
+// contentFields returns the JSON fields of a record (a struct or its
+// stored JSON) that make up its content
+func contentFields(record interface{}) (map[string]json.RawMessage, error) {
+	data, ok := record.([]byte)
+	if !ok {
+		var err error
+		if data, err = json.Marshal(record); err != nil {
+			return nil, err
+		}
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for _, f := range unhashedFields {
+		delete(fields, f)
+	}
+	return fields, nil
+}
+
+// hashFields hashes content fields; json.Marshal sorts map keys, so the
+// hash does not depend on field order
+func hashFields(fields map[string]json.RawMessage) string {
+	data, _ := json.Marshal(fields)
+	return database.GenerateHash(string(data))
+}
+
+// contentHash hashes every field of a record but its hash and updated_at
+func contentHash(record interface{}) (string, error) {
+	fields, err := contentFields(record)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash record: %w", err)
+	}
+	return hashFields(fields), nil
+}
+
+// diffFields lists the fields that differ between two versions, by name
+func diffFields(old, new map[string]json.RawMessage) []models.FieldChange {
+	names := make([]string, 0, len(new))
+	for name := range new {
+		names = append(names, name)
+	}
+	for name := range old {
+		if _, ok := new[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := []models.FieldChange{}
+	for _, name := range names {
+		if !bytes.Equal(old[name], new[name]) {
+			changes = append(changes, models.FieldChange{Field: name, Old: old[name], New: new[name]})
+		}
+	}
+	return changes
+}
+
+func historyKey(key string, at time.Time) string {
+	return fmt.Sprintf("%s%s:%020d", historyPrefix, key, at.UnixNano())
+}
+
+// versionFunc builds the record to save under a key from its stored JSON
+// (nil if there is none) and returns it with its Hash set to hash
+type versionFunc func(prev []byte) (record interface{}, hash string, err error)
+
+// saveVersioned writes the record build returns under key unless the stored
+// version has the same content, logging the version it replaces. Reading
+// the stored version and writing happen in one transaction, so concurrent
+// saves of a record never log or overwrite each other's versions. It
+// reports whether the record was written.
+func (s *StorageService) saveVersioned(key string, build versionFunc, indexes database.IndexFunc) (bool, error) {
+	return s.db.UpdateWithIndex(key, func(prev []byte) (interface{}, []database.Entry, error) {
+		record, hash, err := build(prev)
+		if err != nil || prev == nil {
+			return record, nil, err
+		}
+		// Versions that no longer decode are replaced without a log entry
+		oldFields, err := contentFields(prev)
+		if err != nil {
+			return record, nil, nil
+		}
+		oldHash := hashFields(oldFields)
+		if oldHash == hash {
+			return nil, nil, nil // No changes
+		}
+		newFields, err := contentFields(record)
+		if err != nil {
+			return nil, nil, err
+		}
+		change := models.Change{
+			Key:       key,
+			OldHash:   oldHash,
+			NewHash:   hash,
+			Fields:    diffFields(oldFields, newFields),
+			Previous:  prev,
+			ChangedAt: time.Now(),
+		}
+		return record, []database.Entry{{Key: historyKey(key, change.ChangedAt), Value: change}}, nil
+	}, indexes)
+}
+
+// GetHistory returns a page of the change log of the record stored under
+// key, newest first, and the cursor of the next page
+func (s *StorageService) GetHistory(key string, page Page) ([]models.Change, string, error) {
+	opts, err := page.scanOptions(historyPrefix + key + ":")
+	if err != nil {
+		return nil, "", err
+	}
+	opts.Values, opts.Reverse = true, true
+	changes := []models.Change{}
+	next, err := s.scanPage(opts, func(_ string, v []byte) error {
+		var change models.Change
+		if err := json.Unmarshal(v, &change); err != nil {
+			return err
+		}
+		changes = append(changes, change)
+		return nil
+	})
+	return changes, next, err
+}
+
+// GetRepoHistory returns a page of a repository's change log, newest first
+func (s *StorageService) GetRepoHistory(owner, name string, page Page) ([]models.Change, string, error) {
+	return s.GetHistory("repo:"+owner+"/"+name, page)
+}
+
+// deleteHistory deletes the change logs of a repository, its issues and
+// its pull requests
+func (s *StorageService) deleteHistory(repoID string) error {
+	for _, prefix := range []string{
+		historyPrefix + "repo:" + repoID + ":",
+		historyPrefix + "issue:" + repoID + "/",
+		historyPrefix + "pr:" + repoID + "/",
+	} {
+		keys, err := s.db.KeysWithPrefix(prefix)
+		if err != nil {
+			return err
+		}
+		if err := s.db.DeleteBatch(keys); err != nil {
+			return fmt.Errorf("failed to delete history: %w", err)
+		}
+	}
+	return nil
+}
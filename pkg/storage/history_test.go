@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"Fyne-on/pkg/models"
+)
+
+func TestSaveRepoDetectsContentChanges(t *testing.T) {
+	s, _ := newTestStorage(t)
+	repo := models.Repo{Owner: "acme", Name: "api", URL: "https://github.com/acme/api", Stars: 10}
+
+	if written, err := s.SaveRepo(repo); err != nil || !written {
+		t.Fatalf("Expected first save to write, got %v, %v", written, err)
+	}
+	if written, _ := s.SaveRepo(repo); written {
+		t.Errorf("Expected unchanged repo not to be written")
+	}
+	first, _ := s.GetRepo("acme", "api")
+
+	repo.Stars = 25
+	repo.Description = "API server"
+	if written, _ := s.SaveRepo(repo); !written {
+		t.Fatalf("Expected changed stars to be written")
+	}
+	second, _ := s.GetRepo("acme", "api")
+	if second.Stars != 25 || second.Hash == first.Hash {
+		t.Errorf("Expected stars 25 with a new hash, got %d (%s)", second.Stars, second.Hash)
+	}
+
+	repo.Stars = 30
+	s.SaveRepo(repo)
+
+	changes, next, err := s.GetRepoHistory("acme", "api", Page{})
+	if err != nil {
+		t.Fatalf("GetRepoHistory failed: %v", err)
+	}
+	if len(changes) != 2 || next != "" {
+		t.Fatalf("Expected 2 changes, got %d (next %q)", len(changes), next)
+	}
+
+	// Newest first
+	latest := changes[0]
+	if len(latest.Fields) != 1 || latest.Fields[0].Field != "stars" ||
+		string(latest.Fields[0].Old) != "25" || string(latest.Fields[0].New) != "30" {
+		t.Errorf("Expected stars 25 -> 30, got %+v", latest.Fields)
+	}
+	if latest.OldHash != changes[1].NewHash {
+		t.Errorf("Expected hashes to chain, got %s after %s", latest.OldHash, changes[1].NewHash)
+	}
+
+	oldest := changes[1]
+	fields := map[string]models.FieldChange{}
+	for _, f := range oldest.Fields {
+		fields[f.Field] = f
+	}
+	if len(fields) != 2 || string(fields["stars"].New) != "25" || string(fields["description"].Old) != `""` {
+		t.Errorf("Expected description and stars changes, got %+v", oldest.Fields)
+	}
+	var prev models.Repo
+	if err := json.Unmarshal(oldest.Previous, &prev); err != nil || prev.Stars != 10 {
+		t.Errorf("Expected the previous version with 10 stars, got %+v (%v)", prev, err)
+	}
+
+	page, next, _ := s.GetRepoHistory("acme", "api", Page{Limit: 1})
+	rest, _, _ := s.GetRepoHistory("acme", "api", Page{Limit: 1, Cursor: next})
+	if len(page) != 1 || len(rest) != 1 || !rest[0].ChangedAt.Equal(oldest.ChangedAt) {
+		t.Errorf("Expected history to page newest first")
+	}
+
+	if err := s.DeleteRepo("acme", "api"); err != nil {
+		t.Fatal(err)
+	}
+	if changes, _, _ := s.GetRepoHistory("acme", "api", Page{}); len(changes) != 0 {
+		t.Errorf("Expected history to be deleted with the repo, got %d changes", len(changes))
+	}
+}
+
+func TestSaveIssueDetectsStateChange(t *testing.T) {
+	s, _ := newTestStorage(t)
+	issue := models.Issue{RepoID: "acme/api", ID: "1", Title: "Crash", State: "open"}
+	s.SaveIssue(issue)
+
+	issue.State = "closed"
+	if written, _ := s.SaveIssue(issue); !written {
+		t.Fatalf("Expected closed issue to be written")
+	}
+	issues, _, _ := s.ListIssues(ActivityQuery{State: "closed"}, Page{})
+	if len(issues) != 1 {
+		t.Errorf("Expected the issue in the closed index, got %d", len(issues))
+	}
+
+	changes, _, _ := s.GetHistory("issue:acme/api/1", Page{})
+	if len(changes) != 1 || len(changes[0].Fields) != 1 || changes[0].Fields[0].Field != "state" {
+		t.Errorf("Expected one state change, got %+v", changes)
+	}
+}
+
+func TestSaveRepoLegacyHash(t *testing.T) {
+	s, db := newTestStorage(t)
+	// Records written before content hashing carry an identity hash
+	legacy := models.Repo{Owner: "acme", Name: "api", Stars: 10, Source: models.SourceGitHub, Hash: "identity"}
+	if err := db.SetWithIndex("repo:acme/api", legacy, repoIndexes); err != nil {
+		t.Fatal(err)
+	}
+
+	legacy.Hash = ""
+	if written, _ := s.SaveRepo(legacy); written {
+		t.Errorf("Expected unchanged legacy repo not to be written")
+	}
+}
+
+func TestSaveRepoMergesPartialRecords(t *testing.T) {
+	s, _ := newTestStorage(t)
+	created := time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC)
+	s.SaveRepo(models.Repo{
+		Owner: "acme", Name: "api", URL: "https://github.com/acme/api", Description: "API server",
+		Stars: 10, Watchers: 4, Topics: []string{"http"}, License: "MIT", HasOpenLicense: true, CreatedAt: created,
+	})
+
+	// A trending row carries stars and description only
+	row := models.Repo{Owner: "acme", Name: "api", Description: "API server", Stars: 10}
+	if written, _ := s.SaveRepo(row); written {
+		t.Errorf("Expected a row matching the stored repo not to be written")
+	}
+	row.Stars = 12
+	if written, _ := s.SaveRepo(row); !written {
+		t.Fatalf("Expected new stars to be written")
+	}
+
+	repo, _ := s.GetRepo("acme", "api")
+	if repo.Stars != 12 || repo.Watchers != 4 || len(repo.Topics) != 1 || !repo.HasOpenLicense || !repo.CreatedAt.Equal(created) {
+		t.Errorf("Expected stored fields kept around the new stars, got %+v", repo)
+	}
+	changes, _, _ := s.GetRepoHistory("acme", "api", Page{})
+	if len(changes) != 1 || len(changes[0].Fields) != 1 || changes[0].Fields[0].Field != "stars" {
+		t.Errorf("Expected one stars change, got %+v", changes)
+	}
+}
+
+func TestSaveRepoConcurrentVersions(t *testing.T) {
+	s, _ := newTestStorage(t)
+	var wg sync.WaitGroup
+	var writes atomic.Int32
+	for i := 1; i <= 50; i++ {
+		wg.Add(1)
+		go func(stars int) {
+			defer wg.Done()
+			written, err := s.SaveRepo(models.Repo{Owner: "acme", Name: "api", Stars: stars})
+			if err != nil {
+				t.Errorf("SaveRepo failed: %v", err)
+			}
+			if written {
+				writes.Add(1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Each version is replaced once, by the version logged after it
+	changes, _, _ := s.GetRepoHistory("acme", "api", Page{})
+	if len(changes) != int(writes.Load())-1 {
+		t.Fatalf("Expected %d changes for %d writes, got %d", writes.Load()-1, writes.Load(), len(changes))
+	}
+	replaced := map[string]bool{}
+	for _, c := range changes {
+		if replaced[c.OldHash] {
+			t.Errorf("Expected version %s to be replaced once", c.OldHash)
+		}
+		replaced[c.OldHash] = true
+	}
+	repo, _ := s.GetRepo("acme", "api")
+	if len(changes) > 0 && changes[0].NewHash != repo.Hash {
+		t.Errorf("Expected the latest change to lead to the stored version")
+	}
+}
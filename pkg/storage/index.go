@@ -0,0 +1,347 @@
+package storage
+
+import (
+	"Fyne-on/pkg/database"
+	"Fyne-on/pkg/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// Secondary indexes are value-less keys ending in the indexed record's
+// key, written in the same transaction as the record:
+//
+//	idx:repo:lang:{language}:{owner}/{name}
+//	idx:repo:owner:{owner}:{owner}/{name}
+//	idx:repo:source:{source}:{owner}/{name}
+//	idx:repo:stars:{stars, zero padded}:{owner}/{name}
+//	idx:issue:state:{state}:{repoID}/{id}    (idx:pr:... for pull requests)
+//	idx:issue:author:{author}:{repoID}/{id}
+//
+// Values are lower-cased, so lookups are case-insensitive. A change to this
+// layout needs a migration rebuilding the indexes (see migrate.go).
+const (
+	indexPrefix = "idx:"
+	starsWidth  = 10
+)
+
+// indexValue makes a field value safe to embed in an index key
+func indexValue(v string) string {
+	return strings.ReplaceAll(strings.ToLower(v), ":", "%3A")
+}
+
+func repoIndexPrefix(field, value string) string {
+	return indexPrefix + "repo:" + field + ":" + indexValue(value) + ":"
+}
+
+func starsIndexPrefix() string {
+	return indexPrefix + "repo:stars:"
+}
+
+func starsIndexValue(stars int) string {
+	if stars < 0 {
+		stars = 0
+	}
+	return fmt.Sprintf("%0*d", starsWidth, stars)
+}
+
+func repoIndexes(v []byte) []string {
+	var repo models.Repo
+	if err := json.Unmarshal(v, &repo); err != nil {
+		return nil
+	}
+	id := repo.Owner + "/" + repo.Name
+	keys := []string{
+		repoIndexPrefix("owner", repo.Owner) + id,
+		repoIndexPrefix("source", repo.Source) + id,
+		starsIndexPrefix() + starsIndexValue(repo.Stars) + ":" + id,
+	}
+	if repo.Language != "" {
+		keys = append(keys, repoIndexPrefix("lang", repo.Language)+id)
+	}
+	return keys
+}
+
+// activityIndexes indexes issues (kind "issue") and pull requests ("pr")
+func activityIndexes(kind string) database.IndexFunc {
+	return func(v []byte) []string {
+		var a struct {
+			RepoID string `json:"repo_id"`
+			ID     string `json:"id"`
+			State  string `json:"state"`
+			Author string `json:"author"`
+		}
+		if err := json.Unmarshal(v, &a); err != nil {
+			return nil
+		}
+		id := a.RepoID + "/" + a.ID
+		var keys []string
+		if a.State != "" {
+			keys = append(keys, activityIndexPrefix(kind, "state", a.State)+id)
+		}
+		if a.Author != "" {
+			keys = append(keys, activityIndexPrefix(kind, "author", a.Author)+id)
+		}
+		return keys
+	}
+}
+
+func activityIndexPrefix(kind, field, value string) string {
+	return indexPrefix + kind + ":" + field + ":" + indexValue(value) + ":"
+}
+
+var (
+	issueIndexes = activityIndexes("issue")
+	prIndexes    = activityIndexes("pr")
+)
+
+// RebuildIndexes drops and rewrites every secondary index, a batch of
+// keys at a time, logging progress
+func (s *StorageService) RebuildIndexes() error {
+	dropped := 0
+	err := s.scanBatches(indexPrefix, false, func(keys []string, _ [][]byte) error {
+		if err := s.db.DeleteBatch(keys); err != nil {
+			return err
+		}
+		dropped += len(keys)
+		log.Printf("  %d index keys dropped", dropped)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to drop indexes: %w", err)
+	}
+
+	for _, r := range []struct {
+		prefix  string
+		indexes database.IndexFunc
+	}{
+		{"repo:", repoIndexes},
+		{"issue:", issueIndexes},
+		{"pr:", prIndexes},
+	} {
+		indexed := 0
+		err := s.scanBatches(r.prefix, true, func(_ []string, values [][]byte) error {
+			var keys []string
+			for _, v := range values {
+				keys = append(keys, r.indexes(v)...)
+			}
+			if err := s.db.PutKeys(keys); err != nil {
+				return fmt.Errorf("failed to write indexes: %w", err)
+			}
+			indexed += len(values)
+			log.Printf("  %s %d indexed", r.prefix, indexed)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to index %s records: %w", strings.TrimSuffix(r.prefix, ":"), err)
+		}
+	}
+	return nil
+}
+
+// RepoQuery filters repositories; zero fields match everything and
+// MaxStars 0 means no upper bound
+type RepoQuery struct {
+	Language string
+	Owner    string
+	Source   string
+	MinStars int
+	MaxStars int
+}
+
+func (q RepoQuery) matches(repo models.Repo) bool {
+	source := repo.Source
+	if source == "" {
+		source = models.SourceGitHub
+	}
+	return (q.Language == "" || strings.EqualFold(repo.Language, q.Language)) &&
+		(q.Owner == "" || strings.EqualFold(repo.Owner, q.Owner)) &&
+		(q.Source == "" || strings.EqualFold(source, q.Source)) &&
+		repo.Stars >= q.MinStars &&
+		(q.MaxStars <= 0 || repo.Stars <= q.MaxStars)
+}
+
+// ListRepos returns a page of the repositories matching q and the cursor of
+// the next page. The most selective field given (language, owner, source,
+// then stars) picks the index range scanned, and the order: by repo ID for
+// the first three, most stars first for stars. The other fields are checked
+// on the records read, and the scan goes on until page.Limit repos matched.
+// Without filters every repo is listed by ID.
+func (s *StorageService) ListRepos(q RepoQuery, page Page) ([]models.Repo, string, error) {
+	var prefix string
+	switch {
+	case q.Language != "":
+		prefix = repoIndexPrefix("lang", q.Language)
+	case q.Owner != "":
+		prefix = repoIndexPrefix("owner", q.Owner)
+	case q.Source != "":
+		prefix = repoIndexPrefix("source", q.Source)
+	case q.MinStars > 0 || q.MaxStars > 0:
+		return s.listReposByStars(q, page)
+	default:
+		return s.listRecords(page)
+	}
+
+	opts, err := page.scanOptions(prefix)
+	if err != nil {
+		return nil, "", err
+	}
+	repos := []models.Repo{}
+	next, err := s.scanMatching(opts, func(k string) (string, error) {
+		return "repo:" + strings.TrimPrefix(k, prefix), nil
+	}, matchRepos(q, &repos))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to scan repo index: %w", err)
+	}
+	return repos, next, nil
+}
+
+// listRecords pages through every repo
+func (s *StorageService) listRecords(page Page) ([]models.Repo, string, error) {
+	opts, err := page.scanOptions("repo:")
+	if err != nil {
+		return nil, "", err
+	}
+	opts.Values = true
+	repos := []models.Repo{}
+	next, err := s.scanPage(opts, func(_ string, v []byte) error {
+		var repo models.Repo
+		if err := json.Unmarshal(v, &repo); err != nil {
+			return err
+		}
+		repos = append(repos, repo)
+		return nil
+	})
+	return repos, next, err
+}
+
+// listReposByStars walks the stars index from MaxStars down to MinStars
+func (s *StorageService) listReposByStars(q RepoQuery, page Page) ([]models.Repo, string, error) {
+	prefix := starsIndexPrefix()
+	opts, err := page.scanOptions(prefix)
+	if err != nil {
+		return nil, "", err
+	}
+	opts.Reverse = true
+	if opts.Start == "" && q.MaxStars > 0 {
+		opts.Start = prefix + starsIndexValue(q.MaxStars)
+	}
+
+	repos := []models.Repo{}
+	next, err := s.scanMatching(opts, func(k string) (string, error) {
+		stars, id, _ := strings.Cut(strings.TrimPrefix(k, prefix), ":")
+		if n, _ := strconv.Atoi(stars); n < q.MinStars {
+			return "", database.ErrStop
+		}
+		return "repo:" + id, nil
+	}, matchRepos(q, &repos))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to scan stars index: %w", err)
+	}
+	return repos, next, nil
+}
+
+// matchRepos keeps the repos matching q in repos
+func matchRepos(q RepoQuery, repos *[]models.Repo) func(v []byte) (bool, error) {
+	return func(v []byte) (bool, error) {
+		var repo models.Repo
+		if err := json.Unmarshal(v, &repo); err != nil {
+			return false, err
+		}
+		if !q.matches(repo) {
+			return false, nil
+		}
+		*repos = append(*repos, repo)
+		return true, nil
+	}
+}
+
+// ActivityQuery filters issues or pull requests; zero fields match
+// everything
+type ActivityQuery struct {
+	RepoID string
+	State  string
+	Author string
+}
+
+// activityPage pages through the issues or pull requests (kind "issue" or
+// "pr") of q's repo by its state, or if no state is given its author, and
+// passes each record to keep until page.Limit were kept. It returns the
+// cursor of the next page.
+func (s *StorageService) activityPage(kind string, q ActivityQuery, page Page, keep func(v []byte) (bool, error)) (string, error) {
+	recordPrefix := kind + ":"
+	scoped := func(prefix string) string {
+		if q.RepoID != "" {
+			return prefix + q.RepoID + "/"
+		}
+		return prefix
+	}
+
+	// base is the part of the scanned keys that precedes the record ID
+	var prefix, base string
+	switch {
+	case q.State != "":
+		base = activityIndexPrefix(kind, "state", q.State)
+	case q.Author != "":
+		base = activityIndexPrefix(kind, "author", q.Author)
+	default:
+		base = recordPrefix
+	}
+	prefix = scoped(base)
+
+	opts, err := page.scanOptions(prefix)
+	if err != nil {
+		return "", err
+	}
+	next, err := s.scanMatching(opts, func(k string) (string, error) {
+		return recordPrefix + strings.TrimPrefix(k, base), nil
+	}, keep)
+	if err != nil {
+		return "", fmt.Errorf("failed to scan %s keys: %w", kind, err)
+	}
+	return next, nil
+}
+
+// ListIssues returns a page of the issues matching q, by repo and ID, and
+// the cursor of the next page. The state (or author) index picks the range
+// scanned; when both are given, the author is checked on the issues read.
+func (s *StorageService) ListIssues(q ActivityQuery, page Page) ([]models.Issue, string, error) {
+	issues := []models.Issue{}
+	next, err := s.activityPage("issue", q, page, func(v []byte) (bool, error) {
+		var issue models.Issue
+		if err := json.Unmarshal(v, &issue); err != nil {
+			return false, err
+		}
+		if q.Author != "" && !strings.EqualFold(issue.Author, q.Author) {
+			return false, nil
+		}
+		issues = append(issues, issue)
+		return true, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return issues, next, nil
+}
+
+// ListPullRequests is ListIssues for pull requests
+func (s *StorageService) ListPullRequests(q ActivityQuery, page Page) ([]models.PullRequest, string, error) {
+	prs := []models.PullRequest{}
+	next, err := s.activityPage("pr", q, page, func(v []byte) (bool, error) {
+		var pr models.PullRequest
+		if err := json.Unmarshal(v, &pr); err != nil {
+			return false, err
+		}
+		if q.Author != "" && !strings.EqualFold(pr.Author, q.Author) {
+			return false, nil
+		}
+		prs = append(prs, pr)
+		return true, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return prs, next, nil
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+
+	"Fyne-on/pkg/database"
+	"Fyne-on/pkg/models"
+)
+
+func newTestStorage(t *testing.T) (*StorageService, *database.BadgerDB) {
+	t.Helper()
+	db, err := database.OpenDB(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewStorageService(db), db
+}
+
+func repoIDs(repos []models.Repo) []string {
+	ids := []string{}
+	for _, r := range repos {
+		ids = append(ids, r.Owner+"/"+r.Name)
+	}
+	return ids
+}
+
+func TestQueryRepos(t *testing.T) {
+	s, _ := newTestStorage(t)
+	for _, r := range []models.Repo{
+		{Owner: "acme", Name: "api", Language: "Go", Stars: 120},
+		{Owner: "acme", Name: "web", Language: "TypeScript", Stars: 40},
+		{Owner: "octo", Name: "cli", Language: "Go", Stars: 900},
+		{Owner: "octo", Name: "docs", Stars: 5, Source: models.SourceGitLab},
+	} {
+		if _, err := s.SaveRepo(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	query := func(q RepoQuery) []string {
+		repos, _, err := s.ListRepos(q, Page{})
+		if err != nil {
+			t.Fatalf("ListRepos(%+v) failed: %v", q, err)
+		}
+		return repoIDs(repos)
+	}
+	cases := []struct {
+		q    RepoQuery
+		want []string
+	}{
+		{RepoQuery{Language: "go"}, []string{"acme/api", "octo/cli"}},
+		{RepoQuery{Owner: "acme", MinStars: 100}, []string{"acme/api"}},
+		{RepoQuery{Source: models.SourceGitLab}, []string{"octo/docs"}},
+		{RepoQuery{MinStars: 40, MaxStars: 500}, []string{"acme/api", "acme/web"}},
+		{RepoQuery{MinStars: 1}, []string{"octo/cli", "acme/api", "acme/web", "octo/docs"}},
+	}
+	for _, c := range cases {
+		if got := query(c.q); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("ListRepos(%+v): expected %v, got %v", c.q, c.want, got)
+		}
+	}
+
+	// Updating a record moves its index keys
+	s.SaveRepo(models.Repo{Owner: "acme", Name: "api", Language: "Rust", Stars: 120})
+	if got := query(RepoQuery{Language: "Go"}); !reflect.DeepEqual(got, []string{"octo/cli"}) {
+		t.Errorf("Expected acme/api to leave the Go index, got %v", got)
+	}
+
+	s.DeleteRepo("octo", "cli")
+	if got := query(RepoQuery{MinStars: 500}); len(got) != 0 {
+		t.Errorf("Expected deleted repo to leave the stars index, got %v", got)
+	}
+}
+
+func TestQueryIssues(t *testing.T) {
+	s, _ := newTestStorage(t)
+	for _, is := range []models.Issue{
+		{RepoID: "acme/api", ID: "1", State: "open", Author: "Alice"},
+		{RepoID: "acme/api", ID: "2", State: "closed", Author: "bob"},
+		{RepoID: "acme/web", ID: "3", State: "open", Author: "bob"},
+	} {
+		if _, err := s.SaveIssue(is); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids := func(q ActivityQuery) []string {
+		issues, _, err := s.ListIssues(q, Page{})
+		if err != nil {
+			t.Fatalf("ListIssues(%+v) failed: %v", q, err)
+		}
+		out := []string{}
+		for _, is := range issues {
+			out = append(out, is.ID)
+		}
+		return out
+	}
+	if got := ids(ActivityQuery{State: "open"}); !reflect.DeepEqual(got, []string{"1", "3"}) {
+		t.Errorf("Expected open issues 1 and 3, got %v", got)
+	}
+	if got := ids(ActivityQuery{RepoID: "acme/api", State: "open"}); !reflect.DeepEqual(got, []string{"1"}) {
+		t.Errorf("Expected open issue 1 of acme/api, got %v", got)
+	}
+	if got := ids(ActivityQuery{Author: "alice"}); !reflect.DeepEqual(got, []string{"1"}) {
+		t.Errorf("Expected alice's issue 1, got %v", got)
+	}
+	if got := ids(ActivityQuery{State: "open", Author: "bob"}); !reflect.DeepEqual(got, []string{"3"}) {
+		t.Errorf("Expected bob's open issue 3, got %v", got)
+	}
+	if got := ids(ActivityQuery{RepoID: "acme/api"}); !reflect.DeepEqual(got, []string{"1", "2"}) {
+		t.Errorf("Expected both issues of acme/api, got %v", got)
+	}
+}
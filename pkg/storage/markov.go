@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"Fyne-on/pkg/markov"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	markovMetaKey     = "markov:meta"
+	markovStatePrefix = "markov:state:"
+)
+
+// MarkovMeta describes the last persisted Markov chain checkpoint
+type MarkovMeta struct {
+	Seed        int64     `json:"seed"`
+	Draws       uint64    `json:"draws"`
+	States      int       `json:"states"`
+	Transitions int       `json:"transitions"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// MarkovState is the persisted form of a single state and its transitions
+type MarkovState struct {
+	ID          string              `json:"id"`
+	Transitions []markov.Transition `json:"transitions"`
+}
+
+// SaveMarkovChain checkpoints the chain under the markov: prefix.
+// Every state is stored under its own key so it can be inspected without
+// loading the whole chain; states that no longer exist are removed.
+func (s *StorageService) SaveMarkovChain(mc *markov.MarkovChain) error {
+	snap := mc.Snapshot()
+
+	items := make(map[string]interface{}, len(snap.Transitions)+1)
+	transitions := 0
+	for id, next := range snap.Transitions {
+		items[markovStatePrefix+id] = MarkovState{ID: id, Transitions: next}
+		for _, t := range next {
+			transitions += t.Count
+		}
+	}
+	items[markovMetaKey] = MarkovMeta{
+		Seed:        snap.Seed,
+		Draws:       snap.Draws,
+		States:      len(snap.Transitions),
+		Transitions: transitions,
+		UpdatedAt:   time.Now(),
+	}
+
+	existing, err := s.db.KeysWithPrefix(markovStatePrefix)
+	if err != nil {
+		return fmt.Errorf("failed to list markov states: %w", err)
+	}
+	stale := []string{}
+	for _, key := range existing {
+		if _, ok := snap.Transitions[strings.TrimPrefix(key, markovStatePrefix)]; !ok {
+			stale = append(stale, key)
+		}
+	}
+	if len(stale) > 0 {
+		if err := s.db.DeleteBatch(stale); err != nil {
+			return fmt.Errorf("failed to remove stale markov states: %w", err)
+		}
+	}
+
+	if err := s.db.SetBatch(items); err != nil {
+		return fmt.Errorf("failed to save markov chain: %w", err)
+	}
+	return nil
+}
+
+// LoadMarkovChain restores the last checkpointed chain
+func (s *StorageService) LoadMarkovChain() (*markov.MarkovChain, error) {
+	var meta MarkovMeta
+	if err := s.db.GetJSON(markovMetaKey, &meta); err != nil {
+		return nil, fmt.Errorf("markov chain not found: %w", err)
+	}
+
+	snap := markov.Snapshot{
+		Seed:        meta.Seed,
+		Draws:       meta.Draws,
+		Transitions: make(map[string][]markov.Transition, meta.States),
+	}
+	err := s.db.IteratePrefix(markovStatePrefix, func(_ []byte, v []byte) error {
+		var state MarkovState
+		if err := json.Unmarshal(v, &state); err != nil {
+			return err
+		}
+		snap.Transitions[state.ID] = state.Transitions
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load markov states: %w", err)
+	}
+
+	return markov.Restore(snap), nil
+}
+
+// GetMarkovMeta returns information about the last checkpoint
+func (s *StorageService) GetMarkovMeta() (*MarkovMeta, error) {
+	var meta MarkovMeta
+	if err := s.db.GetJSON(markovMetaKey, &meta); err != nil {
+		return nil, fmt.Errorf("markov chain not found: %w", err)
+	}
+	return &meta, nil
+}
+
+// GetMarkovState returns a single persisted state
+func (s *StorageService) GetMarkovState(id string) (*MarkovState, error) {
+	var state MarkovState
+	if err := s.db.GetJSON(markovStatePrefix+id, &state); err != nil {
+		return nil, fmt.Errorf("markov state not found: %w", err)
+	}
+
+	total := 0
+	for _, t := range state.Transitions {
+		total += t.Count
+	}
+	for i := range state.Transitions {
+		state.Transitions[i].Probability = float64(state.Transitions[i].Count) / float64(total)
+	}
+	return &state, nil
+}
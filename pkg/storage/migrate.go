@@ -0,0 +1,317 @@
+package storage
+
+import (
+	"Fyne-on/pkg/database"
+	"Fyne-on/pkg/models"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// Records carry no version of their own; the database does. Migrations run
+// in order, each once, and the version of the last one applied is stored
+// under meta:schema_version. When a models or key layout change leaves
+// stored data inconsistent, append a migration to migrations; never
+// reorder, renumber or remove one.
+const (
+	schemaVersionKey = "meta:schema_version"
+	// migrationBatchSize is the number of records read and written at once
+	migrationBatchSize = 1000
+)
+
+// Migration brings stored data from schema version Version-1 to Version.
+// Apply must be safe to run again on data it already migrated, since an
+// interrupted migration is rerun from the start.
+type Migration struct {
+	Version     int
+	Description string
+	Apply       func(m *Migrator) error
+}
+
+var migrations = []Migration{
+	{1, "set the source of records saved before multi-source crawling", backfillSources},
+	{2, "hash repos, issues and pull requests by content", rehashRecords},
+	{3, "rebuild secondary indexes", rebuildIndexes},
+}
+
+// Migrations returns the registered migrations in order
+func Migrations() []Migration {
+	return append([]Migration(nil), migrations...)
+}
+
+// LatestSchemaVersion is the version Migrate brings databases to by default
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// MigrateOptions selects what Migrate does
+type MigrateOptions struct {
+	// To is the version to roll forward to, 0 for the latest
+	To int
+	// DryRun counts the records each pending migration would change
+	// without writing anything
+	DryRun bool
+}
+
+// MigrationResult reports a migration Migrate applied, or would apply
+type MigrationResult struct {
+	Version     int    `json:"version"`
+	Description string `json:"description"`
+	Scanned     int    `json:"scanned"`
+	Changed     int    `json:"changed"`
+}
+
+// Migrator is handed to a migration to read and rewrite records
+type Migrator struct {
+	s       *StorageService
+	DryRun  bool
+	Scanned int // records read
+	Changed int // records changed, or that would be in a dry run
+}
+
+// RewriteFunc returns the key and value a record should have. Returning
+// the record unchanged leaves it alone and an empty key deletes it.
+type RewriteFunc func(key string, value []byte) (string, []byte, error)
+
+// Rewrite calls fn for every key with prefix and writes what it changes,
+// a batch at a time, logging progress. Keys fn moves must leave prefix
+// (or be left alone when visited again).
+func (m *Migrator) Rewrite(prefix string, fn RewriteFunc) error {
+	scanned, changed := 0, 0
+	err := m.s.scanBatches(prefix, true, func(keys []string, values [][]byte) error {
+		sets := map[string][]byte{}
+		var deletes []string
+		for i, k := range keys {
+			v := values[i]
+			scanned++
+			newKey, newValue, err := fn(k, v)
+			if err != nil {
+				return fmt.Errorf("failed to migrate %s: %w", k, err)
+			}
+			switch {
+			case newKey == "":
+				deletes = append(deletes, k)
+			case newKey != k:
+				deletes = append(deletes, k)
+				sets[newKey] = newValue
+			case !bytes.Equal(newValue, v):
+				sets[k] = newValue
+			default:
+				continue
+			}
+			changed++
+		}
+		if !m.DryRun && (len(sets) > 0 || len(deletes) > 0) {
+			if err := m.s.db.WriteBatch(sets, deletes); err != nil {
+				return fmt.Errorf("failed to write batch: %w", err)
+			}
+		}
+		log.Printf("  %s %d scanned, %d changed", prefix, scanned, changed)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	m.Scanned += scanned
+	m.Changed += changed
+	return nil
+}
+
+// scanBatches calls fn with the keys with prefix, and their values if
+// values is set, migrationBatchSize at a time. Each batch is read in its
+// own transaction, so fn may write.
+func (s *StorageService) scanBatches(prefix string, values bool, fn func(keys []string, values [][]byte) error) error {
+	opts := database.ScanOptions{Prefix: prefix, Values: values, Limit: migrationBatchSize}
+	for {
+		var keys []string
+		var vals [][]byte
+		more, err := s.db.Scan(opts, func(k string, v []byte) error {
+			keys = append(keys, k)
+			vals = append(vals, v)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := fn(keys, vals); err != nil {
+				return err
+			}
+			opts.Start, opts.After = keys[len(keys)-1], true
+		}
+		if !more {
+			return nil
+		}
+	}
+}
+
+// SchemaVersion returns the database's schema version, 0 for databases no
+// migration ran on
+func (s *StorageService) SchemaVersion() (int, error) {
+	exists, err := s.db.Exists(schemaVersionKey)
+	if err != nil || !exists {
+		return 0, err
+	}
+	var version int
+	if err := s.db.GetJSON(schemaVersionKey, &version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// Migrate applies the migrations after the database's schema version up to
+// opts.To, in order, storing the version after each one. A dry run counts
+// each pending migration's changes against the current data, so it cannot
+// see changes an earlier pending migration would make.
+func (s *StorageService) Migrate(opts MigrateOptions) ([]MigrationResult, error) {
+	current, err := s.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	to := opts.To
+	if to == 0 {
+		to = LatestSchemaVersion()
+	}
+	if to > LatestSchemaVersion() {
+		return nil, fmt.Errorf("unknown schema version %d (latest is %d)", to, LatestSchemaVersion())
+	}
+	if to < current {
+		return nil, fmt.Errorf("schema version %d is newer than %d; migrations only roll forward", current, to)
+	}
+
+	results := []MigrationResult{}
+	for _, mig := range migrations {
+		if mig.Version <= current || mig.Version > to {
+			continue
+		}
+		if opts.DryRun {
+			log.Printf("Checking migration %d: %s", mig.Version, mig.Description)
+		} else {
+			log.Printf("Applying migration %d: %s", mig.Version, mig.Description)
+		}
+
+		m := &Migrator{s: s, DryRun: opts.DryRun}
+		if err := mig.Apply(m); err != nil {
+			return results, fmt.Errorf("migration %d failed: %w", mig.Version, err)
+		}
+		results = append(results, MigrationResult{
+			Version:     mig.Version,
+			Description: mig.Description,
+			Scanned:     m.Scanned,
+			Changed:     m.Changed,
+		})
+		if opts.DryRun {
+			continue
+		}
+		if err := s.db.Set(schemaVersionKey, mig.Version); err != nil {
+			return results, fmt.Errorf("failed to store schema version: %w", err)
+		}
+	}
+	return results, nil
+}
+
+// setField returns record with field set to value; other fields are kept
+// as stored
+func setField(record []byte, field string, value interface{}) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(record, &fields); err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	fields[field] = raw
+	return json.Marshal(fields)
+}
+
+// backfillSources marks records without a source as GitHub records, which
+// is what they are
+func backfillSources(m *Migrator) error {
+	for _, prefix := range []string{"repo:", "issue:", "pr:", "contact:"} {
+		err := m.Rewrite(prefix, func(k string, v []byte) (string, []byte, error) {
+			var r struct {
+				Source string `json:"source"`
+			}
+			if err := json.Unmarshal(v, &r); err != nil {
+				return "", nil, err
+			}
+			if r.Source != "" {
+				return k, v, nil
+			}
+			v, err := setField(v, "source", models.SourceGitHub)
+			return k, v, err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rehashRecords replaces the identity hashes stored before content hashing.
+// Records are decoded into their model and written back as the model
+// encodes them, so the hash covers every field a later save writes and
+// fields missing from old records don't show up as changes then.
+func rehashRecords(m *Migrator) error {
+	for _, prefix := range []string{"repo:", "issue:", "pr:"} {
+		err := m.Rewrite(prefix, func(k string, v []byte) (string, []byte, error) {
+			var record interface{}
+			var err error
+			switch prefix {
+			case "repo:":
+				var r models.Repo
+				if err := json.Unmarshal(v, &r); err != nil {
+					return "", nil, err
+				}
+				r.Hash, err = contentHash(r)
+				record = r
+			case "issue:":
+				var r models.Issue
+				if err := json.Unmarshal(v, &r); err != nil {
+					return "", nil, err
+				}
+				r.Hash, err = contentHash(r)
+				record = r
+			case "pr:":
+				var r models.PullRequest
+				if err := json.Unmarshal(v, &r); err != nil {
+					return "", nil, err
+				}
+				r.Hash, err = contentHash(r)
+				record = r
+			}
+			if err != nil {
+				return "", nil, err
+			}
+			data, err := json.Marshal(record)
+			return k, data, err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rebuildIndexes indexes records written before secondary indexes existed
+// and replaces the index version key used before schema versions
+func rebuildIndexes(m *Migrator) error {
+	for _, prefix := range []string{"repo:", "issue:", "pr:"} {
+		n, err := m.s.db.CountByPrefix(prefix)
+		if err != nil {
+			return err
+		}
+		m.Scanned += n
+	}
+	m.Changed = m.Scanned
+	if m.DryRun {
+		return nil
+	}
+	if err := m.s.RebuildIndexes(); err != nil {
+		return err
+	}
+	log.Printf("  %d records indexed", m.Changed)
+	return m.s.db.Delete("meta:index_version")
+}
@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"Fyne-on/pkg/models"
+)
+
+func TestMigrateLegacyRecords(t *testing.T) {
+	s, db := newTestStorage(t)
+	// Records written before sources, content hashes and indexes existed
+	db.Set("repo:acme/api", models.Repo{Owner: "acme", Name: "api", Language: "Go", Stars: 3, Hash: "identity"})
+	db.Set("issue:acme/api/1", models.Issue{RepoID: "acme/api", ID: "1", State: "open", Hash: "identity"})
+	db.Set("meta:index_version", 1)
+
+	results, err := s.Migrate(MigrateOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if len(results) != LatestSchemaVersion() || results[0].Changed != 2 {
+		t.Fatalf("Expected every migration pending and 2 sources to set, got %+v", results)
+	}
+	if v, _ := s.SchemaVersion(); v != 0 {
+		t.Errorf("Expected dry run to leave version 0, got %d", v)
+	}
+	if repo, _ := s.GetRepo("acme", "api"); repo.Source != "" {
+		t.Errorf("Expected dry run to write nothing, got source %q", repo.Source)
+	}
+
+	if _, err := s.Migrate(MigrateOptions{To: 1}); err != nil {
+		t.Fatalf("Migrate to 1 failed: %v", err)
+	}
+	if v, _ := s.SchemaVersion(); v != 1 {
+		t.Errorf("Expected version 1, got %d", v)
+	}
+	if _, err := s.Migrate(MigrateOptions{}); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if v, _ := s.SchemaVersion(); v != LatestSchemaVersion() {
+		t.Errorf("Expected version %d, got %d", LatestSchemaVersion(), v)
+	}
+
+	repo, _ := s.GetRepo("acme", "api")
+	if repo.Source != models.SourceGitHub {
+		t.Errorf("Expected source github, got %q", repo.Source)
+	}
+	if hash, _ := contentHash(*repo); repo.Hash != hash {
+		t.Errorf("Expected content hash %s, got %s", hash, repo.Hash)
+	}
+	if got, _, _ := s.ListRepos(RepoQuery{Source: "github", Language: "go"}, Page{}); !reflect.DeepEqual(repoIDs(got), []string{"acme/api"}) {
+		t.Errorf("Expected acme/api to be indexed, got %v", repoIDs(got))
+	}
+	if issues, _, _ := s.ListIssues(ActivityQuery{State: "open"}, Page{}); len(issues) != 1 {
+		t.Errorf("Expected the open issue to be indexed, got %d", len(issues))
+	}
+	if exists, _ := db.Exists("meta:index_version"); exists {
+		t.Errorf("Expected the index version key to be dropped")
+	}
+
+	// Migrated records save without spurious changes
+	repo.Hash = ""
+	if written, _ := s.SaveRepo(*repo); written {
+		t.Errorf("Expected migrated repo to be unchanged")
+	}
+
+	if results, _ := s.Migrate(MigrateOptions{}); len(results) != 0 {
+		t.Errorf("Expected nothing pending, got %+v", results)
+	}
+	if _, err := s.Migrate(MigrateOptions{To: 1}); err == nil {
+		t.Errorf("Expected an error migrating backwards")
+	}
+	if _, err := s.Migrate(MigrateOptions{To: LatestSchemaVersion() + 1}); err == nil {
+		t.Errorf("Expected an error for an unknown version")
+	}
+}
+
+func TestRewriteBatches(t *testing.T) {
+	s, db := newTestStorage(t)
+	for i := 0; i < migrationBatchSize+5; i++ {
+		db.Set(fmt.Sprintf("old:%05d", i), i)
+	}
+
+	m := &Migrator{s: s}
+	err := m.Rewrite("old:", func(k string, v []byte) (string, []byte, error) {
+		if k == "old:00003" {
+			return "", nil, nil
+		}
+		return "new:" + k[len("old:"):], v, nil
+	})
+	if err != nil {
+		t.Fatalf("Rewrite failed: %v", err)
+	}
+	if m.Scanned != migrationBatchSize+5 || m.Changed != m.Scanned {
+		t.Errorf("Expected %d records scanned and changed, got %d, %d", migrationBatchSize+5, m.Scanned, m.Changed)
+	}
+	left, _ := db.CountByPrefix("old:")
+	moved, _ := db.CountByPrefix("new:")
+	if left != 0 || moved != migrationBatchSize+4 {
+		t.Errorf("Expected all keys moved but one deleted, got %d left, %d moved", left, moved)
+	}
+	var v int
+	if err := db.GetJSON("new:01004", &v); err != nil || v != 1004 {
+		t.Errorf("Expected values to move with their keys, got %d (%v)", v, err)
+	}
+}
+
+func TestRebuildIndexesBatches(t *testing.T) {
+	s, db := newTestStorage(t)
+	// More records and stale index keys than fit in one batch
+	n := migrationBatchSize + 5
+	stale := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		db.Set(fmt.Sprintf("repo:acme/r%05d", i), models.Repo{Owner: "acme", Name: fmt.Sprintf("r%05d", i), Language: "Go"})
+		stale = append(stale, fmt.Sprintf("%sgone/r%05d", repoIndexPrefix("lang", "rust"), i))
+	}
+	db.PutKeys(stale)
+
+	if err := s.RebuildIndexes(); err != nil {
+		t.Fatalf("RebuildIndexes failed: %v", err)
+	}
+	if left, _ := db.CountByPrefix(repoIndexPrefix("lang", "rust")); left != 0 {
+		t.Errorf("Expected stale index keys dropped, got %d", left)
+	}
+	if indexed, _ := db.CountByPrefix(repoIndexPrefix("lang", "go")); indexed != n {
+		t.Errorf("Expected %d repos indexed by language, got %d", n, indexed)
+	}
+}
+
+func TestRehashNormalizesLegacyRecords(t *testing.T) {
+	s, db := newTestStorage(t)
+	// Stored before forks, watchers and license were part of the model
+	legacy := map[string]interface{}{"owner": "acme", "name": "api", "stars": 3, "source": "github", "hash": "identity"}
+	if err := db.Set("repo:acme/api", legacy); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Migrate(MigrateOptions{To: 2}); err != nil {
+		t.Fatalf("Migrate to 2 failed: %v", err)
+	}
+
+	repo, _ := s.GetRepo("acme", "api")
+	if hash, _ := contentHash(*repo); repo.Hash != hash {
+		t.Errorf("Expected content hash %s, got %s", hash, repo.Hash)
+	}
+	if written, _ := s.SaveRepo(*repo); written {
+		t.Errorf("Expected the migrated repo to be unchanged")
+	}
+	if changes, _, _ := s.GetRepoHistory("acme", "api", Page{}); len(changes) != 0 {
+		t.Errorf("Expected no change for fields the legacy record lacked, got %+v", changes)
+	}
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"Fyne-on/pkg/database"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// ErrInvalidCursor is returned for cursors that were not issued by the
+// listing they are passed to
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Page sizes clients may ask for
+const (
+	DefaultPageLimit = 100
+	MaxPageLimit     = 1000
+)
+
+// Page asks for up to Limit items following Cursor, the next cursor
+// returned with the previous page ("" for the first page). Limit 0 lists
+// everything.
+//
+// Cursors are the base64 encoded Badger key of the last item returned, so
+// a page costs a seek plus Limit reads however deep into the listing it
+// is, and items written or deleted between pages neither shift nor repeat
+// the ones that follow.
+type Page struct {
+	Limit  int
+	Cursor string
+}
+
+func encodeCursor(key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+// scanOptions returns the options scanning prefix from the page's cursor
+func (p Page) scanOptions(prefix string) (database.ScanOptions, error) {
+	opts := database.ScanOptions{Prefix: prefix, Limit: p.Limit}
+	if p.Cursor != "" {
+		key, err := base64.RawURLEncoding.DecodeString(p.Cursor)
+		if err != nil || !strings.HasPrefix(string(key), prefix) {
+			return opts, ErrInvalidCursor
+		}
+		opts.Start, opts.After = string(key), true
+	}
+	return opts, nil
+}
+
+// scanPage runs a paged scan and returns the cursor of the next page, ""
+// after the last one
+func (s *StorageService) scanPage(opts database.ScanOptions, fn func(key string, value []byte) error) (string, error) {
+	last := ""
+	more, err := s.db.Scan(opts, func(k string, v []byte) error {
+		last = k
+		return fn(k, v)
+	})
+	if err != nil || !more {
+		return "", err
+	}
+	return encodeCursor(last), nil
+}
+
+// scanMatching is scanPage for listings filtered on the records an index
+// points to. It scans index keys in batches of opts.Limit, reads the record
+// each one names (recordKey) and passes it to keep, until opts.Limit records
+// were kept, so only the last page holds fewer. The cursor is the index key
+// of the last record read.
+func (s *StorageService) scanMatching(opts database.ScanOptions, recordKey func(indexKey string) (string, error), keep func(value []byte) (bool, error)) (string, error) {
+	kept := 0
+	for {
+		var indexKeys, recordKeys []string
+		indexKeyOf := map[string]string{}
+		more, err := s.db.Scan(opts, func(k string, _ []byte) error {
+			rk, err := recordKey(k)
+			if err != nil {
+				return err
+			}
+			indexKeys = append(indexKeys, k)
+			recordKeys = append(recordKeys, rk)
+			indexKeyOf[rk] = k
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+
+		last := ""
+		err = s.db.GetEach(recordKeys, func(rk string, v []byte) error {
+			ok, err := keep(v)
+			if err != nil {
+				return err
+			}
+			if ok {
+				kept++
+			}
+			if opts.Limit > 0 && kept == opts.Limit {
+				last = indexKeyOf[rk]
+				return database.ErrStop
+			}
+			return nil
+		})
+		if errors.Is(err, database.ErrStop) {
+			if more || last != indexKeys[len(indexKeys)-1] {
+				return encodeCursor(last), nil
+			}
+			return "", nil
+		}
+		if err != nil || !more {
+			return "", err
+		}
+		opts.Start, opts.After = indexKeys[len(indexKeys)-1], true
+	}
+}
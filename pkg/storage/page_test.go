@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"Fyne-on/pkg/models"
+)
+
+func TestListIssuesPages(t *testing.T) {
+	s, _ := newTestStorage(t)
+	for i := 0; i < 5; i++ {
+		state := "open"
+		if i%2 == 1 {
+			state = "closed"
+		}
+		s.SaveIssue(models.Issue{RepoID: "acme/api", ID: fmt.Sprint(i), State: state})
+	}
+	s.SaveIssue(models.Issue{RepoID: "acme/web", ID: "9", State: "open"})
+
+	collect := func(q ActivityQuery, limit int) ([]string, int) {
+		var ids []string
+		pages := 0
+		page := Page{Limit: limit}
+		for {
+			issues, next, err := s.ListIssues(q, page)
+			if err != nil {
+				t.Fatalf("ListIssues failed: %v", err)
+			}
+			pages++
+			for _, is := range issues {
+				ids = append(ids, is.RepoID+"#"+is.ID)
+			}
+			if next == "" {
+				return ids, pages
+			}
+			page.Cursor = next
+		}
+	}
+
+	ids, pages := collect(ActivityQuery{RepoID: "acme/api"}, 2)
+	if !reflect.DeepEqual(ids, []string{"acme/api#0", "acme/api#1", "acme/api#2", "acme/api#3", "acme/api#4"}) || pages != 3 {
+		t.Errorf("Expected acme/api's 5 issues in 3 pages, got %v in %d", ids, pages)
+	}
+	ids, pages = collect(ActivityQuery{State: "open"}, 2)
+	if !reflect.DeepEqual(ids, []string{"acme/api#0", "acme/api#2", "acme/api#4", "acme/web#9"}) || pages != 2 {
+		t.Errorf("Expected 4 open issues in 2 pages, got %v in %d", ids, pages)
+	}
+
+	// A cursor only resumes the listing that issued it
+	_, next, _ := s.ListIssues(ActivityQuery{RepoID: "acme/api"}, Page{Limit: 1})
+	if _, _, err := s.ListIssues(ActivityQuery{State: "open"}, Page{Limit: 1, Cursor: next}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor for another listing's cursor, got %v", err)
+	}
+	if _, _, err := s.ListContacts(Page{Cursor: "%%%"}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor for a malformed cursor, got %v", err)
+	}
+}
+
+func TestListReposByStarsPages(t *testing.T) {
+	s, _ := newTestStorage(t)
+	for i, stars := range []int{10, 50, 50, 300, 7} {
+		s.SaveRepo(models.Repo{Owner: "acme", Name: fmt.Sprintf("r%d", i), Stars: stars})
+	}
+
+	repos, next, err := s.ListRepos(RepoQuery{MinStars: 10, MaxStars: 100}, Page{Limit: 2})
+	if err != nil || !reflect.DeepEqual(repoIDs(repos), []string{"acme/r2", "acme/r1"}) || next == "" {
+		t.Fatalf("Expected first page [acme/r2 acme/r1] with a cursor, got %v %q (%v)", repoIDs(repos), next, err)
+	}
+	repos, next, _ = s.ListRepos(RepoQuery{MinStars: 10, MaxStars: 100}, Page{Limit: 2, Cursor: next})
+	if !reflect.DeepEqual(repoIDs(repos), []string{"acme/r0"}) || next != "" {
+		t.Errorf("Expected last page [acme/r0], got %v %q", repoIDs(repos), next)
+	}
+}
+
+func TestCombinedFiltersFillPages(t *testing.T) {
+	s, _ := newTestStorage(t)
+	// Only every third Go repo has stars and every third open issue is
+	// bob's, so the index ranges hold mostly records the filter drops
+	for i := 0; i < 12; i++ {
+		stars := 0
+		if i%3 == 0 {
+			stars = 50
+		}
+		s.SaveRepo(models.Repo{Owner: "acme", Name: fmt.Sprintf("r%02d", i), Language: "Go", Stars: stars})
+		author := "alice"
+		if i%3 == 0 {
+			author = "bob"
+		}
+		s.SaveIssue(models.Issue{RepoID: "acme/api", ID: fmt.Sprintf("%02d", i), State: "open", Author: author})
+	}
+
+	var repos []string
+	page := Page{Limit: 2}
+	for pages := 1; ; pages++ {
+		got, next, err := s.ListRepos(RepoQuery{Language: "go", MinStars: 10}, page)
+		if err != nil {
+			t.Fatalf("ListRepos failed: %v", err)
+		}
+		if next != "" && len(got) != 2 {
+			t.Errorf("Expected full pages before the last, got %v on page %d", repoIDs(got), pages)
+		}
+		repos = append(repos, repoIDs(got)...)
+		if next == "" {
+			break
+		}
+		page.Cursor = next
+	}
+	if !reflect.DeepEqual(repos, []string{"acme/r00", "acme/r03", "acme/r06", "acme/r09"}) {
+		t.Errorf("Expected the 4 starred Go repos, got %v", repos)
+	}
+
+	issues, next, err := s.ListIssues(ActivityQuery{State: "open", Author: "bob"}, Page{Limit: 3})
+	if err != nil || len(issues) != 3 || next == "" {
+		t.Fatalf("Expected a full first page of bob's issues with a cursor, got %d %q (%v)", len(issues), next, err)
+	}
+	issues, next, _ = s.ListIssues(ActivityQuery{State: "open", Author: "bob"}, Page{Limit: 3, Cursor: next})
+	if len(issues) != 1 || issues[0].ID != "09" || next != "" {
+		t.Errorf("Expected bob's last issue on the last page, got %+v %q", issues, next)
+	}
+}
+
+func TestRankingsPages(t *testing.T) {
+	s, _ := newTestStorage(t)
+	scores := map[string]float64{"acme/a": 0.4, "acme/b": 0.3, "acme/c": 0.2, "bob": 0.1}
+	if err := s.SaveRankings(scores, RankingMeta{}); err != nil {
+		t.Fatalf("SaveRankings failed: %v", err)
+	}
+
+	first, next, err := s.GetRepoRankings(Page{Limit: 2})
+	if err != nil || len(first) != 2 || first[0].ID != "acme/a" || next == "" {
+		t.Fatalf("Expected the top 2 repos with a cursor, got %+v %q (%v)", first, next, err)
+	}
+	rest, next, _ := s.GetRepoRankings(Page{Limit: 2, Cursor: next})
+	if len(rest) != 1 || rest[0].ID != "acme/c" || rest[0].Rank != 3 || next != "" {
+		t.Errorf("Expected acme/c ranked 3rd on the last page, got %+v %q", rest, next)
+	}
+	if _, _, err := s.GetContactRankings(Page{Cursor: encodeCursor("rank:repo:0000000001")}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor for a repo rankings cursor, got %v", err)
+	}
+}
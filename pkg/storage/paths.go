@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"Fyne-on/pkg/markov"
+	"Fyne-on/pkg/models"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+const crawlPathPrefix = "path:"
+
+var crawlPathSeq atomic.Uint64
+
+// SaveCrawlPath appends a traversed path. Keys are time ordered so
+// paths are replayed in the order they were crawled.
+func (s *StorageService) SaveCrawlPath(states []string, strategy string) error {
+	if len(states) < 2 {
+		return nil
+	}
+	now := time.Now()
+	key := fmt.Sprintf("%s%020d:%06d", crawlPathPrefix, now.UnixNano(), crawlPathSeq.Add(1)%1000000)
+	return s.db.Set(key, models.CrawlPath{
+		States:    states,
+		Strategy:  strategy,
+		CreatedAt: now,
+	})
+}
+
+// IterateCrawlPaths calls fn for every stored path, oldest first
+func (s *StorageService) IterateCrawlPaths(fn func(models.CrawlPath) error) error {
+	return s.db.IteratePrefix(crawlPathPrefix, func(_ []byte, v []byte) error {
+		var path models.CrawlPath
+		if err := json.Unmarshal(v, &path); err != nil {
+			return err
+		}
+		return fn(path)
+	})
+}
+
+// TrainPathChain builds an order-k chain from every stored crawl path
+func (s *StorageService) TrainPathChain(order int, seed int64) (*markov.HigherOrderChain, int, error) {
+	hc := markov.NewHigherOrderChain(order, seed)
+	paths := 0
+	err := s.IterateCrawlPaths(func(p models.CrawlPath) error {
+		hc.Train(p.States)
+		paths++
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read crawl paths: %w", err)
+	}
+	return hc, paths, nil
+}
@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	rankMetaKey       = "rank:meta"
+	rankRepoPrefix    = "rank:repo:"
+	rankContactPrefix = "rank:contact:"
+)
+
+// Ranking is the centrality score of a repo or contact
+type Ranking struct {
+	ID    string  `json:"id"`
+	Score float64 `json:"score"`
+	Rank  int     `json:"rank"`
+}
+
+// RankingMeta describes the last rankings computation
+type RankingMeta struct {
+	Damping    float64   `json:"damping"`
+	Iterations int       `json:"iterations"`
+	Converged  bool      `json:"converged"`
+	Repos      int       `json:"repos"`
+	Contacts   int       `json:"contacts"`
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// SaveRankings replaces the stored rankings with new scores. States that
+// look like "owner/name" are repos, everything else is a contact. Entries
+// are keyed by rank position so reading the top N is a prefix scan.
+func (s *StorageService) SaveRankings(scores map[string]float64, meta RankingMeta) error {
+	repos := []Ranking{}
+	contacts := []Ranking{}
+	for id, score := range scores {
+		if strings.Contains(id, "/") {
+			repos = append(repos, Ranking{ID: id, Score: score})
+		} else {
+			contacts = append(contacts, Ranking{ID: id, Score: score})
+		}
+	}
+
+	items := make(map[string]interface{}, len(scores)+1)
+	for prefix, list := range map[string][]Ranking{rankRepoPrefix: repos, rankContactPrefix: contacts} {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Score != list[j].Score {
+				return list[i].Score > list[j].Score
+			}
+			return list[i].ID < list[j].ID
+		})
+		for i := range list {
+			list[i].Rank = i + 1
+			items[fmt.Sprintf("%s%010d", prefix, i+1)] = list[i]
+		}
+	}
+
+	meta.Repos = len(repos)
+	meta.Contacts = len(contacts)
+	meta.ComputedAt = time.Now()
+	items[rankMetaKey] = meta
+
+	old, err := s.db.KeysWithPrefix("rank:")
+	if err != nil {
+		return fmt.Errorf("failed to list rankings: %w", err)
+	}
+	if err := s.db.DeleteBatch(old); err != nil {
+		return fmt.Errorf("failed to clear rankings: %w", err)
+	}
+	if err := s.db.SetBatch(items); err != nil {
+		return fmt.Errorf("failed to save rankings: %w", err)
+	}
+	return nil
+}
+
+// GetRepoRankings returns a page of repos ordered by score and the cursor
+// of the next page
+func (s *StorageService) GetRepoRankings(page Page) ([]Ranking, string, error) {
+	return s.getRankings(rankRepoPrefix, page)
+}
+
+// GetContactRankings returns a page of contacts ordered by score and the
+// cursor of the next page
+func (s *StorageService) GetContactRankings(page Page) ([]Ranking, string, error) {
+	return s.getRankings(rankContactPrefix, page)
+}
+
+// GetRankingMeta returns information about the last rankings computation
+func (s *StorageService) GetRankingMeta() (*RankingMeta, error) {
+	var meta RankingMeta
+	if err := s.db.GetJSON(rankMetaKey, &meta); err != nil {
+		return nil, fmt.Errorf("rankings not computed: %w", err)
+	}
+	return &meta, nil
+}
+
+func (s *StorageService) getRankings(prefix string, page Page) ([]Ranking, string, error) {
+	opts, err := page.scanOptions(prefix)
+	if err != nil {
+		return nil, "", err
+	}
+	opts.Values = true
+	out := []Ranking{}
+	next, err := s.scanPage(opts, func(_ string, v []byte) error {
+		var r Ranking
+		if err := json.Unmarshal(v, &r); err != nil {
+			return err
+		}
+		out = append(out, r)
+		return nil
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list rankings: %w", err)
+	}
+	return out, next, nil
+}
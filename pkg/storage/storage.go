@@ -20,6 +20,9 @@ func NewStorageService(db *database.BadgerDB) *StorageService {
 // SaveContact saves or updates a contact
 func (s *StorageService) SaveContact(contact models.Contact) error {
 	key := "contact:" + contact.Login
+	if contact.Source == "" {
+		contact.Source = models.SourceGitHub
+	}
 
 	// Generate hash if not set
 	if contact.Hash == "" {
@@ -30,6 +33,41 @@ func (s *StorageService) SaveContact(contact models.Contact) error {
 	return s.db.Set(key, contact)
 }
 
+// MergeContact saves contact, keeping the stored values of the fields it
+// leaves empty, so a bare contributor record does not erase a fetched
+// profile. It returns the contact as saved.
+func (s *StorageService) MergeContact(contact models.Contact) (models.Contact, error) {
+	if stored, err := s.GetContact(contact.Login); err == nil {
+		for _, f := range []struct {
+			dst *string
+			src string
+		}{
+			{&contact.ID, stored.ID},
+			{&contact.URL, stored.URL},
+			{&contact.Avatar, stored.Avatar},
+			{&contact.Company, stored.Company},
+			{&contact.Email, stored.Email},
+			{&contact.Location, stored.Location},
+			{&contact.Bio, stored.Bio},
+			{&contact.Source, stored.Source},
+		} {
+			if *f.dst == "" {
+				*f.dst = f.src
+			}
+		}
+		if contact.Followers == 0 {
+			contact.Followers = stored.Followers
+		}
+		if contact.Contributions == 0 {
+			contact.Contributions = stored.Contributions
+		}
+	}
+	if contact.Source == "" {
+		contact.Source = models.SourceGitHub
+	}
+	return contact, s.SaveContact(contact)
+}
+
 // GetContact retrieves a contact
 func (s *StorageService) GetContact(login string) (*models.Contact, error) {
 	key := "contact:" + login
@@ -41,32 +79,85 @@ func (s *StorageService) GetContact(login string) (*models.Contact, error) {
 	return &contact, nil
 }
 
-// SaveRepo saves or updates a repository
+// SaveRepo saves or updates a repository. Fields repo leaves empty keep
+// their stored values, so partial records such as trending rows update
+// what they carry without erasing the rest. It reports whether the repo
+// was written, which it is not when its content is unchanged.
 func (s *StorageService) SaveRepo(repo models.Repo) (bool, error) {
 	key := "repo:" + repo.Owner + "/" + repo.Name
+	return s.saveVersioned(key, func(prev []byte) (interface{}, string, error) {
+		merged := repo
+		if prev != nil {
+			var stored models.Repo
+			if err := json.Unmarshal(prev, &stored); err == nil {
+				mergeRepo(&merged, stored)
+			}
+		}
+		if merged.Source == "" {
+			merged.Source = models.SourceGitHub
+		}
 
-	// Generate hash if not set
-	if repo.Hash == "" {
-		repo.Hash = database.GenerateHash(repo.Owner, repo.Name, repo.URL)
-	}
+		hash, err := contentHash(merged)
+		if err != nil {
+			return nil, "", err
+		}
+		merged.Hash = hash
+		merged.UpdatedAt = time.Now()
+		return merged, hash, nil
+	}, repoIndexes)
+}
 
-	// Check if exists and hash matches
-	exists, err := s.db.Exists(key)
-	if err != nil {
-		return false, err
+// mergeRepo fills the fields repo leaves empty from stored
+func mergeRepo(repo *models.Repo, stored models.Repo) {
+	for _, f := range []struct {
+		dst *string
+		src string
+	}{
+		{&repo.ID, stored.ID},
+		{&repo.URL, stored.URL},
+		{&repo.Description, stored.Description},
+		{&repo.Language, stored.Language},
+		{&repo.Homepage, stored.Homepage},
+		{&repo.Source, stored.Source},
+	} {
+		if *f.dst == "" {
+			*f.dst = f.src
+		}
 	}
-
-	if exists {
-		var existing models.Repo
-		if err := s.db.GetJSON(key, &existing); err == nil {
-			if existing.Hash == repo.Hash {
-				return false, nil // No changes
-			}
+	for _, f := range []struct {
+		dst *int
+		src int
+	}{
+		{&repo.Stars, stored.Stars},
+		{&repo.Forks, stored.Forks},
+		{&repo.Watchers, stored.Watchers},
+	} {
+		if *f.dst == 0 {
+			*f.dst = f.src
 		}
 	}
-
-	repo.UpdatedAt = time.Now()
-	return true, s.db.Set(key, repo)
+	for _, f := range []struct {
+		dst *time.Time
+		src time.Time
+	}{
+		{&repo.PushedAt, stored.PushedAt},
+		{&repo.LastCommitAt, stored.LastCommitAt},
+		{&repo.CreatedAt, stored.CreatedAt},
+	} {
+		if f.dst.IsZero() {
+			*f.dst = f.src
+		}
+	}
+	// HasOpenLicense is only known together with License
+	if repo.License == "" {
+		repo.License, repo.HasOpenLicense = stored.License, stored.HasOpenLicense
+	}
+	if len(repo.Topics) == 0 {
+		repo.Topics = stored.Topics
+	}
+	if len(repo.Languages) == 0 {
+		repo.Languages = stored.Languages
+	}
 }
 
 // GetRepo retrieves a repository
@@ -80,133 +171,101 @@ func (s *StorageService) GetRepo(owner, name string) (*models.Repo, error) {
 	return &repo, nil
 }
 
-// SaveIssue saves or updates an issue
+// SaveIssue saves or updates an issue, unless its content is unchanged
 func (s *StorageService) SaveIssue(issue models.Issue) (bool, error) {
 	key := "issue:" + issue.RepoID + "/" + issue.ID
-
-	// Generate hash if not set
-	if issue.Hash == "" {
-		issue.Hash = database.GenerateHash(issue.RepoID, issue.ID, issue.URL)
+	if issue.Source == "" {
+		issue.Source = models.SourceGitHub
 	}
 
-	// Check if exists and hash matches
-	exists, err := s.db.Exists(key)
+	hash, err := contentHash(issue)
 	if err != nil {
 		return false, err
 	}
-
-	if exists {
-		var existing models.Issue
-		if err := s.db.GetJSON(key, &existing); err == nil {
-			if existing.Hash == issue.Hash {
-				return false, nil // No changes
-			}
-		}
-	}
-
+	issue.Hash = hash
 	if issue.UpdatedAt.IsZero() {
 		issue.UpdatedAt = time.Now()
 	}
-
-	return true, s.db.Set(key, issue)
+	return s.saveVersioned(key, func([]byte) (interface{}, string, error) {
+		return issue, hash, nil
+	}, issueIndexes)
 }
 
-// SavePullRequest saves or updates a pull request
+// SavePullRequest saves or updates a pull request, unless its content is
+// unchanged
 func (s *StorageService) SavePullRequest(pr models.PullRequest) (bool, error) {
 	key := "pr:" + pr.RepoID + "/" + pr.ID
-
-	// Generate hash if not set
-	if pr.Hash == "" {
-		pr.Hash = database.GenerateHash(pr.RepoID, pr.ID, pr.URL)
+	if pr.Source == "" {
+		pr.Source = models.SourceGitHub
 	}
 
-	// Check if exists and hash matches
-	exists, err := s.db.Exists(key)
+	hash, err := contentHash(pr)
 	if err != nil {
 		return false, err
 	}
-
-	if exists {
-		var existing models.PullRequest
-		if err := s.db.GetJSON(key, &existing); err == nil {
-			if existing.Hash == pr.Hash {
-				return false, nil // No changes
-			}
-		}
-	}
-
+	pr.Hash = hash
 	if pr.UpdatedAt.IsZero() {
 		pr.UpdatedAt = time.Now()
 	}
-
-	return true, s.db.Set(key, pr)
+	return s.saveVersioned(key, func([]byte) (interface{}, string, error) {
+		return pr, hash, nil
+	}, prIndexes)
 }
 
 // GetAllRepos retrieves all repositories
 func (s *StorageService) GetAllRepos() ([]models.Repo, error) {
 	repos := []models.Repo{}
-	items, err := s.db.GetAll("repo:")
-	if err != nil {
-		return nil, err
-	}
-
-	for key := range items {
-		var repo models.Repo
-		if err := s.db.GetJSON(key, &repo); err == nil {
-			repos = append(repos, repo)
-		}
-	}
-
-	return repos, nil
+	err := s.IterateRepos(func(repo models.Repo) error {
+		repos = append(repos, repo)
+		return nil
+	})
+	return repos, err
 }
 
 // GetAllContacts retrieves all contacts
 func (s *StorageService) GetAllContacts() ([]models.Contact, error) {
 	contacts := []models.Contact{}
-	items, err := s.db.GetAll("contact:")
+	err := s.IterateContacts(func(contact models.Contact) error {
+		contacts = append(contacts, contact)
+		return nil
+	})
+	return contacts, err
+}
+
+// ListContacts returns a page of contacts by login and the cursor of the
+// next page
+func (s *StorageService) ListContacts(page Page) ([]models.Contact, string, error) {
+	opts, err := page.scanOptions("contact:")
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-
-	for key := range items {
+	opts.Values = true
+	contacts := []models.Contact{}
+	next, err := s.scanPage(opts, func(_ string, v []byte) error {
 		var contact models.Contact
-		if err := s.db.GetJSON(key, &contact); err == nil {
-			contacts = append(contacts, contact)
+		if err := json.Unmarshal(v, &contact); err != nil {
+			return err
 		}
-	}
-
-	return contacts, nil
+		contacts = append(contacts, contact)
+		return nil
+	})
+	return contacts, next, err
 }
 
 // GetRepoIssues retrieves all issues for a repository
 func (s *StorageService) GetRepoIssues(repoID string) ([]models.Issue, error) {
-	issues := []models.Issue{}
-	key := "issue:" + repoID + "/"
-
-	err := s.db.IterateWithPrefix(key, func(k string, v []byte) error {
-		var issue models.Issue
-		if err := s.db.GetJSON(k, &issue); err == nil {
-			issues = append(issues, issue)
-		}
-		return nil
-	})
-
+	issues, _, err := s.ListIssues(ActivityQuery{RepoID: repoID}, Page{})
 	return issues, err
 }
 
+// CountRepoIssues counts the issues of a repository without reading them
+func (s *StorageService) CountRepoIssues(repoID string) (int, error) {
+	return s.db.CountByPrefix("issue:" + repoID + "/")
+}
+
 // GetRepoPullRequests retrieves all pull requests for a repository
 func (s *StorageService) GetRepoPullRequests(repoID string) ([]models.PullRequest, error) {
-	prs := []models.PullRequest{}
-	key := "pr:" + repoID + "/"
-
-	err := s.db.IterateWithPrefix(key, func(k string, v []byte) error {
-		var pr models.PullRequest
-		if err := s.db.GetJSON(k, &pr); err == nil {
-			prs = append(prs, pr)
-		}
-		return nil
-	})
-
+	prs, _, err := s.ListPullRequests(ActivityQuery{RepoID: repoID}, Page{})
 	return prs, err
 }
 
@@ -250,18 +309,34 @@ func (s *StorageService) DeleteRepo(owner, name string) error {
 	key := "repo:" + owner + "/" + name
 	repoID := owner + "/" + name
 
-	// Delete issues
-	s.db.IterateWithPrefix("issue:"+repoID+"/", func(k string, v []byte) error {
-		return s.db.Delete(k)
-	})
+	// Delete issues and PRs with their index keys
+	for prefix, indexes := range map[string]database.IndexFunc{
+		"issue:" + repoID + "/": issueIndexes,
+		"pr:" + repoID + "/":    prIndexes,
+	} {
+		keys, err := s.db.KeysWithPrefix(prefix)
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if err := s.db.DeleteWithIndex(k, indexes); err != nil {
+				return err
+			}
+		}
+	}
 
-	// Delete PRs
-	s.db.IterateWithPrefix("pr:"+repoID+"/", func(k string, v []byte) error {
-		return s.db.Delete(k)
-	})
+	// Delete dependencies
+	if err := s.deleteDependencies(repoID); err != nil {
+		return err
+	}
+
+	// Delete change logs
+	if err := s.deleteHistory(repoID); err != nil {
+		return err
+	}
 
 	// Delete repo
-	return s.db.Delete(key)
+	return s.db.DeleteWithIndex(key, repoIndexes)
 }
 
 // StatsSummary is a compact JSON with aggregated counts.
@@ -299,33 +374,3 @@ func (s *StorageService) GetCounts() (*StatsSummary, error) {
 		Repositories: repos,
 	}, nil
 }
-
-func (s *StorageService) GetIssuesPage(limit, offset int) ([]models.Issue, error) {
-	const prefix = "issue:"
-	out := make([]models.Issue, 0, limit)
-
-	count := 0
-	skipped := 0
-
-	err := s.db.IteratePrefix(prefix, func(_ []byte, v []byte) error {
-		if skipped < offset {
-			skipped++
-			return nil
-		}
-		if count >= limit {
-			return nil
-		}
-
-		var issue models.Issue
-		if err := json.Unmarshal(v, &issue); err != nil {
-			return err
-		}
-		out = append(out, issue)
-		count++
-		return nil
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to list issues: %w", err)
-	}
-	return out, nil
-}
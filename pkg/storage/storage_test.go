@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"testing"
+
+	"Fyne-on/pkg/models"
+)
+
+func TestMergeContactKeepsProfile(t *testing.T) {
+	s, _ := newTestStorage(t)
+	s.SaveContact(models.Contact{Login: "bob", Company: "Acme", Bio: "Gopher", Followers: 40})
+
+	// A contributor record knows little more than the login
+	merged, err := s.MergeContact(models.Contact{Login: "bob", URL: "https://github.com/bob", Contributions: 12})
+	if err != nil {
+		t.Fatalf("MergeContact failed: %v", err)
+	}
+	stored, _ := s.GetContact("bob")
+	for _, c := range []models.Contact{merged, *stored} {
+		if c.Followers != 40 || c.Company != "Acme" || c.Bio != "Gopher" || c.Contributions != 12 || c.URL != "https://github.com/bob" {
+			t.Errorf("Expected the profile merged with the contributor, got %+v", c)
+		}
+	}
+
+	merged, _ = s.MergeContact(models.Contact{Login: "carol"})
+	if merged.Login != "carol" || merged.Source != models.SourceGitHub {
+		t.Errorf("Expected a new contact to be saved as is, got %+v", merged)
+	}
+}
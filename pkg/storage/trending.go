@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"Fyne-on/pkg/models"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const trendingPrefix = "trending:"
+
+// trendingKey is trending:{since}:{language|all}:{date}; dates sort
+// lexically, so a prefix scan returns a filter's snapshots oldest first
+func trendingKey(since, language, date string) string {
+	return trendingFilterPrefix(since, language) + date
+}
+
+func trendingFilterPrefix(since, language string) string {
+	if since == "" {
+		since = "daily"
+	}
+	language = strings.ToLower(language)
+	if language == "" {
+		language = "all"
+	}
+	return trendingPrefix + since + ":" + language + ":"
+}
+
+// SaveTrendingSnapshot stores a trending snapshot. A second snapshot of the
+// same page on the same day replaces the first.
+func (s *StorageService) SaveTrendingSnapshot(snap models.TrendingSnapshot) error {
+	if snap.FetchedAt.IsZero() {
+		snap.FetchedAt = time.Now()
+	}
+	if snap.Date == "" {
+		snap.Date = snap.FetchedAt.UTC().Format("2006-01-02")
+	}
+	if snap.Since == "" {
+		snap.Since = "daily"
+	}
+	snap.Language = strings.ToLower(snap.Language)
+	if err := s.db.Set(trendingKey(snap.Since, snap.Language, snap.Date), snap); err != nil {
+		return fmt.Errorf("failed to save trending snapshot: %w", err)
+	}
+	return nil
+}
+
+// GetTrendingSnapshots returns the snapshots of one trending page between
+// from and to (YYYY-MM-DD, inclusive, either may be empty), oldest first
+func (s *StorageService) GetTrendingSnapshots(since, language, from, to string) ([]models.TrendingSnapshot, error) {
+	out := []models.TrendingSnapshot{}
+	err := s.db.IteratePrefix(trendingFilterPrefix(since, language), func(_ []byte, v []byte) error {
+		var snap models.TrendingSnapshot
+		if err := json.Unmarshal(v, &snap); err != nil {
+			return err
+		}
+		if (from != "" && snap.Date < from) || (to != "" && snap.Date > to) {
+			return nil
+		}
+		out = append(out, snap)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trending snapshots: %w", err)
+	}
+	return out, nil
+}